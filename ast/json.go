@@ -0,0 +1,851 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/NilCent/eval/token"
+)
+
+// Version is the version of the JSON encoding of programs written by
+// Marshal. Unmarshal rejects programs of other versions.
+const Version = 1
+
+type envelope struct {
+	Version int      `json:"version"`
+	Program *Program `json:"program"`
+}
+
+// Marshal encodes program as JSON, wrapped in an envelope recording the
+// version of the encoding. Every node is an object whose "kind" names
+// its type, with its token and its fields, the annotations of the
+// resolver included, so that decoding gives back an equivalent program.
+func Marshal(program *Program) ([]byte, error) {
+	return json.Marshal(envelope{Version: Version, Program: program})
+}
+
+// Unmarshal decodes a program encoded by Marshal. The values of its
+// literals are built by the evaluator, not shared as the parser does.
+func Unmarshal(data []byte) (*Program, error) {
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	if e.Version != Version {
+		return nil, fmt.Errorf("ast: unsupported encoding version %d, want %d", e.Version, Version)
+	}
+	if e.Program == nil {
+		return nil, fmt.Errorf("ast: no program")
+	}
+	return e.Program, nil
+}
+
+// unmarshalNode checks that the node encoded by data is of kind want
+// and decodes it into v.
+func unmarshalNode(data []byte, want string, v interface{}) error {
+	kind, err := kindOf(data)
+	if err != nil {
+		return err
+	}
+	if kind != want {
+		return fmt.Errorf("ast: expected a %s, got kind %q", want, kind)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func kindOf(data []byte) (string, error) {
+	var header struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return "", err
+	}
+	return header.Kind, nil
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+// errMissing reports a node of the given kind decoded without a field
+// the parser always sets.
+func errMissing(kind, field string) error {
+	return fmt.Errorf("ast: %s without %s", kind, field)
+}
+
+var statements = map[string]func() Statement{
+	"LetStatement":        func() Statement { return &LetStatement{} },
+	"ReturnStatement":     func() Statement { return &ReturnStatement{} },
+	"ThrowStatement":      func() Statement { return &ThrowStatement{} },
+	"ImportStatement":     func() Statement { return &ImportStatement{} },
+	"ExportStatement":     func() Statement { return &ExportStatement{} },
+	"ExpressionStatement": func() Statement { return &ExpressionStatement{} },
+	"BlockStatement":      func() Statement { return &BlockStatement{} },
+}
+
+var expressions = map[string]func() Expression{
+	"Identifier":        func() Expression { return &Identifier{} },
+	"Boolean":           func() Expression { return &Boolean{} },
+	"IntegerLiteral":    func() Expression { return &IntegerLiteral{} },
+	"BigIntegerLiteral": func() Expression { return &BigIntegerLiteral{} },
+	"FloatLiteral":      func() Expression { return &FloatLiteral{} },
+	"DecimalLiteral":    func() Expression { return &DecimalLiteral{} },
+	"DurationLiteral":   func() Expression { return &DurationLiteral{} },
+	"StringLiteral":     func() Expression { return &StringLiteral{} },
+	"PrefixExpression":  func() Expression { return &PrefixExpression{} },
+	"InfixExpression":   func() Expression { return &InfixExpression{} },
+	"MemberExpression":  func() Expression { return &MemberExpression{} },
+	"ArrayLiteral":      func() Expression { return &ArrayLiteral{} },
+	"IndexExpression":   func() Expression { return &IndexExpression{} },
+	"HashLiteral":       func() Expression { return &HashLiteral{} },
+	"IfExpression":      func() Expression { return &IfExpression{} },
+	"TryExpression":     func() Expression { return &TryExpression{} },
+	"FunctionLiteral":   func() Expression { return &FunctionLiteral{} },
+	"CallExpression":    func() Expression { return &CallExpression{} },
+}
+
+func unmarshalStatement(data json.RawMessage) (Statement, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	kind, err := kindOf(data)
+	if err != nil {
+		return nil, err
+	}
+	newStatement, ok := statements[kind]
+	if !ok {
+		return nil, fmt.Errorf("ast: unknown statement kind %q", kind)
+	}
+	stmt := newStatement()
+	if err := json.Unmarshal(data, stmt); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func unmarshalStatements(data []json.RawMessage) ([]Statement, error) {
+	if data == nil {
+		return nil, nil
+	}
+	stmts := make([]Statement, 0, len(data))
+	for _, raw := range data {
+		if isNull(raw) {
+			return nil, fmt.Errorf("ast: null statement")
+		}
+		stmt, err := unmarshalStatement(raw)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts, nil
+}
+
+func unmarshalExpression(data json.RawMessage) (Expression, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	kind, err := kindOf(data)
+	if err != nil {
+		return nil, err
+	}
+	newExpression, ok := expressions[kind]
+	if !ok {
+		return nil, fmt.Errorf("ast: unknown expression kind %q", kind)
+	}
+	exp := newExpression()
+	if err := json.Unmarshal(data, exp); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+// unmarshalRequired decodes the expression a node of the given kind
+// must have in field.
+func unmarshalRequired(data json.RawMessage, kind, field string) (Expression, error) {
+	if isNull(data) {
+		return nil, errMissing(kind, field)
+	}
+	return unmarshalExpression(data)
+}
+
+func unmarshalExpressions(data []json.RawMessage) ([]Expression, error) {
+	if data == nil {
+		return nil, nil
+	}
+	exps := make([]Expression, 0, len(data))
+	for _, raw := range data {
+		if isNull(raw) {
+			return nil, fmt.Errorf("ast: null expression")
+		}
+		exp, err := unmarshalExpression(raw)
+		if err != nil {
+			return nil, err
+		}
+		exps = append(exps, exp)
+	}
+	return exps, nil
+}
+
+// Program and statements
+
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind       string      `json:"kind"`
+		Statements []Statement `json:"statements"`
+	}{"Program", p.Statements})
+}
+
+func (p *Program) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Statements []json.RawMessage `json:"statements"`
+	}
+	if err := unmarshalNode(data, "Program", &v); err != nil {
+		return err
+	}
+	stmts, err := unmarshalStatements(v.Statements)
+	if err != nil {
+		return err
+	}
+	*p = Program{Statements: stmts}
+	return nil
+}
+
+func (ls *LetStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string      `json:"kind"`
+		Token token.Token `json:"token"`
+		Name  *Identifier `json:"name"`
+		Value Expression  `json:"value"`
+	}{"LetStatement", ls.Token, ls.Name, ls.Value})
+}
+
+func (ls *LetStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token token.Token     `json:"token"`
+		Name  *Identifier     `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := unmarshalNode(data, "LetStatement", &v); err != nil {
+		return err
+	}
+	if v.Name == nil {
+		return errMissing("LetStatement", "name")
+	}
+	value, err := unmarshalRequired(v.Value, "LetStatement", "value")
+	if err != nil {
+		return err
+	}
+	*ls = LetStatement{Token: v.Token, Name: v.Name, Value: value}
+	return nil
+}
+
+func (rs *ReturnStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind        string      `json:"kind"`
+		Token       token.Token `json:"token"`
+		ReturnValue Expression  `json:"returnValue"`
+	}{"ReturnStatement", rs.Token, rs.ReturnValue})
+}
+
+func (rs *ReturnStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token       token.Token     `json:"token"`
+		ReturnValue json.RawMessage `json:"returnValue"`
+	}
+	if err := unmarshalNode(data, "ReturnStatement", &v); err != nil {
+		return err
+	}
+	value, err := unmarshalRequired(v.ReturnValue, "ReturnStatement", "returnValue")
+	if err != nil {
+		return err
+	}
+	*rs = ReturnStatement{Token: v.Token, ReturnValue: value}
+	return nil
+}
+
+func (ts *ThrowStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string      `json:"kind"`
+		Token token.Token `json:"token"`
+		Value Expression  `json:"value"`
+	}{"ThrowStatement", ts.Token, ts.Value})
+}
+
+func (ts *ThrowStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token token.Token     `json:"token"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := unmarshalNode(data, "ThrowStatement", &v); err != nil {
+		return err
+	}
+	value, err := unmarshalRequired(v.Value, "ThrowStatement", "value")
+	if err != nil {
+		return err
+	}
+	*ts = ThrowStatement{Token: v.Token, Value: value}
+	return nil
+}
+
+func (is *ImportStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string         `json:"kind"`
+		Token token.Token    `json:"token"`
+		Path  *StringLiteral `json:"path"`
+		Alias *Identifier    `json:"alias"`
+	}{"ImportStatement", is.Token, is.Path, is.Alias})
+}
+
+func (is *ImportStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token token.Token    `json:"token"`
+		Path  *StringLiteral `json:"path"`
+		Alias *Identifier    `json:"alias"`
+	}
+	if err := unmarshalNode(data, "ImportStatement", &v); err != nil {
+		return err
+	}
+	if v.Path == nil {
+		return errMissing("ImportStatement", "path")
+	}
+	*is = ImportStatement{Token: v.Token, Path: v.Path, Alias: v.Alias}
+	return nil
+}
+
+func (es *ExportStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind      string        `json:"kind"`
+		Token     token.Token   `json:"token"`
+		Statement *LetStatement `json:"statement"`
+	}{"ExportStatement", es.Token, es.Statement})
+}
+
+func (es *ExportStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token     token.Token   `json:"token"`
+		Statement *LetStatement `json:"statement"`
+	}
+	if err := unmarshalNode(data, "ExportStatement", &v); err != nil {
+		return err
+	}
+	if v.Statement == nil {
+		return errMissing("ExportStatement", "statement")
+	}
+	*es = ExportStatement{Token: v.Token, Statement: v.Statement}
+	return nil
+}
+
+func (es *ExpressionStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind       string      `json:"kind"`
+		Token      token.Token `json:"token"`
+		Expression Expression  `json:"expression"`
+	}{"ExpressionStatement", es.Token, es.Expression})
+}
+
+func (es *ExpressionStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token      token.Token     `json:"token"`
+		Expression json.RawMessage `json:"expression"`
+	}
+	if err := unmarshalNode(data, "ExpressionStatement", &v); err != nil {
+		return err
+	}
+	exp, err := unmarshalRequired(v.Expression, "ExpressionStatement", "expression")
+	if err != nil {
+		return err
+	}
+	*es = ExpressionStatement{Token: v.Token, Expression: exp}
+	return nil
+}
+
+func (bs *BlockStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind       string      `json:"kind"`
+		Token      token.Token `json:"token"`
+		Statements []Statement `json:"statements"`
+	}{"BlockStatement", bs.Token, bs.Statements})
+}
+
+func (bs *BlockStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token      token.Token       `json:"token"`
+		Statements []json.RawMessage `json:"statements"`
+	}
+	if err := unmarshalNode(data, "BlockStatement", &v); err != nil {
+		return err
+	}
+	stmts, err := unmarshalStatements(v.Statements)
+	if err != nil {
+		return err
+	}
+	*bs = BlockStatement{Token: v.Token, Statements: stmts}
+	return nil
+}
+
+// Expressions
+
+type identifierJSON struct {
+	Kind  string      `json:"kind"`
+	Token token.Token `json:"token"`
+	Value string      `json:"value"`
+	Local bool        `json:"local,omitempty"`
+	Depth int         `json:"depth,omitempty"`
+	Slot  int         `json:"slot,omitempty"`
+}
+
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(identifierJSON{"Identifier", i.Token, i.Value, i.Local, i.Depth, i.Slot})
+}
+
+func (i *Identifier) UnmarshalJSON(data []byte) error {
+	var v identifierJSON
+	if err := unmarshalNode(data, "Identifier", &v); err != nil {
+		return err
+	}
+	*i = Identifier{Token: v.Token, Value: v.Value, Local: v.Local, Depth: v.Depth, Slot: v.Slot}
+	return nil
+}
+
+type booleanJSON struct {
+	Kind  string      `json:"kind"`
+	Token token.Token `json:"token"`
+	Value bool        `json:"value"`
+}
+
+func (b *Boolean) MarshalJSON() ([]byte, error) {
+	return json.Marshal(booleanJSON{"Boolean", b.Token, b.Value})
+}
+
+func (b *Boolean) UnmarshalJSON(data []byte) error {
+	var v booleanJSON
+	if err := unmarshalNode(data, "Boolean", &v); err != nil {
+		return err
+	}
+	*b = Boolean{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+type integerJSON struct {
+	Kind  string      `json:"kind"`
+	Token token.Token `json:"token"`
+	Value int64       `json:"value"`
+}
+
+func (il *IntegerLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(integerJSON{"IntegerLiteral", il.Token, il.Value})
+}
+
+func (il *IntegerLiteral) UnmarshalJSON(data []byte) error {
+	var v integerJSON
+	if err := unmarshalNode(data, "IntegerLiteral", &v); err != nil {
+		return err
+	}
+	*il = IntegerLiteral{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+// The values of big integers, decimals and floats are written as
+// strings, which JSON readers do not round.
+
+type numberJSON struct {
+	Kind  string      `json:"kind"`
+	Token token.Token `json:"token"`
+	Value string      `json:"value"`
+	Scale int         `json:"scale,omitempty"`
+}
+
+func (bl *BigIntegerLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(numberJSON{Kind: "BigIntegerLiteral", Token: bl.Token, Value: bl.Value.String()})
+}
+
+func (bl *BigIntegerLiteral) UnmarshalJSON(data []byte) error {
+	var v numberJSON
+	if err := unmarshalNode(data, "BigIntegerLiteral", &v); err != nil {
+		return err
+	}
+	value, ok := new(big.Int).SetString(v.Value, 10)
+	if !ok {
+		return fmt.Errorf("ast: invalid big integer %q", v.Value)
+	}
+	*bl = BigIntegerLiteral{Token: v.Token, Value: value}
+	return nil
+}
+
+func (fl *FloatLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(numberJSON{Kind: "FloatLiteral", Token: fl.Token, Value: strconv.FormatFloat(fl.Value, 'g', -1, 64)})
+}
+
+func (fl *FloatLiteral) UnmarshalJSON(data []byte) error {
+	var v numberJSON
+	if err := unmarshalNode(data, "FloatLiteral", &v); err != nil {
+		return err
+	}
+	value, err := strconv.ParseFloat(v.Value, 64)
+	if err != nil {
+		return fmt.Errorf("ast: invalid float %q", v.Value)
+	}
+	*fl = FloatLiteral{Token: v.Token, Value: value}
+	return nil
+}
+
+func (dl *DecimalLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(numberJSON{"DecimalLiteral", dl.Token, dl.Value.RatString(), dl.Scale})
+}
+
+func (dl *DecimalLiteral) UnmarshalJSON(data []byte) error {
+	var v numberJSON
+	if err := unmarshalNode(data, "DecimalLiteral", &v); err != nil {
+		return err
+	}
+	value, ok := new(big.Rat).SetString(v.Value)
+	if !ok {
+		return fmt.Errorf("ast: invalid decimal %q", v.Value)
+	}
+	*dl = DecimalLiteral{Token: v.Token, Value: value, Scale: v.Scale}
+	return nil
+}
+
+type durationJSON struct {
+	Kind  string        `json:"kind"`
+	Token token.Token   `json:"token"`
+	Value time.Duration `json:"value"` // in nanoseconds
+}
+
+func (dl *DurationLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(durationJSON{"DurationLiteral", dl.Token, dl.Value})
+}
+
+func (dl *DurationLiteral) UnmarshalJSON(data []byte) error {
+	var v durationJSON
+	if err := unmarshalNode(data, "DurationLiteral", &v); err != nil {
+		return err
+	}
+	*dl = DurationLiteral{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+type stringJSON struct {
+	Kind  string      `json:"kind"`
+	Token token.Token `json:"token"`
+	Value string      `json:"value"`
+}
+
+func (sl *StringLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(stringJSON{"StringLiteral", sl.Token, sl.Value})
+}
+
+func (sl *StringLiteral) UnmarshalJSON(data []byte) error {
+	var v stringJSON
+	if err := unmarshalNode(data, "StringLiteral", &v); err != nil {
+		return err
+	}
+	*sl = StringLiteral{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+func (pe *PrefixExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind     string      `json:"kind"`
+		Token    token.Token `json:"token"`
+		Operator string      `json:"operator"`
+		Right    Expression  `json:"right"`
+	}{"PrefixExpression", pe.Token, pe.Operator, pe.Right})
+}
+
+func (pe *PrefixExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token    token.Token     `json:"token"`
+		Operator string          `json:"operator"`
+		Right    json.RawMessage `json:"right"`
+	}
+	if err := unmarshalNode(data, "PrefixExpression", &v); err != nil {
+		return err
+	}
+	right, err := unmarshalRequired(v.Right, "PrefixExpression", "right")
+	if err != nil {
+		return err
+	}
+	*pe = PrefixExpression{Token: v.Token, Operator: v.Operator, Right: right}
+	return nil
+}
+
+func (ie *InfixExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind     string      `json:"kind"`
+		Token    token.Token `json:"token"`
+		Left     Expression  `json:"left"`
+		Operator string      `json:"operator"`
+		Right    Expression  `json:"right"`
+	}{"InfixExpression", ie.Token, ie.Left, ie.Operator, ie.Right})
+}
+
+func (ie *InfixExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token    token.Token     `json:"token"`
+		Left     json.RawMessage `json:"left"`
+		Operator string          `json:"operator"`
+		Right    json.RawMessage `json:"right"`
+	}
+	if err := unmarshalNode(data, "InfixExpression", &v); err != nil {
+		return err
+	}
+	left, err := unmarshalRequired(v.Left, "InfixExpression", "left")
+	if err != nil {
+		return err
+	}
+	right, err := unmarshalRequired(v.Right, "InfixExpression", "right")
+	if err != nil {
+		return err
+	}
+	*ie = InfixExpression{Token: v.Token, Left: left, Operator: v.Operator, Right: right}
+	return nil
+}
+
+func (me *MemberExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind     string      `json:"kind"`
+		Token    token.Token `json:"token"`
+		Object   Expression  `json:"object"`
+		Property *Identifier `json:"property"`
+	}{"MemberExpression", me.Token, me.Object, me.Property})
+}
+
+func (me *MemberExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token    token.Token     `json:"token"`
+		Object   json.RawMessage `json:"object"`
+		Property *Identifier     `json:"property"`
+	}
+	if err := unmarshalNode(data, "MemberExpression", &v); err != nil {
+		return err
+	}
+	if v.Property == nil {
+		return errMissing("MemberExpression", "property")
+	}
+	obj, err := unmarshalRequired(v.Object, "MemberExpression", "object")
+	if err != nil {
+		return err
+	}
+	*me = MemberExpression{Token: v.Token, Object: obj, Property: v.Property}
+	return nil
+}
+
+func (al *ArrayLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind     string       `json:"kind"`
+		Token    token.Token  `json:"token"`
+		Elements []Expression `json:"elements"`
+	}{"ArrayLiteral", al.Token, al.Elements})
+}
+
+func (al *ArrayLiteral) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token    token.Token       `json:"token"`
+		Elements []json.RawMessage `json:"elements"`
+	}
+	if err := unmarshalNode(data, "ArrayLiteral", &v); err != nil {
+		return err
+	}
+	elements, err := unmarshalExpressions(v.Elements)
+	if err != nil {
+		return err
+	}
+	*al = ArrayLiteral{Token: v.Token, Elements: elements}
+	return nil
+}
+
+func (ie *IndexExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string      `json:"kind"`
+		Token token.Token `json:"token"`
+		Left  Expression  `json:"left"`
+		Index Expression  `json:"index"`
+	}{"IndexExpression", ie.Token, ie.Left, ie.Index})
+}
+
+func (ie *IndexExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token token.Token     `json:"token"`
+		Left  json.RawMessage `json:"left"`
+		Index json.RawMessage `json:"index"`
+	}
+	if err := unmarshalNode(data, "IndexExpression", &v); err != nil {
+		return err
+	}
+	left, err := unmarshalRequired(v.Left, "IndexExpression", "left")
+	if err != nil {
+		return err
+	}
+	index, err := unmarshalRequired(v.Index, "IndexExpression", "index")
+	if err != nil {
+		return err
+	}
+	*ie = IndexExpression{Token: v.Token, Left: left, Index: index}
+	return nil
+}
+
+func (hl *HashLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind   string       `json:"kind"`
+		Token  token.Token  `json:"token"`
+		Keys   []Expression `json:"keys"`
+		Values []Expression `json:"values"`
+	}{"HashLiteral", hl.Token, hl.Keys, hl.Values})
+}
+
+func (hl *HashLiteral) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token  token.Token       `json:"token"`
+		Keys   []json.RawMessage `json:"keys"`
+		Values []json.RawMessage `json:"values"`
+	}
+	if err := unmarshalNode(data, "HashLiteral", &v); err != nil {
+		return err
+	}
+	if len(v.Keys) != len(v.Values) {
+		return fmt.Errorf("ast: hash literal with %d keys and %d values", len(v.Keys), len(v.Values))
+	}
+	keys, err := unmarshalExpressions(v.Keys)
+	if err != nil {
+		return err
+	}
+	values, err := unmarshalExpressions(v.Values)
+	if err != nil {
+		return err
+	}
+	*hl = HashLiteral{Token: v.Token, Keys: keys, Values: values}
+	return nil
+}
+
+func (ie *IfExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind        string          `json:"kind"`
+		Token       token.Token     `json:"token"`
+		Condition   Expression      `json:"condition"`
+		Consequence *BlockStatement `json:"consequence"`
+		Alternative *BlockStatement `json:"alternative"`
+	}{"IfExpression", ie.Token, ie.Condition, ie.Consequence, ie.Alternative})
+}
+
+func (ie *IfExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token       token.Token     `json:"token"`
+		Condition   json.RawMessage `json:"condition"`
+		Consequence *BlockStatement `json:"consequence"`
+		Alternative *BlockStatement `json:"alternative"`
+	}
+	if err := unmarshalNode(data, "IfExpression", &v); err != nil {
+		return err
+	}
+	if v.Consequence == nil {
+		return errMissing("IfExpression", "consequence")
+	}
+	condition, err := unmarshalRequired(v.Condition, "IfExpression", "condition")
+	if err != nil {
+		return err
+	}
+	*ie = IfExpression{Token: v.Token, Condition: condition, Consequence: v.Consequence, Alternative: v.Alternative}
+	return nil
+}
+
+type tryJSON struct {
+	Kind       string          `json:"kind"`
+	Token      token.Token     `json:"token"`
+	Block      *BlockStatement `json:"block"`
+	Param      *Identifier     `json:"param"`
+	Catch      *BlockStatement `json:"catch"`
+	Finally    *BlockStatement `json:"finally"`
+	CatchSlots []string        `json:"catchSlots,omitempty"`
+}
+
+func (te *TryExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tryJSON{"TryExpression", te.Token, te.Block, te.Param, te.Catch, te.Finally, te.CatchSlots})
+}
+
+func (te *TryExpression) UnmarshalJSON(data []byte) error {
+	var v tryJSON
+	if err := unmarshalNode(data, "TryExpression", &v); err != nil {
+		return err
+	}
+	switch {
+	case v.Block == nil:
+		return errMissing("TryExpression", "block")
+	case v.Catch != nil && v.Param == nil:
+		return errMissing("TryExpression", "param")
+	case v.Catch == nil && v.Param != nil:
+		return errMissing("TryExpression", "catch")
+	}
+	*te = TryExpression{
+		Token:      v.Token,
+		Block:      v.Block,
+		Param:      v.Param,
+		Catch:      v.Catch,
+		Finally:    v.Finally,
+		CatchSlots: v.CatchSlots,
+	}
+	return nil
+}
+
+type functionJSON struct {
+	Kind       string          `json:"kind"`
+	Token      token.Token     `json:"token"`
+	Parameters []*Identifier   `json:"parameters"`
+	Body       *BlockStatement `json:"body"`
+	Slots      []string        `json:"slots,omitempty"`
+}
+
+func (fl *FunctionLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(functionJSON{"FunctionLiteral", fl.Token, fl.Parameters, fl.Body, fl.Slots})
+}
+
+func (fl *FunctionLiteral) UnmarshalJSON(data []byte) error {
+	var v functionJSON
+	if err := unmarshalNode(data, "FunctionLiteral", &v); err != nil {
+		return err
+	}
+	if v.Body == nil {
+		return errMissing("FunctionLiteral", "body")
+	}
+	for _, param := range v.Parameters {
+		if param == nil {
+			return fmt.Errorf("ast: null parameter")
+		}
+	}
+	*fl = FunctionLiteral{Token: v.Token, Parameters: v.Parameters, Body: v.Body, Slots: v.Slots}
+	return nil
+}
+
+func (ce *CallExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind      string       `json:"kind"`
+		Token     token.Token  `json:"token"`
+		Function  Expression   `json:"function"`
+		Arguments []Expression `json:"arguments"`
+	}{"CallExpression", ce.Token, ce.Function, ce.Arguments})
+}
+
+func (ce *CallExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token     token.Token       `json:"token"`
+		Function  json.RawMessage   `json:"function"`
+		Arguments []json.RawMessage `json:"arguments"`
+	}
+	if err := unmarshalNode(data, "CallExpression", &v); err != nil {
+		return err
+	}
+	function, err := unmarshalRequired(v.Function, "CallExpression", "function")
+	if err != nil {
+		return err
+	}
+	args, err := unmarshalExpressions(v.Arguments)
+	if err != nil {
+		return err
+	}
+	*ce = CallExpression{Token: v.Token, Function: function, Arguments: args}
+	return nil
+}
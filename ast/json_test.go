@@ -0,0 +1,197 @@
+package ast_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/resolver"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	return program
+}
+
+// inputs covers every kind of node.
+var inputs = []string{
+	"let x = 5;\nlet y = -x + 2 * 3;\ny",
+	"9223372036854775807 + 1",
+	"123456789012345678901234567890 * 2",
+	"0.1 + 0.2",
+	"1.25dec + 0.10dec",
+	"1h30m + 15m",
+	"\"a\\n\\\"b\\\"\" + \"c\"",
+	"!true == false",
+	"let a = [1, [2, 3]];\na[1][0]",
+	"let h = {\"k\": 1, 2: [], true: {}};\nh[\"k\"] + h.k",
+	"if (1 < 2) { 10 } else { 20 }",
+	"if (false) { 1 }",
+	"let f = fn(a, b) {\n  if (a > b) { return a; }\n  b\n};\nf(3, 7)",
+	"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } };\nfact(20)",
+	"let r = try {\n  throw \"boom\";\n} catch (e) {\n  e.message\n} finally {\n  1\n};\nr",
+	"try { 1 / 0 } catch (e) { e.line }",
+	"fn() { }()",
+	"export let k = fn(x) { x };\nk(2)",
+	"let a = 1;\nlet b = 0;\na / b",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, input := range inputs {
+		program := parse(t, input)
+		data, err := ast.Marshal(program)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+
+		decoded, err := ast.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		if decoded.String() != program.String() {
+			t.Errorf("%s: decoded %q, want %q", input, decoded.String(), program.String())
+		}
+
+		again, err := ast.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Errorf("%s: encoding is not stable:\n%s\n%s", input, data, again)
+		}
+
+		want := describe(evaluator.Eval(program, object.NewEnvironment()))
+		got := describe(evaluator.Eval(decoded, object.NewEnvironment()))
+		if got != want {
+			t.Errorf("%s: decoded program evaluates to %s, want %s", input, got, want)
+		}
+	}
+}
+
+func describe(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nothing"
+	case *object.Error:
+		return fmt.Sprintf("ERROR line %d: %s", obj.Line, obj.Message)
+	}
+	return fmt.Sprintf("%s %s", obj.Type(), obj.Inspect())
+}
+
+func TestRoundTripResolved(t *testing.T) {
+	program := parse(t, "let f = fn(a) { try { a } catch (e) { e } };\nf(1)")
+	if err := resolver.Resolve(program, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ast.Marshal(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ast.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := decoded.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if got := strings.Join(fn.Slots, " "); got != "a" {
+		t.Errorf("wrong function slots. got=%q", got)
+	}
+	try := fn.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.TryExpression)
+	if got := strings.Join(try.CatchSlots, " "); got != "e" {
+		t.Errorf("wrong catch slots. got=%q", got)
+	}
+	ident := try.Catch.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.Identifier)
+	if !ident.Local || ident.Depth != 0 || ident.Slot != 0 {
+		t.Errorf("wrong binding of e: %+v", ident)
+	}
+	outer := try.Block.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.Identifier)
+	if !outer.Local || outer.Slot != 0 {
+		t.Errorf("wrong binding of a: %+v", outer)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	data, err := ast.Marshal(parse(t, "let x = 1;\nx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"version":1,"program":{"kind":"Program","statements":[` +
+		`{"kind":"LetStatement","token":{"type":"LET","literal":"let","line":1,"column":1},` +
+		`"name":{"kind":"Identifier","token":{"type":"IDENT","literal":"x","line":1,"column":5},"value":"x"},` +
+		`"value":{"kind":"IntegerLiteral","token":{"type":"INT","literal":"1","line":1,"column":9},"value":1}},` +
+		`{"kind":"ExpressionStatement","token":{"type":"IDENT","literal":"x","line":2,"column":1},` +
+		`"expression":{"kind":"Identifier","token":{"type":"IDENT","literal":"x","line":2,"column":1},"value":"x"}}]}}`
+	if string(data) != expected {
+		t.Errorf("wrong encoding.\nwant=%s\ngot= %s", expected, data)
+	}
+}
+
+// program encodes a program made of the statement encoded by stmt.
+func program(stmt string) string {
+	return `{"version":1,"program":{"kind":"Program","statements":[` + stmt + `]}}`
+}
+
+// expression encodes a program made of the expression encoded by exp.
+func expression(exp string) string {
+	return program(`{"kind":"ExpressionStatement","expression":` + exp + `}`)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`{"version":2,"program":{"kind":"Program","statements":[]}}`, "ast: unsupported encoding version 2, want 1"},
+		{`{"program":{"kind":"Program","statements":[]}}`, "ast: unsupported encoding version 0, want 1"},
+		{`{"version":1}`, "ast: no program"},
+		{`{"version":1,"program":{"kind":"Block","statements":[]}}`, `ast: expected a Program, got kind "Block"`},
+		{`{"version":1,"program":{"kind":"Program","statements":[{"kind":"Goto"}]}}`, `ast: unknown statement kind "Goto"`},
+		{`{"version":1,"program":{"kind":"Program","statements":[{"kind":"ExpressionStatement","expression":{"kind":"LetStatement"}}]}}`,
+			`ast: unknown expression kind "LetStatement"`},
+		{`{"version":1,"program":{"kind":"Program","statements":[{"kind":"ExpressionStatement","expression":{"kind":"BigIntegerLiteral","value":"1x"}}]}}`,
+			`ast: invalid big integer "1x"`},
+		{`{"version":1,"program":{"kind":"Program","statements":[{"kind":"ExpressionStatement","expression":{"kind":"HashLiteral","keys":[],"values":[null]}}]}}`,
+			"ast: hash literal with 0 keys and 1 values"},
+		// fields the parser always sets
+		{program(`{"kind":"LetStatement","value":{"kind":"Boolean","value":true}}`), "ast: LetStatement without name"},
+		{program(`{"kind":"LetStatement","name":{"kind":"Identifier","value":"x"}}`), "ast: LetStatement without value"},
+		{program(`{"kind":"ReturnStatement"}`), "ast: ReturnStatement without returnValue"},
+		{program(`{"kind":"ThrowStatement","value":null}`), "ast: ThrowStatement without value"},
+		{program(`{"kind":"ImportStatement"}`), "ast: ImportStatement without path"},
+		{program(`{"kind":"ExportStatement"}`), "ast: ExportStatement without statement"},
+		{program(`{"kind":"ExpressionStatement"}`), "ast: ExpressionStatement without expression"},
+		{program(`null`), "ast: null statement"},
+		{expression(`{"kind":"PrefixExpression","operator":"-"}`), "ast: PrefixExpression without right"},
+		{expression(`{"kind":"InfixExpression","operator":"+","right":{"kind":"IntegerLiteral","value":1}}`), "ast: InfixExpression without left"},
+		{expression(`{"kind":"InfixExpression","operator":"+","left":{"kind":"IntegerLiteral","value":1}}`), "ast: InfixExpression without right"},
+		{expression(`{"kind":"MemberExpression","property":{"kind":"Identifier","value":"x"}}`), "ast: MemberExpression without object"},
+		{expression(`{"kind":"MemberExpression","object":{"kind":"Identifier","value":"x"}}`), "ast: MemberExpression without property"},
+		{expression(`{"kind":"IndexExpression","index":{"kind":"IntegerLiteral","value":1}}`), "ast: IndexExpression without left"},
+		{expression(`{"kind":"IndexExpression","left":{"kind":"Identifier","value":"x"}}`), "ast: IndexExpression without index"},
+		{expression(`{"kind":"IfExpression","consequence":{"kind":"BlockStatement","statements":[]}}`), "ast: IfExpression without condition"},
+		{expression(`{"kind":"IfExpression","condition":{"kind":"Boolean","value":true}}`), "ast: IfExpression without consequence"},
+		{expression(`{"kind":"TryExpression","finally":{"kind":"BlockStatement","statements":[]}}`), "ast: TryExpression without block"},
+		{expression(`{"kind":"TryExpression","block":{"kind":"BlockStatement"},"catch":{"kind":"BlockStatement"}}`), "ast: TryExpression without param"},
+		{expression(`{"kind":"FunctionLiteral","parameters":[]}`), "ast: FunctionLiteral without body"},
+		{expression(`{"kind":"FunctionLiteral","parameters":[null],"body":{"kind":"BlockStatement"}}`), "ast: null parameter"},
+		{expression(`{"kind":"CallExpression","arguments":[]}`), "ast: CallExpression without function"},
+		{expression(`{"kind":"ArrayLiteral","elements":[null]}`), "ast: null expression"},
+	}
+
+	for _, tc := range testCases {
+		_, err := ast.Unmarshal([]byte(tc.input))
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("%s: expected error %q, got %v", tc.input, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Command eval runs a script and prints the value of its last
+// statement.
+//
+// Usage:
+//
+//	eval [-var name=value ...] [-debug] file
+//	eval [-var name=value ...] [-debug] -e 'expr'
+//	eval [-var name=value ...]
+//
+// A file named - is read from the standard input. Without a file nor
+// -e, eval starts an interactive session, see package repl. Each -var binds a
+// variable of the script to a value written as a literal, such as 10,
+// 19.99dec, true, "text" or [1, 2]; other values are bound as strings.
+//
+// With -debug, the script stops before its first line and reads
+// debugger commands such as break 12, step or print x from the
+// standard input, see package debugger.
+//
+// eval exits with status 1 when the script raises an error, 2 when it
+// does not parse, 3 when it cannot be read or the arguments are wrong
+// and 4 when it is rejected before it runs, such as for reading an
+// undefined variable.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NilCent/eval"
+	"github.com/NilCent/eval/debugger"
+	"github.com/NilCent/eval/module"
+	"github.com/NilCent/eval/repl"
+)
+
+const (
+	exitRuntime = 1
+	exitSyntax  = 2
+	exitUsage   = 3
+	exitStatic  = 4
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// vars collects the -var flags.
+type vars []string
+
+func (v *vars) String() string {
+	return strings.Join(*v, ", ")
+}
+
+func (v *vars) Set(s string) error {
+	name, _, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected name=value, got %q", s)
+	}
+	if !isIdentifier(name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	*v = append(*v, s)
+	return nil
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: eval [-var name=value ...] [-debug] [file | - | -e 'expr']")
+		flags.PrintDefaults()
+	}
+	expr := flags.String("e", "", "evaluate `expr` instead of a file")
+	var bindings vars
+	flags.Var(&bindings, "var", "bind the variable `name=value`, repeatable")
+	debug := flags.Bool("debug", false, "run the script in the debugger, reading commands from the standard input")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+
+	if *expr == "" && flags.NArg() == 0 && !*debug {
+		r := repl.New(eval.WithLoader(module.FS(os.DirFS("."))))
+		for _, binding := range bindings {
+			name, text, _ := strings.Cut(binding, "=")
+			r.Set(name, value(text))
+		}
+		if err := r.Run(stdin, stdout); err != nil {
+			fmt.Fprintf(stderr, "eval: %v\n", err)
+			return exitUsage
+		}
+		return 0
+	}
+
+	var name, src string
+	loader := module.FS(os.DirFS("."))
+	switch {
+	case *expr != "" && flags.NArg() == 0:
+		name, src = "-e", *expr
+	case *expr == "" && flags.NArg() == 1 && flags.Arg(0) == "-" && !*debug:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "eval: %v\n", err)
+			return exitUsage
+		}
+		name, src = "<stdin>", string(data)
+	case *expr == "" && flags.NArg() == 1 && flags.Arg(0) != "-":
+		data, err := os.ReadFile(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "eval: %v\n", err)
+			return exitUsage
+		}
+		name, src = flags.Arg(0), string(data)
+		// modules are imported from the directory of the script
+		loader = module.FS(os.DirFS(filepath.Dir(name)))
+	default:
+		flags.Usage()
+		return exitUsage
+	}
+
+	opts := []eval.Option{eval.WithLoader(loader)}
+	var d *debugger.Debugger
+	if *debug {
+		// the commands are read from the standard input
+		d = debugger.New(debugger.NewTerminal(stdin, stdout, src).Pause)
+		opts = append(opts, eval.WithHook(d))
+	}
+	i := eval.New(opts...)
+	for _, binding := range bindings {
+		name, text, _ := strings.Cut(binding, "=")
+		i.Set(name, value(text))
+	}
+
+	result, err := i.Eval(src)
+	if d != nil && d.Aborted() {
+		return 0
+	}
+	var syntaxErr *eval.ErrSyntax
+	var staticErr *eval.ErrStatic
+	var runtimeErr *eval.ErrRuntime
+	switch {
+	case errors.As(err, &syntaxErr):
+		fmt.Fprintf(stderr, "%s: %v\n", name, syntaxErr)
+		return exitSyntax
+	case errors.As(err, &staticErr):
+		fmt.Fprintf(stderr, "%s: %v\n", name, staticErr)
+		return exitStatic
+	case errors.As(err, &runtimeErr) && runtimeErr.Line > 0:
+		fmt.Fprintf(stderr, "%s: Line %d %s\n", name, runtimeErr.Line, runtimeErr.Message)
+		return exitRuntime
+	case errors.As(err, &runtimeErr):
+		fmt.Fprintf(stderr, "%s: %s\n", name, runtimeErr.Message)
+		return exitRuntime
+	case err != nil:
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return exitRuntime
+	}
+
+	if result != nil {
+		fmt.Fprintln(stdout, result.Inspect())
+	}
+	return 0
+}
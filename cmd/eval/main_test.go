@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "rule.eval")
+	lib := filepath.Join(dir, "lib.eval")
+	files := map[string]string{
+		script: "import \"lib\" as lib;\nlib.double(price)",
+		lib:    "export let double = fn(x) { x * 2 };",
+	}
+	for path, src := range files {
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		args   []string
+		stdin  string
+		status int
+		stdout string
+		stderr string
+	}{
+		{[]string{"-e", "1 + 2"}, "", 0, "3\n", ""},
+		{[]string{"-e", "upper(\"ok\")"}, "", 0, "OK\n", ""},
+		{[]string{"-e", "let x = 1"}, "", 0, "", ""},
+		{[]string{"-"}, "let a = 2;\na * 21", 0, "42\n", ""},
+		{[]string{"-var", "price=21", script}, "", 0, "42\n", ""},
+		{[]string{"-var", "a=1.50dec", "-var", "b=2", "-e", "a * b"}, "", 0, "3.00\n", ""},
+		{[]string{"-var", "name=alice", "-var", "vip=true", "-e", "if (vip) { upper(name) }"}, "", 0, "ALICE\n", ""},
+		{[]string{"-var", "tags=[\"a\", -1]", "-e", "tags[1]"}, "", 0, "-1\n", ""},
+		{[]string{"-var", "q=\"x y\"", "-e", "q"}, "", 0, "x y\n", ""},
+		{[]string{"-var", "f=fn() { 1 }", "-e", "f"}, "", 0, "fn() { 1 }\n", ""},
+		{[]string{"-var", "price=21", "-debug", script}, "break 2\nc\nprint price\nc\n", 0,
+			"stopped at line 1: import \"lib\" as lib;\n(dbg) breakpoint at line 2\n(dbg) stopped at line 2: lib.double(price)\n(dbg) 21\n(dbg) 42\n", ""},
+		{[]string{"-debug", "-e", "1 + 2"}, "q\n", 0, "stopped at line 1: 1 + 2\n(dbg) ", ""},
+		// errors
+		{[]string{"-e", "1 +"}, "", exitSyntax, "", "-e: Line 1 Unrecognized Token: no prefix parse function for EOF\n"},
+		{[]string{"-"}, "let a = 1;\na / 0", exitRuntime, "", "<stdin>: Line 2 division by zero\n"},
+		{[]string{"-e", "throw \"boom\""}, "", exitRuntime, "", "-e: Line 1 boom\n"},
+		{[]string{"-e", "missing"}, "", exitStatic, "", "-e: Line 1 Undefined identifier: missing\n"},
+		{[]string{script}, "", exitStatic, "", script + ": Line 2 Undefined identifier: price\n"},
+		{[]string{filepath.Join(dir, "none.eval")}, "", exitUsage, "", ""},
+		{[]string{}, "1 + 1\n", 0, ">> 2\n>> ", ""},
+		{[]string{"-var", "x=4"}, "x * 2\n", 0, ">> 8\n>> ", ""},
+		{[]string{"-e", "1", script}, "", exitUsage, "", ""},
+		{[]string{"-debug", "-"}, "1", exitUsage, "", ""},
+		{[]string{"-debug"}, "", exitUsage, "", ""},
+		{[]string{"-var", "1x=2", "-e", "1"}, "", exitUsage, "", ""},
+		{[]string{"-var", "x", "-e", "1"}, "", exitUsage, "", ""},
+	}
+
+	for _, tc := range testCases {
+		var stdout, stderr bytes.Buffer
+		status := run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+		if status != tc.status {
+			t.Errorf("%q: expected status %d, got %d (%s)", tc.args, tc.status, status, stderr.String())
+		}
+		if stdout.String() != tc.stdout {
+			t.Errorf("%q: expected output %q, got %q", tc.args, tc.stdout, stdout.String())
+		}
+		if tc.stderr != "" && stderr.String() != tc.stderr {
+			t.Errorf("%q: expected error %q, got %q", tc.args, tc.stderr, stderr.String())
+		}
+	}
+}
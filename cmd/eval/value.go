@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/token"
+)
+
+// value returns the value of the text of a -var flag: the value of a
+// literal, or the text itself as a string.
+func value(text string) object.Object {
+	program, err := parser.New(lexer.New(text)).ParseProgram()
+	if err != nil || len(program.Statements) != 1 {
+		return &object.String{Value: text}
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok || !isConstant(stmt.Expression) {
+		return &object.String{Value: text}
+	}
+
+	val := evaluator.Eval(stmt.Expression, object.NewEnvironment())
+	if val == nil || val.Type() == object.ERROR_OBJ {
+		return &object.String{Value: text}
+	}
+	return val
+}
+
+// isConstant reports whether exp is made of literals only, such as -1,
+// "a" or {"a": [1, 2]}.
+func isConstant(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral, *ast.BigIntegerLiteral, *ast.FloatLiteral,
+		*ast.DecimalLiteral, *ast.DurationLiteral, *ast.StringLiteral,
+		*ast.Boolean:
+		return true
+	case *ast.PrefixExpression:
+		return exp.Operator == "-" && isConstant(exp.Right)
+	case *ast.ArrayLiteral:
+		for _, el := range exp.Elements {
+			if !isConstant(el) {
+				return false
+			}
+		}
+		return true
+	case *ast.HashLiteral:
+		for i, key := range exp.Keys {
+			if !isConstant(key) || !isConstant(exp.Values[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isIdentifier(name string) bool {
+	tok, err := lexer.New(name).NextToken()
+	return err == nil && tok.Type == token.IDENT && tok.Literal == name
+}
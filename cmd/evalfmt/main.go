@@ -0,0 +1,86 @@
+// Command evalfmt formats scripts.
+//
+// Usage:
+//
+//	evalfmt [-l] [-w] [file ...]
+//
+// Without files, evalfmt formats its standard input. By default the
+// formatted source is written to the standard output.
+//
+//	-l	list the files whose formatting differs instead
+//	-w	write the result back to the files instead
+//
+// evalfmt exits with status 2 when a file cannot be read or parsed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/NilCent/eval/format"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("evalfmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	write := flags.Bool("w", false, "write result to the source files")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "evalfmt: cannot use -w with standard input")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "evalfmt: %v\n", err)
+			return 2
+		}
+		if err := process("<standard input>", src, *list, false, stdout); err != nil {
+			fmt.Fprintf(stderr, "evalfmt: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err == nil {
+			err = process(path, src, *list, *write, stdout)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "evalfmt: %v\n", err)
+			status = 2
+		}
+	}
+	return status
+}
+
+func process(name string, src []byte, list, write bool, stdout io.Writer) error {
+	formatted, err := format.Source(string(src))
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	changed := formatted != string(src)
+	if list && changed {
+		fmt.Fprintln(stdout, name)
+	}
+	if write && changed {
+		return os.WriteFile(name, []byte(formatted), 0644)
+	}
+	if !list && !write {
+		_, err = io.WriteString(stdout, formatted)
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run(nil, strings.NewReader("let x=1\nx*(2+3)"), &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	if got := stdout.String(); got != "let x = 1;\nx * (2 + 3)\n" {
+		t.Errorf("wrong output %q", got)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	messy := filepath.Join(dir, "messy.eval")
+	tidy := filepath.Join(dir, "tidy.eval")
+	broken := filepath.Join(dir, "broken.eval")
+	for path, src := range map[string]string{messy: "1+1", tidy: "1 + 1\n", broken: "let = 1"} {
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"-l", messy, tidy}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	if got := stdout.String(); got != messy+"\n" {
+		t.Errorf("-l listed %q", got)
+	}
+
+	stdout.Reset()
+	if status := run([]string{"-w", messy}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	if src, _ := os.ReadFile(messy); string(src) != "1 + 1\n" {
+		t.Errorf("-w wrote %q", src)
+	}
+
+	stderr.Reset()
+	if status := run([]string{broken}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("expected status 2 for a syntax error, got %d", status)
+	}
+	if !strings.HasPrefix(stderr.String(), "evalfmt: "+broken+": Line 1") {
+		t.Errorf("wrong error %q", stderr.String())
+	}
+}
@@ -0,0 +1,86 @@
+// Command evallint reports suspicious code in scripts.
+//
+// Usage:
+//
+//	evallint [-check=false ...] [file ...]
+//
+// Without files, evallint checks its standard input. Every check runs
+// unless disabled by a flag named after it, such as -unused-let=false;
+// evallint -h lists them. Findings are printed as
+//
+//	file:line:column: message (check)
+//
+// evallint exits with status 1 when it reports findings and 2 when a
+// file cannot be read or parsed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/lint"
+	"github.com/NilCent/eval/parser"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("evallint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	enabled := make(map[string]*bool)
+	for _, c := range lint.Default() {
+		enabled[c.Name] = flags.Bool(c.Name, true, c.Doc)
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	checks := []lint.Check{}
+	for _, c := range lint.Default() {
+		if *enabled[c.Name] {
+			checks = append(checks, c)
+		}
+	}
+
+	status := 0
+	check := func(name string, src []byte) {
+		program, err := parser.New(lexer.New(string(src))).ParseProgram()
+		if err != nil {
+			fmt.Fprintf(stderr, "evallint: %s: %v\n", name, err)
+			status = 2
+			return
+		}
+		for _, f := range lint.Run(program, checks) {
+			fmt.Fprintf(stdout, "%s:%s\n", name, f)
+			if status == 0 {
+				status = 1
+			}
+		}
+	}
+
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "evallint: %v\n", err)
+			return 2
+		}
+		check("<standard input>", src)
+		return status
+	}
+
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "evallint: %v\n", err)
+			status = 2
+			continue
+		}
+		check(path, src)
+	}
+	return status
+}
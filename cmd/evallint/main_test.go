@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const script = "let unused = 1\nlet f = fn(x) { return x; x }\nf(1)\n"
+
+func TestStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run(nil, strings.NewReader(script), &stdout, &stderr)
+	if status != 1 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	expected := "<standard input>:1:5: unused declared and not used (unused-let)\n" +
+		"<standard input>:2:27: unreachable code after return (unreachable)\n"
+	if got := stdout.String(); got != expected {
+		t.Errorf("wrong output %q", got)
+	}
+}
+
+func TestFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-unused-let=false", "-unreachable=false"}, strings.NewReader(script), &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+	if got := stdout.String(); got != "" {
+		t.Errorf("wrong output %q", got)
+	}
+
+	if status := run([]string{"-nope"}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("expected status 2 for an unknown flag, got %d", status)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.eval")
+	broken := filepath.Join(dir, "broken.eval")
+	for path, src := range map[string]string{clean: "let a = 1; a", broken: "let = 1"} {
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{clean}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr.String())
+	}
+
+	if status := run([]string{clean, broken}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("expected status 2 for a syntax error, got %d", status)
+	}
+	if !strings.HasPrefix(stderr.String(), "evallint: "+broken+": Line 1") {
+		t.Errorf("wrong error %q", stderr.String())
+	}
+}
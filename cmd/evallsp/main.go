@@ -0,0 +1,25 @@
+// Command evallsp is a language server for scripts, for editors
+// supporting the Language Server Protocol. It speaks the protocol over
+// its standard input and output.
+//
+// Usage:
+//
+//	evallsp
+//
+// evallsp exits with status 1 when the client exits without shutting it
+// down first or when the connection fails.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NilCent/eval/lsp"
+)
+
+func main() {
+	if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "evallsp: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,87 @@
+package code
+
+import "testing"
+
+func TestMake(t *testing.T) {
+	testCases := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpGetOuter, []int{2, 258}, []byte{byte(OpGetOuter), 2, 1, 2}},
+		{OpTry, []int{1, NoBlock, 3}, []byte{byte(OpTry), 0, 1, 255, 255, 0, 3}},
+	}
+
+	for _, tc := range testCases {
+		instruction := Make(tc.op, tc.operands...)
+
+		if len(instruction) != len(tc.expected) {
+			t.Errorf("instruction has wrong length. want=%d, got=%d",
+				len(tc.expected), len(instruction))
+			continue
+		}
+		for i, b := range tc.expected {
+			if instruction[i] != b {
+				t.Errorf("wrong byte at pos %d. want=%d, got=%d", i, b, instruction[i])
+			}
+		}
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	instructions := []Instructions{
+		Make(OpAdd),
+		Make(OpGetLocal, 1),
+		Make(OpConstant, 2),
+		Make(OpGetOuter, 1, 65535),
+	}
+
+	expected := `0000 OpAdd
+0001 OpGetLocal 1
+0004 OpConstant 2
+0007 OpGetOuter 1 65535
+`
+
+	concatted := Instructions{}
+	for _, ins := range instructions {
+		concatted = append(concatted, ins...)
+	}
+
+	if concatted.String() != expected {
+		t.Errorf("instructions wrongly formatted.\nwant=%q\ngot=%q", expected, concatted.String())
+	}
+}
+
+func TestReadOperands(t *testing.T) {
+	testCases := []struct {
+		op        Opcode
+		operands  []int
+		bytesRead int
+	}{
+		{OpConstant, []int{65535}, 2},
+		{OpCall, []int{255}, 1},
+		{OpGetOuter, []int{3, 1000}, 3},
+	}
+
+	for _, tc := range testCases {
+		instruction := Make(tc.op, tc.operands...)
+
+		def, err := Lookup(byte(tc.op))
+		if err != nil {
+			t.Fatalf("definition not found: %q\n", err)
+		}
+
+		operandsRead, n := ReadOperands(def, instruction[1:])
+		if n != tc.bytesRead {
+			t.Fatalf("n wrong. want=%d, got=%d", tc.bytesRead, n)
+		}
+
+		for i, want := range tc.operands {
+			if operandsRead[i] != want {
+				t.Errorf("operand wrong. want=%d, got=%d", want, operandsRead[i])
+			}
+		}
+	}
+}
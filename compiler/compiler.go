@@ -0,0 +1,551 @@
+// Package compiler translates programs to the bytecode run by the vm
+// package.
+package compiler
+
+import (
+	"math"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/code"
+	"github.com/NilCent/eval/object"
+)
+
+// Bytecode is a compiled program. Main runs the top-level statements
+// and Constants holds the literals, names and functions they refer to.
+type Bytecode struct {
+	Main      *object.CompiledFunction
+	Constants []object.Object
+}
+
+// unit accumulates the instructions of the function being compiled.
+type unit struct {
+	instructions code.Instructions
+	lines        []code.Line
+	// function is set for the body of a function literal, whose calls
+	// in tail position are tail calls.
+	function bool
+}
+
+type Compiler struct {
+	constants []object.Object
+	names     map[string]int
+
+	unit *unit
+}
+
+func New() *Compiler {
+	return &Compiler{
+		names: make(map[string]int),
+		unit:  &unit{},
+	}
+}
+
+// Compile translates program, which can then be run any number of
+// times by the vm package. The variables of function calls and catch
+// clauses are stored in the slots given by resolver.Resolve, which
+// program must have been annotated by.
+func Compile(program *ast.Program) (*Bytecode, error) {
+	c := New()
+	if err := c.compileProgram(program); err != nil {
+		return nil, err
+	}
+
+	main := &object.CompiledFunction{
+		Instructions: c.unit.instructions,
+		Lines:        c.unit.lines,
+		Constants:    c.constants,
+	}
+	for _, obj := range c.constants {
+		if fn, ok := obj.(*object.CompiledFunction); ok {
+			fn.Constants = c.constants
+		}
+	}
+
+	return &Bytecode{Main: main, Constants: c.constants}, nil
+}
+
+func (c *Compiler) compileProgram(program *ast.Program) error {
+	for i, s := range program.Statements {
+		if err := c.compileStatement(s); err != nil {
+			return err
+		}
+		if _, ok := s.(*ast.ExpressionStatement); ok {
+			if i == len(program.Statements)-1 {
+				c.emit(code.OpReturnValue)
+				return nil
+			}
+			c.emit(code.OpPop)
+		}
+	}
+
+	// the program ends with a statement producing no value
+	c.emit(code.OpReturn)
+	return nil
+}
+
+// compileBlock leaves the value of the last statement of block on the
+// stack, null when it is not an expression.
+func (c *Compiler) compileBlock(block *ast.BlockStatement) error {
+	return c.compileTailBlock(block, false)
+}
+
+// compileTailBlock compiles block as compileBlock does. tail is set
+// when the value of block is the result of the function.
+func (c *Compiler) compileTailBlock(block *ast.BlockStatement, tail bool) error {
+	if len(block.Statements) == 0 {
+		c.emit(code.OpNull)
+		return nil
+	}
+
+	for i, s := range block.Statements {
+		es, isExpression := s.(*ast.ExpressionStatement)
+		last := i == len(block.Statements)-1
+		if tail && last && isExpression {
+			if err := c.compileTailExpression(es.Expression); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.compileStatement(s); err != nil {
+			return err
+		}
+
+		switch {
+		case isExpression && !last:
+			c.emit(code.OpPop)
+		case !isExpression && last:
+			c.emit(code.OpNull)
+		}
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileStatement(s ast.Statement) error {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		return c.compileExpression(s.Expression)
+
+	case *ast.LetStatement:
+		if err := c.compileExpression(s.Value); err != nil {
+			return err
+		}
+		return c.setVariable(s.Name, s.Token.Line)
+
+	case *ast.ExportStatement:
+		return c.compileStatement(s.Statement)
+
+	case *ast.ReturnStatement:
+		compile := c.compileExpression
+		if c.unit.function {
+			compile = c.compileTailExpression
+		}
+		if err := compile(s.ReturnValue); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+
+	case *ast.ThrowStatement:
+		if err := c.compileExpression(s.Value); err != nil {
+			return err
+		}
+		c.emitLine(s.Token.Line, code.OpThrow)
+
+	case *ast.ImportStatement:
+		path, err := c.addConstant(&object.String{Value: s.Path.Value}, s.Token.Line)
+		if err != nil {
+			return err
+		}
+		if s.Alias == nil {
+			c.emitLine(s.Token.Line, code.OpImportAll, path)
+			return nil
+		}
+		c.emitLine(s.Token.Line, code.OpImport, path)
+		return c.setVariable(s.Alias, s.Token.Line)
+
+	case *ast.BlockStatement:
+		return c.compileBlock(s)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileExpression(e ast.Expression) error {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		return c.emitConstant(object.NativeInt(e.Value), e.Token.Line)
+	case *ast.BigIntegerLiteral:
+		return c.emitConstant(&object.BigInteger{Value: e.Value}, e.Token.Line)
+	case *ast.FloatLiteral:
+		return c.emitConstant(&object.Float{Value: e.Value}, e.Token.Line)
+	case *ast.DecimalLiteral:
+		return c.emitConstant(&object.Decimal{Value: e.Value, Scale: e.Scale}, e.Token.Line)
+	case *ast.DurationLiteral:
+		return c.emitConstant(&object.Duration{Value: e.Value}, e.Token.Line)
+	case *ast.StringLiteral:
+		return c.emitConstant(&object.String{Value: e.Value}, e.Token.Line)
+
+	case *ast.Boolean:
+		if e.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
+	case *ast.PrefixExpression:
+		if err := c.compileExpression(e.Right); err != nil {
+			return err
+		}
+		switch e.Operator {
+		case "!":
+			c.emitLine(e.Token.Line, code.OpBang)
+		case "-":
+			c.emitLine(e.Token.Line, code.OpMinus)
+		default:
+			return &ErrUnknownOperator{Operator: e.Operator, Line: e.Token.Line}
+		}
+
+	case *ast.InfixExpression:
+		if e.Operator == "&&" || e.Operator == "||" {
+			return c.compileLogicalExpression(e)
+		}
+		if err := c.compileExpression(e.Left); err != nil {
+			return err
+		}
+		if err := c.compileExpression(e.Right); err != nil {
+			return err
+		}
+		op, ok := infixOperators[e.Operator]
+		if !ok {
+			return &ErrUnknownOperator{Operator: e.Operator, Line: e.Token.Line}
+		}
+		c.emitLine(e.Token.Line, op)
+
+	case *ast.IfExpression:
+		return c.compileIfExpression(e, false)
+
+	case *ast.TryExpression:
+		return c.compileTryExpression(e)
+
+	case *ast.Identifier:
+		if e.Local {
+			if e.Depth == 0 {
+				c.emitLine(e.Token.Line, code.OpGetLocal, e.Slot)
+			} else {
+				c.emitLine(e.Token.Line, code.OpGetOuter, e.Depth, e.Slot)
+			}
+			return nil
+		}
+		name, err := c.addName(e.Value, e.Token.Line)
+		if err != nil {
+			return err
+		}
+		c.emitLine(e.Token.Line, code.OpGetGlobal, name)
+
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(e)
+
+	case *ast.CallExpression:
+		return c.compileCall(e, code.OpCall)
+
+	case *ast.MemberExpression:
+		if err := c.compileExpression(e.Object); err != nil {
+			return err
+		}
+		name, err := c.addName(e.Property.Value, e.Token.Line)
+		if err != nil {
+			return err
+		}
+		c.emitLine(e.Token.Line, code.OpMember, name)
+
+	case *ast.IndexExpression:
+		if err := c.compileExpression(e.Left); err != nil {
+			return err
+		}
+		if err := c.compileExpression(e.Index); err != nil {
+			return err
+		}
+		c.emitLine(e.Token.Line, code.OpIndex)
+
+	case *ast.ArrayLiteral:
+		if len(e.Elements) > math.MaxUint16 {
+			return &ErrLimit{What: "elements", Line: e.Token.Line}
+		}
+		for _, el := range e.Elements {
+			if err := c.compileExpression(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(e.Elements))
+
+	case *ast.HashLiteral:
+		if len(e.Keys) > math.MaxUint16 {
+			return &ErrLimit{What: "elements", Line: e.Token.Line}
+		}
+		for i, key := range e.Keys {
+			if err := c.compileExpression(key); err != nil {
+				return err
+			}
+			// keys are checked before the value is evaluated
+			c.emitLine(e.Token.Line, code.OpHashKey)
+			if err := c.compileExpression(e.Values[i]); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(e.Keys))
+	}
+
+	return nil
+}
+
+var infixOperators = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	">":  code.OpGreaterThan,
+}
+
+// compileTailExpression compiles an expression whose value is the
+// result of the function, making its calls in tail position with
+// OpTailCall. Calls in a try expression are never in tail position,
+// since its clauses act on their result.
+func (c *Compiler) compileTailExpression(e ast.Expression) error {
+	switch e := e.(type) {
+	case *ast.CallExpression:
+		return c.compileCall(e, code.OpTailCall)
+	case *ast.IfExpression:
+		return c.compileIfExpression(e, true)
+	}
+	return c.compileExpression(e)
+}
+
+func (c *Compiler) compileCall(e *ast.CallExpression, op code.Opcode) error {
+	if err := c.compileExpression(e.Function); err != nil {
+		return err
+	}
+	if len(e.Arguments) > math.MaxUint8 {
+		return &ErrLimit{What: "arguments", Line: e.Token.Line}
+	}
+	for _, a := range e.Arguments {
+		if err := c.compileExpression(a); err != nil {
+			return err
+		}
+	}
+	c.emitLine(e.Token.Line, op, len(e.Arguments))
+	return nil
+}
+
+func (c *Compiler) compileIfExpression(e *ast.IfExpression, tail bool) error {
+	if err := c.compileExpression(e.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, 9999)
+	if err := c.compileTailBlock(e.Consequence, tail); err != nil {
+		return err
+	}
+	jump := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(jumpNotTruthy, len(c.unit.instructions))
+	if e.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compileTailBlock(e.Alternative, tail); err != nil {
+		return err
+	}
+	c.changeOperand(jump, len(c.unit.instructions))
+
+	if len(c.unit.instructions) > math.MaxUint16 {
+		return &ErrLimit{What: "instructions", Line: e.Token.Line}
+	}
+	return nil
+}
+
+// compileLogicalExpression jumps over the right operand of && and ||
+// when the left one decides the result. The operand deciding it is
+// turned into a boolean by two OpBang.
+func (c *Compiler) compileLogicalExpression(e *ast.InfixExpression) error {
+	if err := c.compileExpression(e.Left); err != nil {
+		return err
+	}
+
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, 9999)
+	var jump int
+	if e.Operator == "||" {
+		c.emit(code.OpTrue)
+		jump = c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthy, len(c.unit.instructions))
+	}
+	if err := c.compileExpression(e.Right); err != nil {
+		return err
+	}
+	c.emit(code.OpBang)
+	c.emit(code.OpBang)
+	if e.Operator == "&&" {
+		jump = c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthy, len(c.unit.instructions))
+		c.emit(code.OpFalse)
+	}
+	c.changeOperand(jump, len(c.unit.instructions))
+
+	if len(c.unit.instructions) > math.MaxUint16 {
+		return &ErrLimit{What: "instructions", Line: e.Token.Line}
+	}
+	return nil
+}
+
+// compileTryExpression compiles each clause to a block run by the VM
+// in a frame of its own, so that errors and returns raised by a clause
+// stop at the try expression.
+func (c *Compiler) compileTryExpression(e *ast.TryExpression) error {
+	block, err := c.compileClause(e.Block, nil, e.Token.Line)
+	if err != nil {
+		return err
+	}
+
+	catch, finally := code.NoBlock, code.NoBlock
+	if e.Catch != nil {
+		if e.CatchSlots == nil {
+			return &ErrUnresolved{Line: e.Token.Line}
+		}
+		if catch, err = c.compileClause(e.Catch, e.CatchSlots, e.Token.Line); err != nil {
+			return err
+		}
+	}
+	if e.Finally != nil {
+		if finally, err = c.compileClause(e.Finally, nil, e.Token.Line); err != nil {
+			return err
+		}
+	}
+
+	c.emit(code.OpTry, block, catch, finally)
+	return nil
+}
+
+// compileClause returns the constant holding the compiled clause. The
+// catch clause, whose parameter is its first slot, gets a scope of its
+// own as in the evaluator; others share the scope of the expression and
+// have no slots.
+func (c *Compiler) compileClause(block *ast.BlockStatement, slots []string, line int) (int, error) {
+	outerUnit := c.unit
+	defer func() { c.unit = outerUnit }()
+
+	c.unit = &unit{}
+	fn := &object.CompiledFunction{Slots: slots}
+	if slots != nil {
+		fn.NumParameters = 1
+	}
+
+	if err := c.compileBlock(block); err != nil {
+		return 0, err
+	}
+	c.emit(code.OpBlockEnd)
+
+	fn.Instructions = c.unit.instructions
+	fn.Lines = c.unit.lines
+	return c.addConstant(fn, line)
+}
+
+func (c *Compiler) compileFunctionLiteral(e *ast.FunctionLiteral) error {
+	if e.Slots == nil {
+		return &ErrUnresolved{Line: e.Token.Line}
+	}
+
+	outerUnit := c.unit
+	c.unit = &unit{function: true}
+
+	err := c.compileTailBlock(e.Body, true)
+	c.emit(code.OpReturnValue)
+
+	fn := &object.CompiledFunction{
+		Instructions:  c.unit.instructions,
+		NumParameters: len(e.Parameters),
+		Slots:         e.Slots,
+		Lines:         c.unit.lines,
+		Literal:       e,
+	}
+	c.unit = outerUnit
+	if err != nil {
+		return err
+	}
+
+	if len(fn.Slots) > math.MaxUint16 {
+		return &ErrLimit{What: "variables", Line: e.Token.Line}
+	}
+
+	index, err := c.addConstant(fn, e.Token.Line)
+	if err != nil {
+		return err
+	}
+	c.emit(code.OpClosure, index)
+	return nil
+}
+
+// setVariable stores the value on top of the stack in the variable
+// name binds.
+func (c *Compiler) setVariable(name *ast.Identifier, line int) error {
+	if !name.Local {
+		index, err := c.addName(name.Value, line)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpSetGlobal, index)
+		return nil
+	}
+
+	c.emit(code.OpSetLocal, name.Slot)
+	return nil
+}
+
+func (c *Compiler) addConstant(obj object.Object, line int) (int, error) {
+	if len(c.constants) > math.MaxUint16-1 {
+		return 0, &ErrLimit{What: "constants", Line: line}
+	}
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1, nil
+}
+
+// addName returns the constant holding name, shared by every variable
+// and member of that name.
+func (c *Compiler) addName(name string, line int) (int, error) {
+	if index, ok := c.names[name]; ok {
+		return index, nil
+	}
+	index, err := c.addConstant(&object.String{Value: name}, line)
+	if err != nil {
+		return 0, err
+	}
+	c.names[name] = index
+	return index, nil
+}
+
+func (c *Compiler) emitConstant(obj object.Object, line int) error {
+	index, err := c.addConstant(obj, line)
+	if err != nil {
+		return err
+	}
+	c.emit(code.OpConstant, index)
+	return nil
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	pos := len(c.unit.instructions)
+	c.unit.instructions = append(c.unit.instructions, code.Make(op, operands...)...)
+	return pos
+}
+
+// emitLine emits an instruction which may fail, recording its line.
+// The VM looks lines up by the offset following the instruction.
+func (c *Compiler) emitLine(line int, op code.Opcode, operands ...int) int {
+	pos := c.emit(op, operands...)
+	c.unit.lines = append(c.unit.lines, code.Line{Offset: len(c.unit.instructions), Line: line})
+	return pos
+}
+
+func (c *Compiler) changeOperand(pos int, operand int) {
+	op := code.Opcode(c.unit.instructions[pos])
+	copy(c.unit.instructions[pos:], code.Make(op, operand))
+}
@@ -0,0 +1,151 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/NilCent/eval/code"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/resolver"
+)
+
+func compile(t *testing.T, input string) *Bytecode {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse %q: %s", input, err)
+	}
+	// undefined names are left to the VM to report
+	resolver.Resolve(program, nil)
+
+	bytecode, err := Compile(program)
+	if err != nil {
+		t.Fatalf("compile %q: %s", input, err)
+	}
+	return bytecode
+}
+
+func concat(instructions ...[]byte) code.Instructions {
+	out := code.Instructions{}
+	for _, ins := range instructions {
+		out = append(out, ins...)
+	}
+	return out
+}
+
+func testInstructions(t *testing.T, input string, expected, actual code.Instructions) {
+	if actual.String() != expected.String() {
+		t.Errorf("%s: wrong instructions.\nwant=\n%s\ngot=\n%s", input, expected, actual)
+	}
+}
+
+func TestProgram(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected code.Instructions
+	}{
+		{"1 + 2", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpAdd),
+			code.Make(code.OpReturnValue),
+		)},
+		{"1; 2", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpPop),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpReturnValue),
+		)},
+		{"let a = true; a", concat(
+			code.Make(code.OpTrue),
+			code.Make(code.OpSetGlobal, 0),
+			code.Make(code.OpGetGlobal, 0),
+			code.Make(code.OpReturnValue),
+		)},
+		{"let a = 1;", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpSetGlobal, 1),
+			code.Make(code.OpReturn),
+		)},
+		{"if (x) { 1 }", concat(
+			code.Make(code.OpGetGlobal, 0),
+			code.Make(code.OpJumpNotTruthy, 12),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpJump, 13),
+			code.Make(code.OpNull),
+			code.Make(code.OpReturnValue),
+		)},
+	}
+
+	for _, tc := range testCases {
+		testInstructions(t, tc.input, tc.expected, compile(t, tc.input).Main.Instructions)
+	}
+}
+
+func TestFunctionScopes(t *testing.T) {
+	bytecode := compile(t, "fn(a) { let f = fn() { a + b }; let b = 1; f() }")
+
+	outer, ok := bytecode.Constants[len(bytecode.Constants)-1].(*object.CompiledFunction)
+	if !ok {
+		t.Fatalf("expected the outer function last, got %#v", bytecode.Constants)
+	}
+	if len(outer.Slots) != 3 || outer.Slots[1] != "f" || outer.Slots[2] != "b" {
+		t.Errorf("expected slots for a, f and b, got %v", outer.Slots)
+	}
+
+	var inner *object.CompiledFunction
+	for _, c := range bytecode.Constants {
+		if fn, ok := c.(*object.CompiledFunction); ok && fn != outer {
+			inner = fn
+		}
+	}
+	testInstructions(t, "inner function", concat(
+		code.Make(code.OpGetOuter, 1, 0),
+		code.Make(code.OpGetOuter, 1, 2),
+		code.Make(code.OpAdd),
+		code.Make(code.OpReturnValue),
+	), inner.Instructions)
+}
+
+func TestTailCalls(t *testing.T) {
+	input := "fn(f) { f(1); if (f) { f(2) } else { 3 } }"
+	bytecode := compile(t, input)
+
+	fn := bytecode.Constants[len(bytecode.Constants)-1].(*object.CompiledFunction)
+	testInstructions(t, input, concat(
+		code.Make(code.OpGetLocal, 0),
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpCall, 1),
+		code.Make(code.OpPop),
+		code.Make(code.OpGetLocal, 0),
+		code.Make(code.OpJumpNotTruthy, 26),
+		code.Make(code.OpGetLocal, 0),
+		code.Make(code.OpConstant, 1),
+		code.Make(code.OpTailCall, 1),
+		code.Make(code.OpJump, 29),
+		code.Make(code.OpConstant, 2),
+		code.Make(code.OpReturnValue),
+	), fn.Instructions)
+}
+
+func TestUnresolved(t *testing.T) {
+	for _, input := range []string{"fn(x) { x }", "try { 1 } catch (e) { e }"} {
+		program, err := parser.New(lexer.New(input)).ParseProgram()
+		if err != nil {
+			t.Fatalf("parse %q: %s", input, err)
+		}
+		_, err = Compile(program)
+		if _, ok := err.(*ErrUnresolved); !ok {
+			t.Errorf("%s: expected an unresolved program, got %v", input, err)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	bytecode := compile(t, "let a = 1;\n\na + b")
+
+	// the offset following OpGetGlobal b
+	if line := code.LineAt(bytecode.Main.Lines, 12); line != 3 {
+		t.Errorf("expected line 3, got %d", line)
+	}
+}
@@ -0,0 +1,31 @@
+package compiler
+
+import "fmt"
+
+type ErrLimit struct {
+	What string
+	Line int
+}
+
+func (e *ErrLimit) Error() string {
+	return fmt.Sprintf("Line %d Too many %s", e.Line, e.What)
+}
+
+// ErrUnresolved reports a function literal or a catch clause of a
+// program which resolver.Resolve has not annotated.
+type ErrUnresolved struct {
+	Line int
+}
+
+func (e *ErrUnresolved) Error() string {
+	return fmt.Sprintf("Line %d Program not resolved", e.Line)
+}
+
+type ErrUnknownOperator struct {
+	Operator string
+	Line     int
+}
+
+func (e *ErrUnknownOperator) Error() string {
+	return fmt.Sprintf("Line %d Unknown operator: %s", e.Line, e.Operator)
+}
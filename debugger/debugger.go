@@ -0,0 +1,274 @@
+// Package debugger pauses the evaluation of a script at breakpoints and
+// after steps, through the hook of the evaluator, so that its variables
+// and its call stack can be inspected. Terminal is a front-end driven
+// by commands typed in a terminal.
+//
+// The evaluation stops before statements starting a line, so that a
+// line holding several statements is stepped over at once. Modules
+// imported by the script run without stopping.
+package debugger
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+)
+
+// Action tells how to resume a stopped evaluation.
+type Action int
+
+const (
+	// Continue runs up to the next breakpoint.
+	Continue Action = iota
+	// StepInto stops at the next line, within a function called by
+	// the current one if any.
+	StepInto
+	// StepOver stops at the next line of the current function or of
+	// its callers.
+	StepOver
+	// StepOut stops at the next line of a caller of the current
+	// function.
+	StepOut
+	// Quit stops the evaluation for good.
+	Quit
+)
+
+// Frame is a call being evaluated, or the script itself.
+type Frame struct {
+	// Name is the name a let statement bound the function to, its
+	// parameters as in fn(a, b) for an anonymous function and "main" for
+	// the script.
+	Name string
+	// Function is nil for the script.
+	Function *object.Function
+	// Env is the environment of the statement being evaluated, whose
+	// outer environments are those of enclosing scopes.
+	Env *object.Environment
+	// Line is the line of the statement being evaluated, 0 before the
+	// first one.
+	Line      int
+	Statement ast.Statement
+}
+
+// Debugger is the hook of an evaluation, calling a front-end whenever
+// it stops.
+type Debugger struct {
+	pause       func(*Debugger) Action
+	breakpoints map[int]bool
+	// frames are the calls being evaluated, the script first.
+	frames []*Frame
+	// names holds the names let statements bind functions to, by body.
+	names map[*ast.BlockStatement]string
+	// root is the global environment of the script.
+	root *object.Environment
+
+	action Action
+	// depth is the number of frames when the evaluation last resumed.
+	depth int
+	// inspecting is set while an expression is evaluated for the
+	// front-end, which must not stop.
+	inspecting bool
+	quit       bool
+}
+
+var _ object.Hook = (*Debugger)(nil)
+
+// errQuit stops the evaluation when the front-end quits. It is
+// returned before every statement from then on, since a catch clause
+// would otherwise go on.
+var errQuit = &object.Error{Message: "debugger: quit"}
+
+// New returns a debugger calling pause whenever the evaluation stops,
+// which returns how to resume it. The evaluation first stops before the
+// first statement of the script.
+func New(pause func(d *Debugger) Action) *Debugger {
+	return &Debugger{
+		pause:       pause,
+		breakpoints: make(map[int]bool),
+		names:       make(map[*ast.BlockStatement]string),
+		action:      StepInto,
+	}
+}
+
+// Break sets a breakpoint at line.
+func (d *Debugger) Break(line int) {
+	d.breakpoints[line] = true
+}
+
+// Clear removes the breakpoint at line, if any.
+func (d *Debugger) Clear(line int) {
+	delete(d.breakpoints, line)
+}
+
+// Breakpoints returns the lines of the breakpoints, sorted.
+func (d *Debugger) Breakpoints() []int {
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Aborted reports whether the front-end quit, stopping the evaluation.
+func (d *Debugger) Aborted() bool {
+	return d.quit
+}
+
+// Stack returns the frames of the calls being evaluated, the innermost
+// first and the script last.
+func (d *Debugger) Stack() []Frame {
+	stack := make([]Frame, len(d.frames))
+	for i, f := range d.frames {
+		stack[len(d.frames)-1-i] = *f
+	}
+	return stack
+}
+
+// Eval evaluates input in the environment of the statement the
+// evaluation stopped at, without stopping at breakpoints. Let
+// statements bind variables in that environment.
+func (d *Debugger) Eval(input string) (object.Object, error) {
+	if len(d.frames) == 0 {
+		return nil, errors.New("evaluation not started")
+	}
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+
+	d.inspecting = true
+	defer func() { d.inspecting = false }()
+
+	evaluated := evaluator.Eval(program, d.frames[len(d.frames)-1].Env)
+	if err, ok := evaluated.(*object.Error); ok {
+		return nil, errors.New(err.Message)
+	}
+	return evaluated, nil
+}
+
+// Statement stops the evaluation before stmt when a breakpoint or the
+// last step asks for it.
+func (d *Debugger) Statement(stmt ast.Statement, env *object.Environment) *object.Error {
+	if d.quit {
+		return errQuit
+	}
+	if d.inspecting {
+		return nil
+	}
+
+	if len(d.frames) == 0 {
+		d.root = env
+		d.frames = append(d.frames, &Frame{Name: "main", Env: env})
+	}
+	d.record(stmt)
+
+	f := d.frames[len(d.frames)-1]
+	line := lineOf(stmt)
+	started := line != f.Line
+	f.Env, f.Line, f.Statement = env, line, stmt
+	if !started || global(env) != d.root || !d.stops(line) {
+		return nil
+	}
+
+	d.action = d.pause(d)
+	d.depth = len(d.frames)
+	if d.action == Quit {
+		d.quit = true
+		return errQuit
+	}
+	return nil
+}
+
+func (d *Debugger) stops(line int) bool {
+	if d.breakpoints[line] {
+		return true
+	}
+	switch d.action {
+	case StepInto:
+		return true
+	case StepOver:
+		return len(d.frames) <= d.depth
+	case StepOut:
+		return len(d.frames) < d.depth
+	}
+	return false
+}
+
+// Call pushes the frame of the call of fn.
+func (d *Debugger) Call(fn *object.Function, env *object.Environment) {
+	if d.inspecting {
+		return
+	}
+	d.frames = append(d.frames, &Frame{Name: d.name(fn), Function: fn, Env: env})
+}
+
+// Return pops the frame of the call of fn.
+func (d *Debugger) Return(fn *object.Function, result object.Object) {
+	if d.inspecting || len(d.frames) < 2 {
+		return
+	}
+	d.frames = d.frames[:len(d.frames)-1]
+}
+
+// record notes the name stmt binds a function literal to.
+func (d *Debugger) record(stmt ast.Statement) {
+	if export, ok := stmt.(*ast.ExportStatement); ok {
+		stmt = export.Statement
+	}
+	if let, ok := stmt.(*ast.LetStatement); ok {
+		if fl, ok := let.Value.(*ast.FunctionLiteral); ok {
+			d.names[fl.Body] = let.Name.Value
+		}
+	}
+}
+
+func (d *Debugger) name(fn *object.Function) string {
+	if name, ok := d.names[fn.Body]; ok {
+		return name
+	}
+	return signature(fn)
+}
+
+// signature returns the parameters of fn, as in fn(a, b).
+func signature(fn *object.Function) string {
+	params := make([]string, len(fn.Parameters))
+	for i, param := range fn.Parameters {
+		params[i] = param.Value
+	}
+	return "fn(" + strings.Join(params, ", ") + ")"
+}
+
+// global returns the outermost environment enclosing env.
+func global(env *object.Environment) *object.Environment {
+	for env.Outer() != nil {
+		env = env.Outer()
+	}
+	return env
+}
+
+func lineOf(stmt ast.Statement) int {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token.Line
+	case *ast.ReturnStatement:
+		return stmt.Token.Line
+	case *ast.ThrowStatement:
+		return stmt.Token.Line
+	case *ast.ImportStatement:
+		return stmt.Token.Line
+	case *ast.ExportStatement:
+		return stmt.Token.Line
+	case *ast.ExpressionStatement:
+		return stmt.Token.Line
+	case *ast.BlockStatement:
+		return stmt.Token.Line
+	}
+	return 0
+}
@@ -0,0 +1,266 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/NilCent/eval"
+	"github.com/NilCent/eval/module"
+)
+
+// script returns a pause function answering actions in turn, continuing
+// once they run out, and the stops it was called at as "line in name".
+func script(actions ...Action) (func(*Debugger) Action, *[]string) {
+	stops := []string{}
+	return func(d *Debugger) Action {
+		f := d.Stack()[0]
+		stops = append(stops, fmt.Sprintf("%d in %s", f.Line, f.Name))
+		if len(actions) == 0 {
+			return Continue
+		}
+		action := actions[0]
+		actions = actions[1:]
+		return action
+	}, &stops
+}
+
+const double = `let double = fn(x) {
+	let y = x * 2
+	y
+}
+let a = double(1)
+let b = double(a)
+b`
+
+func TestSteps(t *testing.T) {
+	testCases := []struct {
+		actions  []Action
+		expected []string
+	}{
+		{[]Action{StepInto, StepInto, StepInto}, []string{"1 in main", "5 in main", "2 in double", "3 in double"}},
+		{[]Action{StepOver, StepOver, StepOver}, []string{"1 in main", "5 in main", "6 in main", "7 in main"}},
+		{[]Action{StepOver, StepInto, StepOver, StepOut, StepInto}, []string{"1 in main", "5 in main", "2 in double", "3 in double", "6 in main", "2 in double"}},
+		// out of the script runs it to the end
+		{[]Action{StepOver, StepInto, StepOut, StepOut}, []string{"1 in main", "5 in main", "2 in double", "6 in main"}},
+		{[]Action{Continue}, []string{"1 in main"}},
+	}
+
+	for _, tc := range testCases {
+		pause, stops := script(tc.actions...)
+		result, err := eval.New(eval.WithHook(New(pause))).Eval(double)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.actions, err)
+		}
+		if result.Inspect() != "4" {
+			t.Errorf("%v: expected 4, got %s", tc.actions, result.Inspect())
+		}
+		if !reflect.DeepEqual(*stops, tc.expected) {
+			t.Errorf("%v: expected stops %q, got %q", tc.actions, tc.expected, *stops)
+		}
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	input := `let fact = fn(n) {
+	if (n < 2) { return 1 }
+	n * fact(n - 1)
+}
+let count = fn(n) {
+	if (n == 0) { return 0 }
+	count(n - 1)
+}
+fact(3) + count(2)`
+
+	var depths []int
+	d := New(func(d *Debugger) Action {
+		depths = append(depths, len(d.Stack()))
+		return Continue
+	})
+	d.Break(2)
+	d.Break(6)
+	d.Break(6)
+	d.Clear(9)
+	if got := d.Breakpoints(); !reflect.DeepEqual(got, []int{2, 6}) {
+		t.Errorf("expected breakpoints [2 6], got %v", got)
+	}
+
+	result, err := eval.New(eval.WithHook(d)).Eval(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inspect() != "6" {
+		t.Errorf("expected 6, got %s", result.Inspect())
+	}
+	// the first stop is before the script, tail calls replace the frame
+	// of their caller
+	expected := []int{1, 2, 3, 4, 2, 2, 2}
+	if !reflect.DeepEqual(depths, expected) {
+		t.Errorf("expected depths %v, got %v", expected, depths)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	input := `let rate = 3
+let apply = fn(x, f) { f(x) + 0 }
+apply(1, fn(x) {
+	let y = x * rate
+	y
+})`
+
+	var stack []Frame
+	var printed []string
+	d := New(func(d *Debugger) Action {
+		if d.Stack()[0].Line != 5 {
+			return Continue
+		}
+		stack = d.Stack()
+		for _, input := range []string{"x + y", "rate", "missing", "let z = 1", "upper(\"ok\")", "1 +"} {
+			val, err := d.Eval(input)
+			switch {
+			case err != nil:
+				printed = append(printed, "error: "+err.Error())
+			case val == nil:
+				printed = append(printed, "nil")
+			default:
+				printed = append(printed, val.Inspect())
+			}
+		}
+		return Quit
+	})
+	d.Break(5)
+
+	_, err := eval.New(eval.WithHook(d)).Eval(input)
+	if err == nil || !strings.Contains(err.Error(), "debugger: quit") {
+		t.Errorf("expected the evaluation to quit, got %v", err)
+	}
+	if !d.Aborted() {
+		t.Errorf("expected the debugger to be aborted")
+	}
+
+	var names []string
+	for _, f := range stack {
+		names = append(names, fmt.Sprintf("%s:%d", f.Name, f.Line))
+	}
+	if expected := []string{"fn(x):5", "apply:2", "main:3"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected stack %v, got %v", expected, names)
+	}
+	expected := []string{"4", "3", "error: identifier not found: missing", "nil", "OK",
+		"error: Line 1 Unrecognized Token: no prefix parse function for EOF"}
+	if !reflect.DeepEqual(printed, expected) {
+		t.Errorf("expected %q, got %q", expected, printed)
+	}
+}
+
+func TestQuit(t *testing.T) {
+	input := `let r = try {
+	1
+	2
+} catch (e) {
+	3
+}
+r`
+	pause, stops := script(StepInto, Quit)
+	d := New(pause)
+	_, err := eval.New(eval.WithHook(d)).Eval(input)
+	if err == nil || !strings.Contains(err.Error(), "debugger: quit") {
+		t.Errorf("expected the evaluation to quit, got %v", err)
+	}
+	if expected := []string{"1 in main", "2 in main"}; !reflect.DeepEqual(*stops, expected) {
+		t.Errorf("expected stops %q, got %q", expected, *stops)
+	}
+}
+
+func TestModules(t *testing.T) {
+	loader := module.FS(fstest.MapFS{
+		"lib.eval": {Data: []byte("export let double = fn(x) {\n\tx * 2\n}")},
+	})
+	input := "import \"lib\" as lib\nlib.double(21)"
+
+	pause, stops := script(StepInto, StepInto, StepInto)
+	result, err := eval.New(eval.WithLoader(loader), eval.WithHook(New(pause))).Eval(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inspect() != "42" {
+		t.Errorf("expected 42, got %s", result.Inspect())
+	}
+	if expected := []string{"1 in main", "2 in main"}; !reflect.DeepEqual(*stops, expected) {
+		t.Errorf("expected stops %q, got %q", expected, *stops)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	input := `let rate = 2
+let scale = fn(x) {
+	let y = x * rate
+	y
+}
+scale(21)`
+	commands := `break 4
+break x
+breakpoints
+continue
+print y + 1
+p
+env
+stack
+list
+bogus
+continue
+`
+	var out bytes.Buffer
+	term := NewTerminal(strings.NewReader(commands), &out, input)
+	result, err := eval.New(eval.WithHook(New(term.Pause))).Eval(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inspect() != "42" {
+		t.Errorf("expected 42, got %s", result.Inspect())
+	}
+
+	expected := `stopped at line 1: let rate = 2
+(dbg) breakpoint at line 4
+(dbg) expected a line number, got "x"
+(dbg) line 4
+(dbg) stopped at line 4 in scale: y
+(dbg) 43
+(dbg) expected an expression
+(dbg) scope 0:
+  x = 21
+  y = 42
+global:
+  rate = 2
+  scale = fn(x)
+(dbg) #0 scale at line 4
+#1 main at line 6
+(dbg)       2  let scale = fn(x) {
+      3  	let y = x * rate
+=>*   4  	y
+      5  }
+      6  scale(21)
+(dbg) unknown command bogus, see help
+(dbg) `
+	if out.String() != expected {
+		t.Errorf("expected output\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestTerminalEOF(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal(strings.NewReader("step\n"), &out, "1\n2")
+	d := New(term.Pause)
+	if _, err := eval.New(eval.WithHook(d)).Eval("1\n2"); err == nil {
+		t.Errorf("expected the evaluation to quit")
+	}
+	if !d.Aborted() {
+		t.Errorf("expected the debugger to be aborted")
+	}
+	expected := "stopped at line 1: 1\n(dbg) stopped at line 2: 2\n(dbg) \n"
+	if out.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, out.String())
+	}
+}
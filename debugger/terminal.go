@@ -0,0 +1,192 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/NilCent/eval/object"
+)
+
+const prompt = "(dbg) "
+
+const help = `break N      set a breakpoint at line N (b)
+clear N      remove the breakpoint at line N
+breakpoints  list the breakpoints
+continue     run to the next breakpoint (c)
+step         go to the next line, into calls (s)
+next         go to the next line, over calls (n)
+out          go to the next line of the caller (o)
+print EXPR   evaluate EXPR where stopped (p)
+env          list the variables of every enclosing scope
+stack        list the calls being evaluated (bt)
+list         show the source around the current line (l)
+quit         stop the evaluation (q)
+`
+
+// Terminal is a front-end reading the commands of a user from in and
+// writing to out. Its Pause method is the pause function of a debugger.
+type Terminal struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+	// lines is the source of the script, for listing.
+	lines []string
+}
+
+// NewTerminal returns a front-end debugging the script of source.
+func NewTerminal(in io.Reader, out io.Writer, source string) *Terminal {
+	return &Terminal{scanner: bufio.NewScanner(in), out: out, lines: strings.Split(source, "\n")}
+}
+
+// Pause prints where the evaluation stopped and runs the commands of
+// the user up to one resuming it. The end of the input quits.
+func (t *Terminal) Pause(d *Debugger) Action {
+	f := d.Stack()[0]
+	where := fmt.Sprintf("line %d", f.Line)
+	if f.Function != nil {
+		where += " in " + f.Name
+	}
+	fmt.Fprintf(t.out, "stopped at %s: %s\n", where, t.source(f))
+
+	for {
+		fmt.Fprint(t.out, prompt)
+		if !t.scanner.Scan() {
+			fmt.Fprintln(t.out)
+			return Quit
+		}
+
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(t.scanner.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch cmd {
+		case "":
+		case "break", "b":
+			if line, ok := t.line(arg); ok {
+				d.Break(line)
+				fmt.Fprintf(t.out, "breakpoint at line %d\n", line)
+			}
+		case "clear":
+			if line, ok := t.line(arg); ok {
+				d.Clear(line)
+			}
+		case "breakpoints":
+			for _, line := range d.Breakpoints() {
+				fmt.Fprintf(t.out, "line %d\n", line)
+			}
+		case "continue", "c":
+			return Continue
+		case "step", "s":
+			return StepInto
+		case "next", "n":
+			return StepOver
+		case "out", "o":
+			return StepOut
+		case "print", "p":
+			t.print(d, arg)
+		case "env":
+			t.env(f.Env)
+		case "stack", "bt":
+			for i, f := range d.Stack() {
+				fmt.Fprintf(t.out, "#%d %s at line %d\n", i, f.Name, f.Line)
+			}
+		case "list", "l":
+			t.list(d, f.Line)
+		case "help", "h":
+			io.WriteString(t.out, help)
+		case "quit", "q":
+			return Quit
+		default:
+			fmt.Fprintf(t.out, "unknown command %s, see help\n", cmd)
+		}
+	}
+}
+
+// source returns the source of the line of f, the statement if the
+// script is not at hand.
+func (t *Terminal) source(f Frame) string {
+	if f.Line > 0 && f.Line <= len(t.lines) {
+		if text := strings.TrimSpace(t.lines[f.Line-1]); text != "" {
+			return text
+		}
+	}
+	return f.Statement.String()
+}
+
+func (t *Terminal) line(arg string) (int, bool) {
+	line, err := strconv.Atoi(arg)
+	if err != nil || line < 1 {
+		fmt.Fprintf(t.out, "expected a line number, got %q\n", arg)
+		return 0, false
+	}
+	return line, true
+}
+
+func (t *Terminal) print(d *Debugger, input string) {
+	if input == "" {
+		fmt.Fprintln(t.out, "expected an expression")
+		return
+	}
+	val, err := d.Eval(input)
+	switch {
+	case err != nil:
+		fmt.Fprintf(t.out, "error: %v\n", err)
+	case val == nil:
+		fmt.Fprintln(t.out, "no value")
+	default:
+		fmt.Fprintln(t.out, val.Inspect())
+	}
+}
+
+// env lists the variables of env and of the environments enclosing it,
+// the innermost first.
+func (t *Terminal) env(env *object.Environment) {
+	for depth := 0; env != nil; depth++ {
+		scope := fmt.Sprintf("scope %d", depth)
+		if env.Outer() == nil {
+			scope = "global"
+		}
+		fmt.Fprintf(t.out, "%s:\n", scope)
+		for _, name := range env.Names() {
+			val, _ := env.Get(name)
+			fmt.Fprintf(t.out, "  %s = %s\n", name, short(val))
+		}
+		env = env.Outer()
+	}
+}
+
+// short returns val as printed in a list of variables, functions
+// without their body.
+func short(val object.Object) string {
+	if fn, ok := val.(*object.Function); ok {
+		return signature(fn)
+	}
+	return val.Inspect()
+}
+
+// list shows the lines around line, marking it and the breakpoints.
+func (t *Terminal) list(d *Debugger, line int) {
+	breakpoints := make(map[int]bool)
+	for _, bp := range d.Breakpoints() {
+		breakpoints[bp] = true
+	}
+
+	from, to := line-2, line+2
+	if from < 1 {
+		from = 1
+	}
+	if to > len(t.lines) {
+		to = len(t.lines)
+	}
+	for n := from; n <= to; n++ {
+		mark := "  "
+		if n == line {
+			mark = "=>"
+		}
+		bp := " "
+		if breakpoints[n] {
+			bp = "*"
+		}
+		fmt.Fprintf(t.out, "%s%s%4d  %s\n", mark, bp, n, t.lines[n-1])
+	}
+}
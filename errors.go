@@ -0,0 +1,43 @@
+package eval
+
+import "fmt"
+
+// ErrSyntax reports a script that does not parse. Err is the error of
+// the lexer or the parser.
+type ErrSyntax struct {
+	Err error
+}
+
+func (e *ErrSyntax) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrSyntax) Unwrap() error {
+	return e.Err
+}
+
+// ErrStatic reports a script that parses but is rejected before it
+// runs, such as one reading an undefined variable or too large for the
+// compiler. Err is the error of the resolver or the compiler.
+type ErrStatic struct {
+	Err error
+}
+
+func (e *ErrStatic) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrStatic) Unwrap() error {
+	return e.Err
+}
+
+// ErrRuntime reports an error raised while evaluating a script and not
+// caught by it.
+type ErrRuntime struct {
+	Message string
+	Line    int
+}
+
+func (e *ErrRuntime) Error() string {
+	return fmt.Sprintf("ERROR: %s", e.Message)
+}
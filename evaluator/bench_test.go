@@ -0,0 +1,82 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/resolver"
+)
+
+// The benchmarks evaluate representative scripts parsed once, resolved
+// as by the interpreter or not. Run them with
+//
+//	go test ./evaluator -run NONE -bench . -benchmem
+var benchmarks = []struct {
+	name  string
+	input string
+}{
+	{"Arithmetic", "let price = 120; let qty = 3; price * qty - price * qty / 10 + 5"},
+	{"Decimal", "let price = 19.99dec; let qty = 3; price * qty * 1.08dec - 2.50dec"},
+	{"Conditions", `let score = 72; if (score > 90) { "A" } else { if (score > 70) { "B" } else { "C" } }`},
+	{"Calls", "let discount = fn(price, rate) { price - price * rate / 100 }; discount(200, 15) + discount(80, 5)"},
+	{"Recursion", "let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(15)"},
+	{"Closures", "let adder = fn(x) { fn(y) { x + y } }; let add2 = adder(2); add2(3) + add2(4)"},
+}
+
+func BenchmarkEval(b *testing.B) {
+	benchmarkEval(b, false)
+}
+
+func BenchmarkEvalResolved(b *testing.B) {
+	benchmarkEval(b, true)
+}
+
+func benchmarkEval(b *testing.B, resolve bool) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			program := parseProgram(b, bm.input)
+			if resolve {
+				if err := resolver.Resolve(program, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if evaluated := Eval(program, object.NewEnvironment()); isError(evaluated) {
+					b.Fatal(evaluated.Inspect())
+				}
+			}
+		})
+	}
+}
+
+func TestAllocations(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected float64
+	}{
+		// small integers and literals are shared
+		{"1 + 2 * 3 - 4", 0},
+		{`"total" == "total"`, 0},
+		{"1.5 < 2.5", 0},
+		// the function, the environment of the call and its arguments
+		{"let inc = fn(x) { x + 1 }; inc(41)", 3},
+	}
+
+	for _, tc := range testCases {
+		program := parseProgram(t, tc.input)
+		if err := resolver.Resolve(program, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		env := object.NewEnvironment()
+		allocs := testing.AllocsPerRun(100, func() {
+			Eval(program, env)
+		})
+		if allocs > tc.expected {
+			t.Errorf("%s: expected at most %v allocations, got %v", tc.input, tc.expected, allocs)
+		}
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"github.com/NilCent/eval/ast"
 	"github.com/NilCent/eval/object"
+	"math"
+	"math/big"
+	"time"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
@@ -32,16 +35,62 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return &object.ReturnValue{Value: val}
 
+	case *ast.ThrowStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return newThrow(val, node.Token.Line)
+
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		setVariable(env, node.Name, val)
+
+	case *ast.ExportStatement:
+		return Eval(node.Statement, env)
+
+	case *ast.ImportStatement:
+		return withLine(evalImportStatement(node, env), node.Token.Line)
 
 	// Expressions
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		if obj, ok := node.Object.(object.Object); ok {
+			return obj
+		}
+		return object.NativeInt(node.Value)
+
+	case *ast.BigIntegerLiteral:
+		if obj, ok := node.Object.(object.Object); ok {
+			return obj
+		}
+		return &object.BigInteger{Value: node.Value}
+
+	case *ast.FloatLiteral:
+		if obj, ok := node.Object.(object.Object); ok {
+			return obj
+		}
+		return &object.Float{Value: node.Value}
+
+	case *ast.DecimalLiteral:
+		if obj, ok := node.Object.(object.Object); ok {
+			return obj
+		}
+		return &object.Decimal{Value: node.Value, Scale: node.Scale}
+
+	case *ast.DurationLiteral:
+		if obj, ok := node.Object.(object.Object); ok {
+			return obj
+		}
+		return &object.Duration{Value: node.Value}
+
+	case *ast.StringLiteral:
+		if obj, ok := node.Object.(object.Object); ok {
+			return obj
+		}
+		return &object.String{Value: node.Value}
 
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
@@ -51,9 +100,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return withLine(evalPrefixExpression(node.Operator, right), node.Token.Line)
 
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -64,31 +116,57 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return withLine(evalInfixExpression(node.Operator, left, right, env.Runtime()), node.Token.Line)
 
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
+
+	case *ast.MemberExpression:
+		obj := Eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return withLine(evalMemberExpression(obj, node.Property.Value), node.Token.Line)
+
+	case *ast.HashLiteral:
+		return withLine(evalHashLiteral(node, env), node.Token.Line)
+
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return withLine(evalIndexExpression(left, index), node.Token.Line)
+
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return withLine(evalIdentifier(node, env), node.Token.Line)
 
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		return &object.Function{Parameters: params, Env: env, Body: body, Slots: node.Slots}
 
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
+		function, args, err := evalCall(node, env)
+		if err != nil {
+			return err
 		}
 
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return applyFunction(function, args)
+		return withLine(applyFunction(function, args), node.Token.Line)
 	}
 
 	return nil
@@ -98,6 +176,9 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range program.Statements {
+		if err := enter(statement, env); err != nil {
+			return err
+		}
 		result = Eval(statement, env)
 
 		switch result := result.(type) {
@@ -118,6 +199,9 @@ func evalBlockStatement(
 	var result object.Object
 
 	for _, statement := range block.Statements {
+		if err := enter(statement, env); err != nil {
+			return err
+		}
 		result = Eval(statement, env)
 
 		if result != nil {
@@ -149,13 +233,46 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	}
 }
 
+// evalLogicalExpression evaluates the right operand of && and || only
+// when the left one does not decide the result, which is a boolean.
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	if isTruthy(left) == (node.Operator == "||") {
+		return nativeBoolToBooleanObject(isTruthy(left))
+	}
+
+	right := Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
 func evalInfixExpression(
 	operator string,
 	left, right object.Object,
+	rt *object.Runtime,
 ) object.Object {
 	switch {
+	case isRational(left) && isRational(right) &&
+		(operator == "/" && rationalMode(rt) ||
+			left.Type() == object.RATIONAL_OBJ || right.Type() == object.RATIONAL_OBJ):
+		return evalRationalInfixExpression(operator, left, right)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntegerInfixExpression(operator, left, right)
+	case isDecimal(left) && isDecimal(right):
+		return evalDecimalInfixExpression(operator, left, right, decimalContext(rt))
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case isTemporal(left) || isTemporal(right):
+		return evalTimeInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
@@ -183,12 +300,25 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.NewInteger(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return object.NativeInt(-right.Value)
+	case *object.BigInteger:
+		return object.NewInteger(new(big.Int).Neg(right.Value))
+	case *object.Rational:
+		return &object.Rational{Value: new(big.Rat).Neg(right.Value)}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	case *object.Duration:
+		return &object.Duration{Value: -right.Value}
+	case *object.Decimal:
+		return &object.Decimal{Value: new(big.Rat).Neg(right.Value), Scale: right.Scale}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
 func evalIntegerInfixExpression(
@@ -200,13 +330,156 @@ func evalIntegerInfixExpression(
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		if sum := leftVal + rightVal; (sum > leftVal) == (rightVal > 0) {
+			return object.NativeInt(sum)
+		}
+	case "-":
+		if diff := leftVal - rightVal; (diff < leftVal) == (rightVal > 0) {
+			return object.NativeInt(diff)
+		}
+	case "*":
+		product := leftVal * rightVal
+		if leftVal == 0 || product/leftVal == rightVal &&
+			!(leftVal == -1 && rightVal == math.MinInt64) {
+			return object.NativeInt(product)
+		}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		if leftVal != math.MinInt64 || rightVal != -1 {
+			return object.NativeInt(leftVal / rightVal)
+		}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+
+	// the result overflows an int64
+	return evalBigIntegerInfixExpression(operator, left, right)
+}
+
+func isInteger(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.INTEGER_OBJ || t == object.BIG_INTEGER_OBJ
+}
+
+// evalBigIntegerInfixExpression handles the integers beyond int64,
+// demoting results back to integers when they fit.
+func evalBigIntegerInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal, _ := object.ToBigInt(left)
+	rightVal, _ := object.ToBigInt(right)
+
+	switch operator {
+	case "+":
+		return object.NewInteger(new(big.Int).Add(leftVal, rightVal))
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		return object.NewInteger(new(big.Int).Sub(leftVal, rightVal))
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		return object.NewInteger(new(big.Int).Mul(leftVal, rightVal))
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return object.NewInteger(new(big.Int).Quo(leftVal, rightVal))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+// isRational reports whether obj takes part in rational arithmetic,
+// which is exact for integers and rationals alike.
+func isRational(obj object.Object) bool {
+	return obj.Type() == object.RATIONAL_OBJ || isInteger(obj)
+}
+
+func rationalMode(rt *object.Runtime) bool {
+	return rt != nil && rt.Rational
+}
+
+func toRational(obj object.Object) *big.Rat {
+	if integer, ok := object.ToBigInt(obj); ok {
+		return new(big.Rat).SetInt(integer)
+	}
+	return obj.(*object.Rational).Value
+}
+
+// evalRationalInfixExpression computes exact fractions, normalising
+// the results with a denominator of 1 back to integers.
+func evalRationalInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := toRational(left)
+	rightVal := toRational(right)
+
+	switch operator {
+	case "+":
+		return object.NewRational(new(big.Rat).Add(leftVal, rightVal))
+	case "-":
+		return object.NewRational(new(big.Rat).Sub(leftVal, rightVal))
+	case "*":
+		return object.NewRational(new(big.Rat).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return object.NewRational(new(big.Rat).Quo(leftVal, rightVal))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+// evalFloatInfixExpression handles floats and the mix of floats and
+// integers, the integer operand being promoted to a float.
+func evalFloatInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: leftVal / rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
@@ -221,6 +494,207 @@ func evalIntegerInfixExpression(
 	}
 }
 
+// isDecimal reports whether obj takes part in decimal arithmetic, in
+// which integers are promoted to decimals. Floats never mix with
+// decimals, since they would make the result inexact.
+func isDecimal(obj object.Object) bool {
+	return obj.Type() == object.DECIMAL_OBJ || isInteger(obj)
+}
+
+func decimalContext(rt *object.Runtime) object.DecimalContext {
+	if rt != nil && rt.Decimal != nil {
+		return *rt.Decimal
+	}
+	return object.DefaultDecimalContext
+}
+
+func toDecimal(obj object.Object) *object.Decimal {
+	if integer, ok := object.ToBigInt(obj); ok {
+		return &object.Decimal{Value: new(big.Rat).SetInt(integer)}
+	}
+	return obj.(*object.Decimal)
+}
+
+// evalDecimalInfixExpression computes exact results, rounded to the
+// scale of the context when they have more fractional digits.
+func evalDecimalInfixExpression(
+	operator string,
+	left, right object.Object,
+	ctx object.DecimalContext,
+) object.Object {
+	leftVal := toDecimal(left)
+	rightVal := toDecimal(right)
+
+	scale := leftVal.Scale
+	if rightVal.Scale > scale {
+		scale = rightVal.Scale
+	}
+
+	result := new(big.Rat)
+	switch operator {
+	case "+":
+		result.Add(leftVal.Value, rightVal.Value)
+	case "-":
+		result.Sub(leftVal.Value, rightVal.Value)
+	case "*":
+		result.Mul(leftVal.Value, rightVal.Value)
+		scale = leftVal.Scale + rightVal.Scale
+	case "/":
+		if rightVal.Value.Sign() == 0 {
+			return newError("division by zero")
+		}
+		result.Quo(leftVal.Value, rightVal.Value)
+		scale = ctx.Scale
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Value.Cmp(rightVal.Value) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Value.Cmp(rightVal.Value) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Value.Cmp(rightVal.Value) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Value.Cmp(rightVal.Value) != 0)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+
+	if scale > ctx.Scale {
+		scale = ctx.Scale
+	}
+	return ctx.Round(result, scale)
+}
+
+func isNumber(obj object.Object) bool {
+	return isRational(obj) || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInteger:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	case *object.Rational:
+		f, _ := obj.Value.Float64()
+		return f
+	}
+	return obj.(*object.Float).Value
+}
+
+func isTemporal(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.TIME_OBJ || t == object.DURATION_OBJ
+}
+
+// evalTimeInfixExpression compares times and durations and implements
+// the arithmetic between them: time ± duration, time - time, and the
+// scaling of durations by integers.
+func evalTimeInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	switch l := left.(type) {
+	case *object.Time:
+		switch r := right.(type) {
+		case *object.Time:
+			switch operator {
+			case "-":
+				return &object.Duration{Value: l.Value.Sub(r.Value)}
+			case "<":
+				return nativeBoolToBooleanObject(l.Value.Before(r.Value))
+			case ">":
+				return nativeBoolToBooleanObject(l.Value.After(r.Value))
+			case "==":
+				return nativeBoolToBooleanObject(l.Value.Equal(r.Value))
+			case "!=":
+				return nativeBoolToBooleanObject(!l.Value.Equal(r.Value))
+			}
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Time{Value: l.Value.Add(r.Value)}
+			case "-":
+				return &object.Time{Value: l.Value.Add(-r.Value)}
+			}
+		}
+	case *object.Duration:
+		switch r := right.(type) {
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Duration{Value: l.Value + r.Value}
+			case "-":
+				return &object.Duration{Value: l.Value - r.Value}
+			case "<":
+				return nativeBoolToBooleanObject(l.Value < r.Value)
+			case ">":
+				return nativeBoolToBooleanObject(l.Value > r.Value)
+			case "==":
+				return nativeBoolToBooleanObject(l.Value == r.Value)
+			case "!=":
+				return nativeBoolToBooleanObject(l.Value != r.Value)
+			}
+		case *object.Time:
+			if operator == "+" {
+				return &object.Time{Value: r.Value.Add(l.Value)}
+			}
+		case *object.Integer:
+			switch operator {
+			case "*":
+				return scaleDuration(l.Value, r.Value)
+			case "/":
+				if r.Value == 0 {
+					return newError("division by zero")
+				}
+				return &object.Duration{Value: l.Value / time.Duration(r.Value)}
+			}
+		}
+	case *object.Integer:
+		if r, ok := right.(*object.Duration); ok && operator == "*" {
+			return scaleDuration(r.Value, l.Value)
+		}
+	}
+
+	if operator == "==" || operator == "!=" {
+		return nativeBoolToBooleanObject(operator == "!=")
+	}
+	if left.Type() != right.Type() {
+		return newError("type mismatch: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+	return newError("unknown operator: %s %s %s",
+		left.Type(), operator, right.Type())
+}
+
+func scaleDuration(d time.Duration, n int64) object.Object {
+	scaled := d * time.Duration(n)
+	if n != 0 && scaled/time.Duration(n) != d {
+		return newError("duration overflow")
+	}
+	return &object.Duration{Value: scaled}
+}
+
+func evalStringInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
 func evalIfExpression(
 	ie *ast.IfExpression,
 	env *object.Environment,
@@ -239,11 +713,154 @@ func evalIfExpression(
 	}
 }
 
+func evalTryExpression(
+	te *ast.TryExpression,
+	env *object.Environment,
+) object.Object {
+	result := Eval(te.Block, env)
+
+	if err, ok := result.(*object.Error); ok && te.Catch != nil {
+		var catchEnv *object.Environment
+		if te.CatchSlots != nil {
+			catchEnv = object.NewSlotEnvironment(env, te.CatchSlots)
+		} else {
+			catchEnv = object.NewEnclosedEnvironment(env)
+		}
+		setVariable(catchEnv, te.Param, &object.Exception{
+			Message: err.Message,
+			Line:    err.Line,
+			Value:   err.Value,
+		})
+		result = Eval(te.Catch, catchEnv)
+	}
+
+	if te.Finally != nil {
+		// a return or an error raised by finally replaces the pending result
+		final := Eval(te.Finally, env)
+		if final != nil {
+			ft := final.Type()
+			if ft == object.RETURN_VALUE_OBJ || ft == object.ERROR_OBJ {
+				return final
+			}
+		}
+	}
+
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+// evalImportStatement binds the names exported by the imported module
+// in the importing environment.
+func evalImportStatement(
+	is *ast.ImportStatement,
+	env *object.Environment,
+) object.Object {
+	rt := env.Runtime()
+	if rt == nil || rt.Importer == nil {
+		return newError("import not supported: %s", is.Path.Value)
+	}
+
+	module, err := rt.Importer.Import(is.Path.Value)
+	if err != nil {
+		return newError("%s", err)
+	}
+
+	if is.Alias != nil {
+		setVariable(env, is.Alias, module)
+		return nil
+	}
+
+	for _, name := range module.Exports {
+		val, _ := module.Env.Get(name)
+		env.Set(name, val)
+	}
+
+	return nil
+}
+
+func evalMemberExpression(obj object.Object, name string) object.Object {
+	accessor, ok := obj.(object.Accessor)
+	if !ok {
+		return newError("member access on %s: %s", obj.Type(), name)
+	}
+
+	val, ok := accessor.Member(name)
+	if !ok {
+		return newError("unknown member of %s: %s", obj.Type(), name)
+	}
+
+	return val
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		i, ok := index.(*object.Integer)
+		if !ok {
+			return newError("index operator not supported: %s[%s]", left.Type(), index.Type())
+		}
+		if i.Value < 0 || i.Value >= int64(len(left.Elements)) {
+			return NULL
+		}
+		return left.Elements[i.Value]
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		pair, ok := left.Pairs[key.HashKey()]
+		if !ok {
+			return NULL
+		}
+		return pair.Value
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+func evalHashLiteral(
+	node *ast.HashLiteral,
+	env *object.Environment,
+) object.Object {
+	pairs := make(map[object.HashKey]object.HashPair)
+
+	for i, keyNode := range node.Keys {
+		key := Eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(node.Values[i], env)
+		if isError(value) {
+			return value
+		}
+
+		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+
+	return &object.Hash{Pairs: pairs}
+}
+
 func evalIdentifier(
 	node *ast.Identifier,
 	env *object.Environment,
 ) object.Object {
-	val, ok := env.Get(node.Value)
+	if node.Local {
+		// an unset slot is a variable read before its let statement ran,
+		// which finds the variable of the same name of an outer scope
+		if val := env.Slot(node.Depth, node.Slot); val != nil {
+			return val
+		}
+	}
+
+	val, ok := lookup(env, node.Value)
 	if !ok {
 		return newError("identifier not found: " + node.Value)
 	}
@@ -251,6 +868,35 @@ func evalIdentifier(
 	return val
 }
 
+// Defined reports whether an identifier naming a global variable of a
+// program resolved for env would be found, name being bound in env or
+// a builtin.
+func Defined(env *object.Environment, name string) bool {
+	_, ok := lookup(env, name)
+	return ok
+}
+
+func lookup(env *object.Environment, name string) (object.Object, bool) {
+	if val, ok := env.Get(name); ok {
+		return val, true
+	}
+	if rt := env.Runtime(); rt != nil {
+		val, ok := rt.Builtins[name]
+		return val, ok
+	}
+	return nil, false
+}
+
+// setVariable binds a variable declared by a let statement, an import
+// alias or a parameter in env.
+func setVariable(env *object.Environment, name *ast.Identifier, val object.Object) {
+	if name.Local {
+		env.SetSlot(name.Slot, val)
+	} else {
+		env.Set(name.Value, val)
+	}
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -268,6 +914,24 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// newThrow turns a thrown value into an error. Rethrowing a caught
+// exception keeps its original message and line.
+func newThrow(val object.Object, line int) *object.Error {
+	if ex, ok := val.(*object.Exception); ok {
+		return &object.Error{Message: ex.Message, Line: ex.Line, Value: ex.Value}
+	}
+	return &object.Error{Message: val.Inspect(), Line: line, Value: val}
+}
+
+// withLine records the line of the node that raised a runtime error,
+// leaving errors that already carry a position untouched.
+func withLine(obj object.Object, line int) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Line == 0 {
+		err.Line = line
+	}
+	return obj
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -279,7 +943,7 @@ func evalExpressions(
 	exps []ast.Expression,
 	env *object.Environment,
 ) []object.Object {
-	var result []object.Object
+	result := make([]object.Object, 0, len(exps))
 
 	for _, e := range exps {
 		evaluated := Eval(e, env)
@@ -292,21 +956,81 @@ func evalExpressions(
 	return result
 }
 
+// evalCall evaluates the function and the arguments of a call, or
+// returns the error raised by one of them.
+func evalCall(
+	node *ast.CallExpression,
+	env *object.Environment,
+) (object.Object, []object.Object, object.Object) {
+	function := Eval(node.Function, env)
+	if isError(function) {
+		return nil, nil, function
+	}
+
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return nil, nil, args[0]
+	}
+
+	return function, args, nil
+}
+
+// applyFunction calls fn. The calls a function makes in tail position
+// are made in turn by the loop rather than nested, so that recursive
+// functions making them run in constant stack space.
 func applyFunction(fn object.Object, args []object.Object) object.Object {
-	function, ok := fn.(*object.Function)
-	if !ok {
-		return newError("not a function: %s", fn.Type())
+	line := 0
+	for {
+		function, ok := fn.(*object.Function)
+		if !ok {
+			return withLine(applyBuiltin(fn, args), line)
+		}
+		if len(args) != len(function.Parameters) {
+			return withLine(newError("wrong number of arguments: want=%d, got=%d",
+				len(function.Parameters), len(args)), line)
+		}
+
+		extendedEnv := extendFunctionEnv(function, args)
+		hook := hookOf(extendedEnv)
+		if hook != nil {
+			hook.Call(function, extendedEnv)
+		}
+		evaluated := unwrapReturnValue(evalTailBlock(function.Body, extendedEnv, true))
+
+		call, ok := evaluated.(*tailCall)
+		if hook != nil {
+			if ok {
+				hook.Return(function, nil)
+			} else {
+				hook.Return(function, evaluated)
+			}
+		}
+		if !ok {
+			return withLine(evaluated, line)
+		}
+		fn, args, line = call.fn, call.args, call.line
 	}
+}
 
-	extendedEnv := extendFunctionEnv(function, args)
-	evaluated := Eval(function.Body, extendedEnv)
-	return unwrapReturnValue(evaluated)
+func applyBuiltin(fn object.Object, args []object.Object) object.Object {
+	if builtin, ok := fn.(*object.Builtin); ok {
+		return builtin.Fn(args...)
+	}
+	return newError("not a function: %s", fn.Type())
 }
 
 func extendFunctionEnv(
 	fn *object.Function,
 	args []object.Object,
 ) *object.Environment {
+	if fn.Slots != nil {
+		env := object.NewSlotEnvironment(fn.Env, fn.Slots)
+		for paramIdx, param := range fn.Parameters {
+			env.SetSlot(param.Slot, args[paramIdx])
+		}
+		return env
+	}
+
 	env := object.NewEnclosedEnvironment(fn.Env)
 
 	for paramIdx, param := range fn.Parameters {
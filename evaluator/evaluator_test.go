@@ -0,0 +1,626 @@
+package evaluator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+)
+
+func parseProgram(t testing.TB, input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatalf("parse %q: %s", input, err)
+	}
+
+	return program
+}
+
+// engines are the other implementations of the language, registered
+// by their tests. Every program evaluated by the tests is run through
+// each of them, which must agree with the evaluator.
+var engines = map[string]func(*ast.Program, *object.Environment) object.Object{}
+
+func testEval(t *testing.T, input string) object.Object {
+	return testEvalIn(t, input, object.NewEnvironment)
+}
+
+// testEvalIn evaluates input in a fresh environment from newEnv for
+// the evaluator and each of the engines. Each is given a program of its
+// own, since engines such as the resolver annotate it.
+func testEvalIn(t *testing.T, input string, newEnv func() *object.Environment) object.Object {
+	evaluated := Eval(parseProgram(t, input), newEnv())
+
+	for name, run := range engines {
+		got := run(parseProgram(t, input), newEnv())
+		if describe(got) != describe(evaluated) {
+			t.Errorf("%s: %s disagrees with the evaluator. want=%s, got=%s",
+				input, name, describe(evaluated), describe(got))
+		}
+	}
+
+	return evaluated
+}
+
+func describe(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "NULL null"
+	case *object.Error:
+		return fmt.Sprintf("ERROR line %d: %s", obj.Line, obj.Message)
+	}
+	return fmt.Sprintf("%s %s", obj.Type(), obj.Inspect())
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d",
+			result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t",
+			result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	return true
+}
+
+func testObject(t *testing.T, obj object.Object, expected interface{}) bool {
+	switch v := expected.(type) {
+	case int:
+		return testIntegerObject(t, obj, int64(v))
+	case int64:
+		return testIntegerObject(t, obj, v)
+	case bool:
+		return testBooleanObject(t, obj, v)
+	case nil:
+		return testNullObject(t, obj)
+	}
+	t.Errorf("type of expected not handled. got=%T", expected)
+	return false
+}
+
+func TestEvalIntegerExpression(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"50 / 2 * 2 + 10", 60},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+	}
+
+	for _, tc := range testCases {
+		testIntegerObject(t, testEval(t, tc.input), tc.expected)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"true == false", false},
+		{"(1 < 2) == true", true},
+		{"!true", false},
+		{"!!5", true},
+		{"true && false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"false || 1 > 2", false},
+		{"false || \"\"", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		// the right operand is not evaluated once the left one decides
+		{"false && 1 / 0 == 0", false},
+		{"true || fn() { throw \"boom\" }()", true},
+	}
+
+	for _, tc := range testCases {
+		testBooleanObject(t, testEval(t, tc.input), tc.expected)
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, testEval(t, tc.input), tc.expected)
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{`if (10 > 1) {
+  if (10 > 1) {
+    return 10;
+  }
+  return 1;
+}`, 10},
+	}
+
+	for _, tc := range testCases {
+		testIntegerObject(t, testEval(t, tc.input), tc.expected)
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{`let newAdder = fn(x) { fn(y) { x + y }; };
+let addTwo = newAdder(2);
+addTwo(2);`, 4},
+	}
+
+	for _, tc := range testCases {
+		testIntegerObject(t, testEval(t, tc.input), tc.expected)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; let f = fn() { x }; let x = 2; f()", 2},
+		{"let f = fn() { let g = fn() { y }; let y = 3; g() }; f()", 3},
+		{"let x = 1; let f = fn() { let a = x; let x = 2; a + x }; f()", 3},
+		{"let f = fn() { if (true) { let y = 4 }; y }; f()", 4},
+		{"if (true) { let z = 5 }; z", 5},
+		{"let f = fn(n) { let g = fn() { n }; let n = n + 1; g() }; f(1)", 2},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(10)", 3628800},
+		{"let f = fn() { let r = fn(n) { if (n == 0) { 0 } else { r(n - 1) } }; r(5) }; f()", 0},
+		{"let e = 1; try { throw 2 } catch (e) { e.value }; e", 1},
+		{"let f = fn() { let e = 1; try { throw 2 } catch (e) { let e = 3 }; e }; f()", 1},
+		{"let f = fn() { try { throw 2 } catch (e) { let g = fn() { e.value } }; 0 }; f()", 0},
+		{"let f = fn(x) { try { throw x } catch (e) { fn() { e.value + x } } }; f(2)()", 4},
+		{"let f = fn() { try { let a = 6 } finally { 0 }; a }; f()", 6},
+		{"let f = fn() { try { 1 / 0 } catch (e) { return 7 }; 8 }; f()", 7},
+		{"let f = fn() { try { 1 } finally { 2 }; 9 }; f()", 9},
+		{"let f = fn() { let g = fn() { return 1; }; g() + 1 }; f()", 2},
+		{"let f = fn() { if (true) { return 10; }; 11 }; f()", 10},
+		{"fn(a, b) { a }(1, 2)", 1},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, testEval(t, tc.input), tc.expected)
+	}
+
+	errObj, ok := testEval(t, "let f = fn() { let a = z; let z = 1; a }; f()").(*object.Error)
+	if !ok || errObj.Message != "identifier not found: z" {
+		t.Errorf("expected z to be undefined before its let statement. got=%#v", errObj)
+	}
+	errObj, ok = testEval(t, "try { throw 2 } catch (e) { let inner = 1 }; inner").(*object.Error)
+	if !ok || errObj.Message != "identifier not found: inner" {
+		t.Errorf("expected catch bindings to stay in the catch clause. got=%#v", errObj)
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedMessage string
+		expectedLine    int
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN", 1},
+		{"-true", "unknown operator: -BOOLEAN", 1},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN", 1},
+		{"let a = 1;\nfoobar", "identifier not found: foobar", 2},
+		{"let f = fn() {\n  1 / 0\n};\nf()", "division by zero", 2},
+		{"\n\nthrow 42", "42", 3},
+		{"let f = fn() {\n  g()\n};\nf()", "identifier not found: g", 2},
+		{"let f = fn(x) {\n  x.y\n};\n\nf(1)", "unknown member of INTEGER: y", 2},
+		{"let f = fn() { 1 };\nf()()", "not a function: INTEGER", 2},
+		{"let f = fn(a, b) { a };\nf(1)", "wrong number of arguments: want=2, got=1", 2},
+		{"fn(x) { x; }(5, 6)", "wrong number of arguments: want=1, got=2", 1},
+		{"let f = fn() {\n  fn() { 1 }(2)\n};\nf()", "wrong number of arguments: want=0, got=1", 2},
+		{"let g = fn(a, b) { a };\nlet f = fn() {\n  g()\n};\nf()", "wrong number of arguments: want=2, got=0", 3},
+		{"let f = fn(a, b) { a };\ntry { f(1) } catch (e) { throw e.message }", "wrong number of arguments: want=2, got=1", 2},
+		{"{\n[1]: 1 / 0}", "unusable as hash key: ARRAY", 1},
+		{"let f = fn() {\n  try { 1 / 0 } finally { 0 }\n};\nf()", "division by zero", 2},
+		{"let f = fn() {\n  try { 1 } catch (e) { 0 } finally { throw 3 }\n};\nf()", "3", 2},
+		{"try { 1 } catch (e) {\n  throw e\n}; -true", "unknown operator: -BOOLEAN", 3},
+		{"try { missing } catch (e) {\n  throw e\n}", "identifier not found: missing", 1},
+		{"true &&\n1 / 0 == 0", "division by zero", 2},
+		{"false || missing", "identifier not found: missing", 1},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(t, tc.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tc.expectedMessage, errObj.Message)
+		}
+		if errObj.Line != tc.expectedLine {
+			t.Errorf("wrong error line for %q. expected=%d, got=%d",
+				tc.input, tc.expectedLine, errObj.Line)
+		}
+	}
+}
+
+func TestTryExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"try { 1 } catch (e) { 2 }", 1},
+		{"try { throw 1; 2 } catch (e) { 3 }", 3},
+		{"let x = try { 1 / 0 } catch (e) { -1 }; x", -1},
+		{"try { 1 } finally { 2 }", 1},
+		{"try { throw 1 } catch (e) { 2 } finally { 3 }", 2},
+		{"let f = fn() { try { return 1; } finally { return 2; } }; f()", 2},
+		{"let f = fn() { try { return 1; } catch (e) { 3 } }; f()", 1},
+		{"try { try { throw 1 } catch (e) { throw e } } catch (e) { 5 }", 5},
+		{"try { try { throw 1 } finally { 9 } } catch (e) { 5 }", 5},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, testEval(t, tc.input), tc.expected)
+	}
+}
+
+func TestCaughtException(t *testing.T) {
+	evaluated := testEval(t, "try {\n  missing\n} catch (err) { err }")
+
+	ex, ok := evaluated.(*object.Exception)
+	if !ok {
+		t.Fatalf("object is not Exception. got=%T (%+v)", evaluated, evaluated)
+	}
+	if ex.Message != "identifier not found: missing" {
+		t.Errorf("wrong message. got=%q", ex.Message)
+	}
+	if ex.Line != 2 {
+		t.Errorf("wrong line. got=%d", ex.Line)
+	}
+	if ex.Value != nil {
+		t.Errorf("runtime errors carry no thrown value. got=%+v", ex.Value)
+	}
+}
+
+func TestCaughtThrownValue(t *testing.T) {
+	evaluated := testEval(t, "try { throw 7 } catch (e) { e }")
+
+	ex, ok := evaluated.(*object.Exception)
+	if !ok {
+		t.Fatalf("object is not Exception. got=%T (%+v)", evaluated, evaluated)
+	}
+	if ex.Message != "7" || ex.Line != 1 {
+		t.Errorf("wrong exception. got=%+v", ex)
+	}
+	testIntegerObject(t, ex.Value, 7)
+}
+
+func TestUncaughtFinallyError(t *testing.T) {
+	evaluated := testEval(t, "try { 1 } finally { throw 2 }")
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	testIntegerObject(t, errObj.Value, 2)
+}
+
+func TestStringExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"foo" + "bar" == "foobar"`, true},
+		{`"foo" != "foo"`, false},
+		{`let greet = fn(name) { "hi " + name }; greet("bob") == "hi bob"`, true},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, testEval(t, tc.input), tc.expected)
+	}
+
+	evaluated := testEval(t, `"a\"b" + "\n"`)
+	str, ok := evaluated.(*object.String)
+	if !ok || str.Value != "a\"b\n" {
+		t.Errorf("wrong string. got=%#v", evaluated)
+	}
+}
+
+func TestImportWithoutImporter(t *testing.T) {
+	evaluated := testEval(t, `import "lib"`)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "import not supported: lib" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+type testRecord struct {
+	age int64
+}
+
+func (r *testRecord) Type() object.ObjectType { return "RECORD" }
+func (r *testRecord) Inspect() string         { return "record" }
+func (r *testRecord) Member(name string) (object.Object, bool) {
+	if name == "age" {
+		return &object.Integer{Value: r.age}, true
+	}
+	return nil, false
+}
+
+func TestMemberExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"age": 3}.age`, 3},
+		{`let user = {"age": 3, "next": {"age": 4}}; user.age + user.next.age`, 7},
+		{`math.max(2, 5)`, 5},
+		{`math.double(math.max(2, 5))`, 10},
+		{`host.age * 2`, 84},
+		{`try { throw 7 } catch (e) { e.value + e.line }`, 8},
+		{`try { 1 / 0 } catch (e) { e.message == "division by zero" }`, true},
+	}
+
+	defs := object.NewEnvironment()
+	Eval(parseProgram(t, `let max = fn(a, b) { if (a > b) { a } else { b } };
+let double = fn(x) { x * 2 };`), defs)
+	max, _ := defs.Get("max")
+	double, _ := defs.Get("double")
+
+	newEnv := func() *object.Environment {
+		env := object.NewEnvironment()
+		env.Set("math", object.NewNamespace("math", map[string]object.Object{
+			"max":    max,
+			"double": double,
+		}))
+		env.Set("host", &testRecord{age: 42})
+		return env
+	}
+
+	for _, tc := range testCases {
+		testObject(t, testEvalIn(t, tc.input, newEnv), tc.expected)
+	}
+}
+
+func TestMemberErrors(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`5.age`, "unknown member of INTEGER: age"},
+		{`{"age": 3}.name`, "unknown member of HASH: name"},
+		{`{fn(x) { x }: 1}`, "unusable as hash key: FUNCTION"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(t, tc.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tc.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"-2.25", -2.25},
+		{"1.5 + 1", 2.5},
+		{"3 * 0.5", 1.5},
+		{"1 / 4.0", 0.25},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(t, tc.input)
+		f, ok := evaluated.(*object.Float)
+		if !ok || f.Value != tc.expected {
+			t.Errorf("%s: expected %v, got %#v", tc.input, tc.expected, evaluated)
+		}
+	}
+
+	testBooleanObject(t, testEval(t, "1.0 == 1"), true)
+	testBooleanObject(t, testEval(t, "2 > 1.5"), true)
+	testBooleanObject(t, testEval(t, "0.1 * 3 - 0.3 < 0.0001"), true)
+}
+
+func TestBigIntegerExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"-1 * (-9223372036854775807 - 1)", "9223372036854775808"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"99999999999999999999 - 99999999999999999998", "1"},
+		{"18446744073709551616 / 4294967296", "4294967296"},
+		{"-99999999999999999999 / 10", "-9999999999999999999"},
+		{"let f = fn(n) { if (n < 2) { 1 } else { n * f(n - 1) } }; f(25)", "15511210043330985984000000"},
+		{"99999999999999999999 > 1", "true"},
+		{"1 < -99999999999999999999", "false"},
+		{"18446744073709551616 == 4294967296 * 4294967296", "true"},
+		{"18446744073709551616 != 18446744073709551615", "true"},
+		{"18446744073709551616 * 0.5", "9223372036854776000"},
+		{"18446744073709551616 + 0.25dec", "18446744073709551616.25"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(t, tc.input)
+		if evaluated.Inspect() != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.input, tc.expected, evaluated.Inspect())
+		}
+	}
+
+	testIntegerObject(t, testEval(t, "99999999999999999999 - 99999999999999999998"), 1)
+	testObject(t, testEval(t, `{18446744073709551616: 1}[4294967296 * 4294967296]`), 1)
+
+	errObj, ok := testEval(t, "99999999999999999999 / 0").(*object.Error)
+	if !ok || errObj.Message != "division by zero" {
+		t.Errorf("expected division by zero, got %#v", errObj)
+	}
+}
+
+func rationalEnv() *object.Environment {
+	env := object.NewEnvironment()
+	env.SetRuntime(&object.Runtime{Rational: true})
+	return env
+}
+
+func TestRationalExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"1 / 3", "1/3"},
+		{"6 / 3", "2"},
+		{"-2 / 4", "-1/2"},
+		{"2 / -4", "-1/2"},
+		{"1 / 3 + 1 / 3 + 1 / 3", "1"},
+		{"1 / 3 * 3 == 1", "true"},
+		{"1 / 2 - 1", "-1/2"},
+		{"-(1 / 2)", "-1/2"},
+		{"(1 / 2) / (1 / 4)", "2"},
+		{"1 / 3 < 1 / 2", "true"},
+		{"1 / 3 > 0", "true"},
+		{"1 / 3 == 2 / 6", "true"},
+		{"1 / 3 != 1", "true"},
+		{"1 / 2 + 0.25", "0.75"},
+		{"99999999999999999999 / 10", "99999999999999999999/10"},
+		{"(2 / 3).num", "2"},
+		{"(2 / 3).den", "3"},
+		{"(-4 / 6).num", "-2"},
+		{"(-4 / 6).den", "3"},
+		// whole quotients are integers, which have the same members
+		{"let r = 6 / 3; r.num", "2"},
+		{"let r = 6 / 3; r.den", "1"},
+		{"(99999999999999999999 / 1).den", "1"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEvalIn(t, tc.input, rationalEnv)
+		if evaluated.Inspect() != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.input, tc.expected, evaluated.Inspect())
+		}
+	}
+
+	testIntegerObject(t, testEval(t, "1 / 3"), 0)
+}
+
+func TestRationalErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"1 / 0", "division by zero"},
+		{"(1 / 3) / 0", "division by zero"},
+		{"1 / 3 + 1.5dec", "type mismatch: RATIONAL + DECIMAL"},
+		{`1 / 3 + "a"`, "type mismatch: RATIONAL + STRING"},
+		{"(1 / 3).nope", "unknown member of RATIONAL: nope"},
+	}
+
+	for _, tc := range testCases {
+		errObj, ok := testEvalIn(t, tc.input, rationalEnv).(*object.Error)
+		if !ok || errObj.Message != tc.expected {
+			t.Errorf("%s: expected %q, got %#v", tc.input, tc.expected, errObj)
+		}
+	}
+}
+
+func TestArrayAndIndexExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2 * 2, 3 + 3][1]", 4},
+		{"let a = [1, 2, 3]; a[0] + a[1] + a[2]", 6},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", nil},
+		{`{"a": 5}["a"]`, 5},
+		{`{"a": 5}["b"]`, nil},
+		{`{1: true}[1]`, true},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, testEval(t, tc.input), tc.expected)
+	}
+
+	errObj, ok := testEval(t, `1[0]`).(*object.Error)
+	if !ok || errObj.Message != "index operator not supported: INTEGER" {
+		t.Errorf("expected index error, got %#v", errObj)
+	}
+}
@@ -0,0 +1,13 @@
+package evaluator
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/object"
+)
+
+// RegisterEngine adds an implementation of the language checked
+// against the evaluator by every test of this package. run is given a
+// program parsed for it alone, which it may modify.
+func RegisterEngine(name string, run func(*ast.Program, *object.Environment) object.Object) {
+	engines[name] = run
+}
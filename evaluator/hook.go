@@ -0,0 +1,23 @@
+package evaluator
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/object"
+)
+
+// hookOf returns the hook of the runtime of env, nil if none.
+func hookOf(env *object.Environment) object.Hook {
+	if rt := env.Runtime(); rt != nil {
+		return rt.Hook
+	}
+	return nil
+}
+
+// enter calls the hook, if any, before stmt is evaluated in env. It
+// returns the error stopping the evaluation, nil to go on.
+func enter(stmt ast.Statement, env *object.Environment) *object.Error {
+	if hook := hookOf(env); hook != nil {
+		return hook.Statement(stmt, env)
+	}
+	return nil
+}
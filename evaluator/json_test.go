@@ -0,0 +1,21 @@
+package evaluator_test
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/object"
+)
+
+func init() {
+	evaluator.RegisterEngine("json", func(program *ast.Program, env *object.Environment) object.Object {
+		data, err := ast.Marshal(program)
+		if err != nil {
+			return &object.Error{Message: err.Error()}
+		}
+		decoded, err := ast.Unmarshal(data)
+		if err != nil {
+			return &object.Error{Message: err.Error()}
+		}
+		return evaluator.Eval(decoded, env)
+	})
+}
@@ -0,0 +1,14 @@
+package evaluator_test
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/optimizer"
+)
+
+func init() {
+	evaluator.RegisterEngine("optimizer", func(program *ast.Program, env *object.Environment) object.Object {
+		return evaluator.Eval(optimizer.Optimize(program, env.Runtime()), env)
+	})
+}
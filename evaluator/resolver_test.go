@@ -0,0 +1,17 @@
+package evaluator_test
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/resolver"
+)
+
+func init() {
+	evaluator.RegisterEngine("resolver", func(program *ast.Program, env *object.Environment) object.Object {
+		// the program is annotated even when a name is undefined, which
+		// then fails when evaluated as it does in an unresolved program
+		resolver.Resolve(program, nil)
+		return evaluator.Eval(program, env)
+	})
+}
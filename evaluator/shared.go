@@ -0,0 +1,41 @@
+package evaluator
+
+import "github.com/NilCent/eval/object"
+
+// The functions below expose the semantics of operators and calls to
+// the vm package, so that both engines agree on results and errors.
+
+// Infix applies a binary operator to two values.
+func Infix(operator string, left, right object.Object, rt *object.Runtime) object.Object {
+	return evalInfixExpression(operator, left, right, rt)
+}
+
+// Prefix applies a unary operator to a value.
+func Prefix(operator string, right object.Object) object.Object {
+	return evalPrefixExpression(operator, right)
+}
+
+// Index looks up an element of an array or a hash.
+func Index(left, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// Member looks up a member of an object exposing some.
+func Member(obj object.Object, name string) object.Object {
+	return evalMemberExpression(obj, name)
+}
+
+// Throw turns a thrown value into an error raised at line.
+func Throw(val object.Object, line int) *object.Error {
+	return newThrow(val, line)
+}
+
+// IsTruthy reports whether a condition holding obj is met.
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
+
+// Apply calls a builtin or a function created by the evaluator.
+func Apply(fn object.Object, args []object.Object) object.Object {
+	return applyFunction(fn, args)
+}
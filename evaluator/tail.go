@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/object"
+)
+
+// tailCall is a call made in tail position by the body of a function,
+// returned to applyFunction to be made once the body is left.
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+	line int
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Inspect() string         { return "tail call" }
+
+// evalTailBlock evaluates a block of a function body, returning a
+// tailCall for the calls in tail position: the value of a return
+// statement and, when the value of the block is the result of the
+// function, of its last expression. Calls in a try expression are
+// never in tail position, since its clauses act on their result.
+func evalTailBlock(
+	block *ast.BlockStatement,
+	env *object.Environment,
+	result bool,
+) object.Object {
+	var evaluated object.Object
+
+	for i, statement := range block.Statements {
+		if err := enter(statement, env); err != nil {
+			return err
+		}
+		evaluated = evalTailStatement(statement, env, result && i == len(block.Statements)-1)
+
+		if evaluated != nil {
+			rt := evaluated.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return evaluated
+			}
+		}
+	}
+
+	return evaluated
+}
+
+func evalTailStatement(
+	statement ast.Statement,
+	env *object.Environment,
+	result bool,
+) object.Object {
+	switch statement := statement.(type) {
+	case *ast.ReturnStatement:
+		if call, ok := statement.ReturnValue.(*ast.CallExpression); ok {
+			val := evalTailCall(call, env)
+			if isError(val) {
+				return val
+			}
+			return &object.ReturnValue{Value: val}
+		}
+
+	case *ast.ExpressionStatement:
+		switch exp := statement.Expression.(type) {
+		case *ast.IfExpression:
+			return evalTailIfExpression(exp, env, result)
+		case *ast.CallExpression:
+			if result {
+				return evalTailCall(exp, env)
+			}
+		}
+	}
+
+	return Eval(statement, env)
+}
+
+func evalTailIfExpression(
+	ie *ast.IfExpression,
+	env *object.Environment,
+	result bool,
+) object.Object {
+	condition := Eval(ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return evalTailBlock(ie.Consequence, env, result)
+	} else if ie.Alternative != nil {
+		return evalTailBlock(ie.Alternative, env, result)
+	} else {
+		return NULL
+	}
+}
+
+func evalTailCall(node *ast.CallExpression, env *object.Environment) object.Object {
+	function, args, err := evalCall(node, env)
+	if err != nil {
+		return err
+	}
+	return &tailCall{fn: function, args: args, line: node.Token.Line}
+}
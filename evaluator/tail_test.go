@@ -0,0 +1,62 @@
+package evaluator
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+const sum = "let sum = fn(n, acc) { if (n == 0) { return acc; } return sum(n - 1, acc + n); };"
+
+func TestTailCalls(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{sum + "sum(1000, 0)", "INTEGER 500500"},
+		{"let count = fn(n) { if (n == 0) { \"done\" } else { count(n - 1) } }; count(1000)", "STRING done"},
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };" +
+			"let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(1001)", "BOOLEAN false"},
+		{"let f = fn(n) { if (n > 0) { return f(n - 1); }; let r = n; r }; f(10)", "INTEGER 0"},
+		{"let f = fn(n) { if (n > 0) { f(n - 1) } }; f(10)", "NULL null"},
+		// calls out of tail position
+		{"let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(100)", "INTEGER 100"},
+		{"let f = fn(n) { let r = if (n == 0) { 0 } else { f(n - 1) }; r + 1 }; f(3)", "INTEGER 4"},
+		{"let f = fn(n) { n + 1 }; let g = fn(n) { if (n == 0) { return 0; } f(0); n }; g(2)", "INTEGER 2"},
+		{"let f = fn() { try { g() } catch (e) { \"caught \" + e.message } }; f()", "STRING caught identifier not found: g"},
+		{"let f = fn() { try { return g(); } catch (e) { \"caught\" } }; let g = fn() { throw 1 }; f()", "STRING caught"},
+		{"let f = fn() { try { return 1; } finally { return g(); } }; let g = fn() { 2 }; f()", "INTEGER 2"},
+	}
+
+	for _, tc := range testCases {
+		if got := describe(testEval(t, tc.input)); got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestTailCallErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn(n) {\n  if (n == 0) { return 1 / n; }\n  f(n - 1)\n};\nf(3)", "ERROR line 2: division by zero"},
+		{"let f = fn(n) {\n  n(1)\n};\nf(3)", "ERROR line 2: not a function: INTEGER"},
+		{"let f = fn() {\n  g()\n};\nlet g = fn() {\n  return h();\n};\nlet h = 1;\nf()", "ERROR line 5: not a function: INTEGER"},
+		{"let f = fn(x) {\n  x\n};\nf(1)(2)", "ERROR line 4: not a function: INTEGER"},
+	}
+
+	for _, tc := range testCases {
+		if got := describe(testEval(t, tc.input)); got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.input, tc.expected, got)
+		}
+	}
+}
+
+// TestTailCallStack runs tail recursive functions much deeper than the
+// stack allows nested calls to go, and than the frames of the VM.
+func TestTailCallStack(t *testing.T) {
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+
+	testIntegerObject(t, testEval(t, sum+"sum(1100000, 0)"), 605000550000)
+	testIntegerObject(t, testEval(t, "let down = fn(n) { if (n == 0) { n } else { down(n - 1) } }; down(300000)"), 0)
+}
@@ -0,0 +1,22 @@
+package evaluator_test
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/compiler"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/resolver"
+	"github.com/NilCent/eval/vm"
+)
+
+func init() {
+	evaluator.RegisterEngine("vm", func(program *ast.Program, env *object.Environment) object.Object {
+		// undefined names are left to the VM to report
+		resolver.Resolve(program, nil)
+		bytecode, err := compiler.Compile(program)
+		if err != nil {
+			return &object.Error{Message: err.Error()}
+		}
+		return vm.New(bytecode, env).Run()
+	})
+}
@@ -0,0 +1,48 @@
+// Package format prints programs in a canonical layout: one statement
+// per line, blocks indented with tabs, statements terminated by
+// semicolons and only the parentheses the grammar requires. Parsing the
+// output of Format gives back an equivalent program.
+package format
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/parser"
+)
+
+// Format returns the source of program, ending with a newline.
+func Format(program *ast.Program) string {
+	text := (&printer{}).statements(program.Statements)
+	if text == "" {
+		return ""
+	}
+	return text + "\n"
+}
+
+// Node returns the source of a statement or an expression, laid out as
+// at the top level of a program, without a final newline.
+func Node(node ast.Node) string {
+	p := &printer{}
+	switch node := node.(type) {
+	case *ast.Program:
+		return p.statements(node.Statements)
+	case *ast.BlockStatement:
+		return p.block(node)
+	case *ast.ExpressionStatement:
+		return p.statement(node)
+	case ast.Statement:
+		return p.statement(node) + ";"
+	case ast.Expression:
+		return p.expression(node, parser.LOWEST)
+	}
+	return ""
+}
+
+// Source parses src and returns it formatted.
+func Source(src string) (string, error) {
+	program, err := parser.New(lexer.New(src)).ParseProgram()
+	if err != nil {
+		return "", err
+	}
+	return Format(program), nil
+}
@@ -0,0 +1,159 @@
+package format
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	return program
+}
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"1+2*3", "1 + 2 * 3\n"},
+		{"(1 + 2) * 3", "(1 + 2) * 3\n"},
+		{"((a * b)) + c", "a * b + c\n"},
+		{"a - (b - c) - (d + e)", "a - (b - c) - (d + e)\n"},
+		{"a == (b == c)", "a == (b == c)\n"},
+		{"(a < b) == (c > d)", "a < b == c > d\n"},
+		{"-(a + b) * -c", "-(a + b) * -c\n"},
+		{"-(-a)", "--a\n"},
+		{"(a || b && c) || (d == e)", "a || b && c || d == e\n"},
+		{"(a || b) && !c", "(a || b) && !c\n"},
+		{"!(a.b) + (f(x))[0]", "!a.b + f(x)[0]\n"},
+		{"(-a).b; (a + b)(c); (a[0])(1)", "(-a).b;\n(a + b)(c);\na[0](1)\n"},
+		{"let x = 5\nlet y = x", "let x = 5;\nlet y = x;\n"},
+		{"f(1) g(2)", "f(1);\ng(2)\n"},
+		{"[1,2 , 3][0]; {\"a\":1,2:true}", "[1, 2, 3][0];\n{\"a\": 1, 2: true}\n"},
+		{"\"a\\tb\\n\\\"c\\\"\\\\\"", "\"a\\tb\\n\\\"c\\\"\\\\\"\n"},
+		{"1.50 + 2.0 + 0.10dec + 30d", "1.5 + 2.0 + 0.10dec + 30d\n"},
+		{"19dec + 19d", "19dec + 19d\n"},
+		{"import \"m\" as m\nimport \"n\"\nexport let a = m.b", "import \"m\" as m;\nimport \"n\";\nexport let a = m.b;\n"},
+		{"let f = fn(x, y) { let z = x + y; if (z > 10) { return z } z * 2 }; f(1, 2)",
+			"let f = fn(x, y) {\n\tlet z = x + y;\n\tif (z > 10) {\n\t\treturn z;\n\t}\n\tz * 2\n};\nf(1, 2)\n"},
+		{"if (a) { b } else { c }", "if (a) {\n\tb\n} else {\n\tc\n}\n"},
+		{"if (a) { } ; fn() { }", "if (a) {}\nfn() {}\n"},
+		{"try { throw 1 } catch (e) { e } finally { done() }",
+			"try {\n\tthrow 1;\n} catch (e) {\n\te\n} finally {\n\tdone()\n}\n"},
+		{"try { 1 } finally { 2 }", "try {\n\t1\n} finally {\n\t2\n}\n"},
+		// a statement after an expression ending with a brace needs a
+		// semicolon only when it would continue the expression
+		{"if (a) { 1 }; -1", "if (a) {\n\t1\n};\n-1\n"},
+		{"fn() { 1 }; (2)", "fn() {\n\t1\n}\n2\n"},
+		{"fn() { 1 }; [2]", "fn() {\n\t1\n};\n[2]\n"},
+		{"fn() { 1 }; (a + b) * 2", "fn() {\n\t1\n};\n(a + b) * 2\n"},
+		{"fn() { 1 }(2)", "fn() {\n\t1\n}(2)\n"},
+		{"{\"a\": 1}; x", "{\"a\": 1}\nx\n"},
+	}
+
+	for _, tc := range testCases {
+		got, err := Source(tc.input)
+		if err != nil {
+			t.Errorf("%s: %v", tc.input, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestNode(t *testing.T) {
+	program := parse(t, "let f = fn(x) { x + 1 }; f(2) * 3")
+	let := program.Statements[0].(*ast.LetStatement)
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.InfixExpression).Left
+
+	testCases := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{let, "let f = fn(x) {\n\tx + 1\n};"},
+		{let.Value.(*ast.FunctionLiteral).Body, "{\n\tx + 1\n}"},
+		{call, "f(2)"},
+		{program.Statements[1], "f(2) * 3"},
+		{program, "let f = fn(x) {\n\tx + 1\n};\nf(2) * 3"},
+	}
+
+	for _, tc := range testCases {
+		if got := Node(tc.node); got != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, got)
+		}
+	}
+}
+
+// inputs are programs whose formatted source parses into an equivalent
+// program, evaluating to the same value.
+var inputs = []string{
+	"let a = 1; let b = 2; a + b * (a - b) / (b - a)",
+	"-(1 + 2) * -(3 - -4) - !true == false",
+	"1 - (2 - (3 - (4 - 5)))",
+	"(((1 < 2) == (3 > 4)) != true)",
+	"let h = {\"a\": [1, 2], \"b\": fn(x) { x * 2 }}; h.b(h[\"a\"][1]) + h.a[0]",
+	"let f = fn(n, acc) { if (n == 0) { return acc; } f(n - 1, acc + n) }; f(100, 0)",
+	"let add = fn(x) { fn(y) { x + y } }; add(1)(2)",
+	"fn(x) { x }(5)",
+	"let r = try { throw {\"code\": 7} } catch (e) { e.value.code } finally { 0 }; r",
+	"try { 1 / 0 } catch (e) { e.message }",
+	"let s = \"tab\\tquote\\\"slash\\\\\nline\"; s + s",
+	"if (1 > 2) { 1 } else { if (2 > 1) { 2 } else { 3 } }",
+	"let x = if (true) { 5 }; x; -x",
+	"let k = fn() { 3 }; k(); (k)()",
+	"0.5 + 1.25",
+	"1.50dec + 0.25dec",
+	"2h + 30m",
+	"123456789012345678901234567890 + 1",
+	"let a = [1, 2, 3]; a[1 + 1] * -a[0]",
+	"let g = fn() { if (true) { 1 } }; g(); [2][0]",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, input := range inputs {
+		program := parse(t, input)
+		formatted := Format(program)
+
+		reparsed, err := parser.New(lexer.New(formatted)).ParseProgram()
+		if err != nil {
+			t.Errorf("%s: formatted as\n%s\ndoes not parse: %v", input, formatted, err)
+			continue
+		}
+		if reparsed.String() != program.String() {
+			t.Errorf("%s: formatted as\n%s\nparses into %q, want %q", input, formatted, reparsed.String(), program.String())
+		}
+		if again := Format(reparsed); again != formatted {
+			t.Errorf("%s: formatting is not stable:\n%s\n%s", input, formatted, again)
+		}
+
+		want := describe(evaluator.Eval(program, object.NewEnvironment()))
+		got := describe(evaluator.Eval(reparsed, object.NewEnvironment()))
+		if got != want {
+			t.Errorf("%s: formatted program evaluates to %s, want %s", input, got, want)
+		}
+	}
+}
+
+// describe describes obj, without the line of an error, which the
+// layout changes.
+func describe(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nothing"
+	case *object.Error:
+		return "ERROR " + obj.Message
+	}
+	return fmt.Sprintf("%s %s", obj.Type(), obj.Inspect())
+}
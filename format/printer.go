@@ -0,0 +1,304 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/token"
+)
+
+// primary is the precedence of expressions never needing parentheses,
+// such as literals, identifiers and if expressions.
+const primary = parser.MEMBER + 1
+
+var precedences = map[string]int{
+	"||": parser.OR,
+	"&&": parser.AND,
+	"==": parser.EQUALS,
+	"!=": parser.EQUALS,
+	"<":  parser.LESSGREATER,
+	">":  parser.LESSGREATER,
+	"+":  parser.SUM,
+	"-":  parser.SUM,
+	"*":  parser.PRODUCT,
+	"/":  parser.PRODUCT,
+}
+
+type printer struct {
+	indent int
+}
+
+func (p *printer) tabs() string {
+	return strings.Repeat("\t", p.indent)
+}
+
+// statements returns stmts one per line at the current indentation.
+// An expression statement needs no semicolon when it is the last one,
+// whose value is that of the block, or when it ends with a brace and
+// the next statement cannot be read as its continuation.
+func (p *printer) statements(stmts []ast.Statement) string {
+	stmts = flatten(stmts)
+
+	texts := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		texts[i] = p.statement(stmt)
+	}
+
+	var out strings.Builder
+	for i, stmt := range stmts {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(p.tabs())
+		out.WriteString(texts[i])
+
+		if _, ok := stmt.(*ast.ExpressionStatement); !ok {
+			out.WriteString(";")
+			continue
+		}
+		if i == len(stmts)-1 {
+			continue
+		}
+		if !strings.HasSuffix(texts[i], "}") || continues(texts[i+1]) {
+			out.WriteString(";")
+		}
+	}
+
+	return out.String()
+}
+
+// continues reports whether a statement starting as text would extend
+// an expression written before it: as its operand, the arguments of a
+// call or an index.
+func continues(text string) bool {
+	return strings.HasPrefix(text, "(") || strings.HasPrefix(text, "[") || strings.HasPrefix(text, "-")
+}
+
+// flatten splices the statements of nested blocks, which the parser
+// only produces as the bodies of expressions, and drops empty ones.
+func flatten(stmts []ast.Statement) []ast.Statement {
+	flat := make([]ast.Statement, 0, len(stmts))
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.BlockStatement:
+			flat = append(flat, flatten(stmt.Statements)...)
+		case *ast.ExpressionStatement:
+			if stmt.Expression != nil {
+				flat = append(flat, stmt)
+			}
+		case nil:
+		default:
+			flat = append(flat, stmt)
+		}
+	}
+	return flat
+}
+
+// statement returns the source of stmt, without its semicolon.
+func (p *printer) statement(stmt ast.Statement) string {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return "let " + stmt.Name.Value + " = " + p.expression(stmt.Value, parser.LOWEST)
+	case *ast.ExportStatement:
+		return "export " + p.statement(stmt.Statement)
+	case *ast.ReturnStatement:
+		return "return " + p.expression(stmt.ReturnValue, parser.LOWEST)
+	case *ast.ThrowStatement:
+		return "throw " + p.expression(stmt.Value, parser.LOWEST)
+	case *ast.ImportStatement:
+		if stmt.Alias != nil {
+			return "import " + quote(stmt.Path.Value) + " as " + stmt.Alias.Value
+		}
+		return "import " + quote(stmt.Path.Value)
+	case *ast.ExpressionStatement:
+		return p.expression(stmt.Expression, parser.LOWEST)
+	case *ast.BlockStatement:
+		return p.statements(stmt.Statements)
+	}
+	return ""
+}
+
+// block returns block between braces, its statements indented one
+// level deeper than the current one.
+func (p *printer) block(block *ast.BlockStatement) string {
+	if len(flatten(block.Statements)) == 0 {
+		return "{}"
+	}
+
+	p.indent++
+	body := p.statements(block.Statements)
+	p.indent--
+
+	return "{\n" + body + "\n" + p.tabs() + "}"
+}
+
+// expression returns the source of exp as an operand of an operator of
+// precedence outer, between parentheses if it binds less tightly.
+func (p *printer) expression(exp ast.Expression, outer int) string {
+	text := p.bare(exp)
+	if precedence(exp, text) < outer {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// precedence returns the precedence of the operator of exp, whose
+// source is text.
+func precedence(exp ast.Expression, text string) int {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return precedences[exp.Operator]
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression, *ast.MemberExpression, *ast.IndexExpression:
+		return parser.CALL
+	}
+	if strings.HasPrefix(text, "-") {
+		// a negative literal, which the parser reads as a prefix
+		// expression
+		return parser.PREFIX
+	}
+	return primary
+}
+
+// bare returns the source of exp without enclosing parentheses.
+func (p *printer) bare(exp ast.Expression) string {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		return exp.Value
+	case *ast.Boolean:
+		return strconv.FormatBool(exp.Value)
+	case *ast.IntegerLiteral:
+		return strconv.FormatInt(exp.Value, 10)
+	case *ast.BigIntegerLiteral:
+		return exp.Value.String()
+	case *ast.FloatLiteral:
+		return float(exp.Value)
+	case *ast.DecimalLiteral:
+		return exp.Value.FloatString(exp.Scale) + token.DecimalSuffix
+	case *ast.DurationLiteral:
+		if exp.Token.Literal != "" {
+			return exp.Token.Literal
+		}
+		return duration(exp.Value)
+	case *ast.StringLiteral:
+		return quote(exp.Value)
+
+	case *ast.PrefixExpression:
+		return exp.Operator + p.expression(exp.Right, parser.PREFIX)
+	case *ast.InfixExpression:
+		prec := precedences[exp.Operator]
+		// operators associate to the left
+		return p.expression(exp.Left, prec) + " " + exp.Operator + " " + p.expression(exp.Right, prec+1)
+
+	case *ast.CallExpression:
+		return p.expression(exp.Function, parser.CALL) + "(" + p.list(exp.Arguments) + ")"
+	case *ast.MemberExpression:
+		return p.expression(exp.Object, parser.CALL) + "." + exp.Property.Value
+	case *ast.IndexExpression:
+		return p.expression(exp.Left, parser.CALL) + "[" + p.expression(exp.Index, parser.LOWEST) + "]"
+
+	case *ast.ArrayLiteral:
+		return "[" + p.list(exp.Elements) + "]"
+	case *ast.HashLiteral:
+		pairs := make([]string, len(exp.Keys))
+		for i, key := range exp.Keys {
+			pairs[i] = p.expression(key, parser.LOWEST) + ": " + p.expression(exp.Values[i], parser.LOWEST)
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+
+	case *ast.IfExpression:
+		text := "if (" + p.expression(exp.Condition, parser.LOWEST) + ") " + p.block(exp.Consequence)
+		if exp.Alternative != nil {
+			text += " else " + p.block(exp.Alternative)
+		}
+		return text
+	case *ast.TryExpression:
+		text := "try " + p.block(exp.Block)
+		if exp.Catch != nil {
+			text += " catch (" + exp.Param.Value + ") " + p.block(exp.Catch)
+		}
+		if exp.Finally != nil {
+			text += " finally " + p.block(exp.Finally)
+		}
+		return text
+	case *ast.FunctionLiteral:
+		params := make([]string, len(exp.Parameters))
+		for i, param := range exp.Parameters {
+			params[i] = param.Value
+		}
+		return "fn(" + strings.Join(params, ", ") + ") " + p.block(exp.Body)
+	}
+	return ""
+}
+
+func (p *printer) list(exps []ast.Expression) string {
+	texts := make([]string, len(exps))
+	for i, exp := range exps {
+		texts[i] = p.expression(exp, parser.LOWEST)
+	}
+	return strings.Join(texts, ", ")
+}
+
+// quote returns s as a string literal, escaping the characters the
+// lexer resolves.
+func quote(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; ch {
+		case '"', '\\':
+			out.WriteByte('\\')
+			out.WriteByte(ch)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			out.WriteByte(ch)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+// float returns a float literal of value f, which has a fractional part
+// unlike an integer literal.
+func float(f float64) string {
+	text := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(text, ".") {
+		text += ".0"
+	}
+	return text
+}
+
+var units = []struct {
+	name string
+	unit time.Duration
+}{
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+}
+
+// duration returns a duration literal of value d, rounded down to the
+// millisecond.
+func duration(d time.Duration) string {
+	var out strings.Builder
+	for _, u := range units {
+		if n := d / u.unit; n > 0 {
+			out.WriteString(strconv.FormatInt(int64(n), 10) + u.name)
+			d -= n * u.unit
+		}
+	}
+	if out.Len() == 0 {
+		return "0ms"
+	}
+	return out.String()
+}
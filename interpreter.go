@@ -2,38 +2,185 @@ package eval
 
 import (
 	"errors"
+	"github.com/NilCent/eval/compiler"
 	"github.com/NilCent/eval/evaluator"
 	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/module"
 	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/optimizer"
 	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/resolver"
+	"github.com/NilCent/eval/stdlib"
+	"github.com/NilCent/eval/vm"
 	"fmt"
+	"math/big"
+	"time"
 )
 
 type interpreter struct {
-	env *object.Environment
+	env       *object.Environment
+	loader    module.Loader
+	libraries []stdlib.Library
+	clock     func() time.Time
+	decimal   *object.DecimalContext
+	rational  bool
+	vm        bool
+	optimize  bool
+	hook      object.Hook
 }
 
-func New() *interpreter {
-	return &interpreter{
-		env: object.NewEnvironment(),
+type Option func(*interpreter)
+
+// WithLoader sets the loader resolving import statements. Without it,
+// scripts read no file and import statements fail with a
+// module.ErrNoLoader.
+func WithLoader(loader module.Loader) Option {
+	return func(i *interpreter) {
+		i.loader = loader
 	}
 }
 
-func (i *interpreter) EvalInt(input string) (int, error) {
+// WithLibraries replaces the built-in libraries, stdlib.Default() by
+// default, installed in the interpreter.
+func WithLibraries(libs ...stdlib.Library) Option {
+	return func(i *interpreter) {
+		i.libraries = libs
+	}
+}
+
+// WithoutLibrary disables the built-in library with the given name.
+func WithoutLibrary(name string) Option {
+	return func(i *interpreter) {
+		libs := []stdlib.Library{}
+		for _, lib := range i.libraries {
+			if lib.Name != name {
+				libs = append(libs, lib)
+			}
+		}
+		i.libraries = libs
+	}
+}
+
+// WithClock sets the clock read by now(), so that scripts can be
+// evaluated at a fixed instant.
+func WithClock(clock func() time.Time) Option {
+	return func(i *interpreter) {
+		i.clock = clock
+	}
+}
+
+// WithDecimal sets the number of fractional digits kept by decimal
+// arithmetic and how the digits beyond it are rounded. Results keep two
+// digits, rounded half-up, by default.
+func WithDecimal(scale int, rounding object.RoundingMode) Option {
+	return func(i *interpreter) {
+		i.decimal = &object.DecimalContext{Scale: scale, Rounding: rounding}
+	}
+}
+
+// WithRational makes the division of integers exact: 1 / 3 evaluates
+// to the rational 1/3 instead of 0, and 1/3 + 2/3 to the integer 1.
+func WithRational() Option {
+	return func(i *interpreter) {
+		i.rational = true
+	}
+}
+
+// WithVM compiles scripts to bytecode run by a virtual machine instead
+// of walking their syntax tree, which is faster on scripts doing much
+// work.
+func WithVM() Option {
+	return func(i *interpreter) {
+		i.vm = true
+	}
+}
 
+// WithOptimizer folds constant expressions, drops the branches of if
+// expressions with constant conditions and inlines calls of trivial
+// functions before evaluating scripts.
+func WithOptimizer() Option {
+	return func(i *interpreter) {
+		i.optimize = true
+	}
+}
+
+// WithHook sets the hook the evaluator calls before each statement and
+// around each call of a function, such as a debugger.Debugger. It is
+// not called with WithVM.
+func WithHook(hook object.Hook) Option {
+	return func(i *interpreter) {
+		i.hook = hook
+	}
+}
+
+func New(opts ...Option) *interpreter {
+	i := &interpreter{
+		env:       object.NewEnvironment(),
+		libraries: stdlib.Default(),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	rt := &object.Runtime{Now: i.clock, Decimal: i.decimal, Rational: i.rational, Hook: i.hook}
+	rt.Importer = module.NewRegistry(i.loader, rt)
+	for _, lib := range i.libraries {
+		stdlib.Install(rt, lib)
+	}
+	i.env.SetRuntime(rt)
+
+	return i
+}
+
+func (i *interpreter) eval(input string) (object.Object, error) {
 	l := lexer.New(input)
 	p := parser.New(l)
 
 	program, err := p.ParseProgram()
+	if err != nil {
+		return nil, &ErrSyntax{Err: err}
+	}
+	if i.optimize {
+		program = optimizer.Optimize(program, i.env.Runtime())
+	}
+	err = resolver.Resolve(program, func(name string) bool {
+		return evaluator.Defined(i.env, name)
+	})
+	if err != nil {
+		return nil, &ErrStatic{Err: err}
+	}
+
+	var evaluated object.Object
+	if i.vm {
+		bytecode, err := compiler.Compile(program)
+		if err != nil {
+			return nil, &ErrStatic{Err: err}
+		}
+		evaluated = vm.New(bytecode, i.env).Run()
+	} else {
+		evaluated = evaluator.Eval(program, i.env)
+	}
+	if err, ok := evaluated.(*object.Error); ok {
+		return nil, &ErrRuntime{Message: err.Message, Line: err.Line}
+	}
+
+	return evaluated, nil
+}
+
+// Eval evaluates input and returns the value of its last statement,
+// nil if it has none.
+func (i *interpreter) Eval(input string) (object.Object, error) {
+	return i.eval(input)
+}
+
+func (i *interpreter) EvalInt(input string) (int, error) {
+	evaluated, err := i.eval(input)
 	if err != nil {
 		return 0, err
 	}
 
-	evaluated := evaluator.Eval(program, i.env)
 	if evaluated != nil {
-		if evaluated.Type() == object.ERROR_OBJ {
-			return 0, errors.New(evaluated.Inspect())
-		} else if evaluated.Type() != object.INTEGER_OBJ {
+		if evaluated.Type() != object.INTEGER_OBJ {
 			return 0, errors.New(fmt.Sprintf("expect %s, got %s", object.INTEGER_OBJ, evaluated.Type()))
 		} else {
 			return int(evaluated.(*object.Integer).Value), nil
@@ -43,16 +190,97 @@ func (i *interpreter) EvalInt(input string) (int, error) {
 	return 0, nil
 }
 
-func (i *interpreter) Do(input string) error {
-	l := lexer.New(input)
-	p := parser.New(l)
+// EvalBigInt evaluates input to an integer of any size.
+func (i *interpreter) EvalBigInt(input string) (*big.Int, error) {
+	evaluated, err := i.eval(input)
+	if err != nil {
+		return nil, err
+	}
 
-	program, err := p.ParseProgram()
+	switch evaluated := evaluated.(type) {
+	case *object.Integer:
+		return big.NewInt(evaluated.Value), nil
+	case *object.BigInteger:
+		return new(big.Int).Set(evaluated.Value), nil
+	case nil:
+		return nil, errors.New(fmt.Sprintf("expect %s, got nothing", object.INTEGER_OBJ))
+	default:
+		return nil, errors.New(fmt.Sprintf("expect %s, got %s", object.INTEGER_OBJ, evaluated.Type()))
+	}
+}
+
+// EvalRational evaluates input to a rational or an integer.
+func (i *interpreter) EvalRational(input string) (*big.Rat, error) {
+	evaluated, err := i.eval(input)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	evaluator.Eval(program, i.env)
+	switch evaluated := evaluated.(type) {
+	case *object.Rational:
+		return new(big.Rat).Set(evaluated.Value), nil
+	case *object.Integer, *object.BigInteger:
+		n, _ := object.ToBigInt(evaluated)
+		return new(big.Rat).SetInt(n), nil
+	case nil:
+		return nil, errors.New(fmt.Sprintf("expect %s, got nothing", object.RATIONAL_OBJ))
+	default:
+		return nil, errors.New(fmt.Sprintf("expect %s, got %s", object.RATIONAL_OBJ, evaluated.Type()))
+	}
+}
+
+// EvalDecimal evaluates input to a decimal or an integer.
+func (i *interpreter) EvalDecimal(input string) (*big.Rat, error) {
+	d, err := i.evalDecimal(input)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Set(d.Value), nil
+}
+
+// EvalDecimalString evaluates input to a decimal or an integer and
+// formats it with its fractional digits, as in "39.98".
+func (i *interpreter) EvalDecimalString(input string) (string, error) {
+	d, err := i.evalDecimal(input)
+	if err != nil {
+		return "", err
+	}
+	return d.Inspect(), nil
+}
+
+func (i *interpreter) evalDecimal(input string) (*object.Decimal, error) {
+	evaluated, err := i.eval(input)
+	if err != nil {
+		return nil, err
+	}
+
+	switch evaluated := evaluated.(type) {
+	case *object.Decimal:
+		return evaluated, nil
+	case *object.Integer:
+		return &object.Decimal{Value: new(big.Rat).SetInt64(evaluated.Value)}, nil
+	case *object.BigInteger:
+		return &object.Decimal{Value: new(big.Rat).SetInt(evaluated.Value)}, nil
+	case nil:
+		return nil, errors.New(fmt.Sprintf("expect %s, got nothing", object.DECIMAL_OBJ))
+	default:
+		return nil, errors.New(fmt.Sprintf("expect %s, got %s", object.DECIMAL_OBJ, evaluated.Type()))
+	}
+}
+
+func (i *interpreter) Do(input string) error {
+	_, err := i.eval(input)
+	return err
+}
+
+// Env returns the global environment of the interpreter, holding the
+// variables bound by scripts and by Set.
+func (i *interpreter) Env() *object.Environment {
+	return i.env
+}
 
-	return nil
+// Set binds name to a host-provided value, such as a namespace built
+// with object.NewNamespace or a type implementing object.Accessor.
+func (i *interpreter) Set(name string, val object.Object) {
+	i.env.Set(name, val)
 }
@@ -0,0 +1,267 @@
+package eval
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"testing/fstest"
+
+	"github.com/NilCent/eval/module"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/stdlib"
+)
+
+func TestLibraries(t *testing.T) {
+	i := New()
+	if n, err := i.EvalInt(`len(upper("abc")) + math.abs(-1)`); err != nil || n != 4 {
+		t.Errorf("expected 4, got %d (%v)", n, err)
+	}
+
+	i = New(WithoutLibrary("strings"))
+	if _, err := i.EvalInt(`len("abc")`); err == nil || err.Error() != "Line 1 Undefined identifier: len" {
+		t.Errorf("strings library must be disabled, got %v", err)
+	}
+	if n, err := i.EvalInt(`math.abs(-1)`); err != nil || n != 1 {
+		t.Errorf("expected 1, got %d (%v)", n, err)
+	}
+
+	i = New(WithLibraries(stdlib.Strings))
+	if _, err := i.EvalInt(`math.abs(-1)`); err == nil {
+		t.Errorf("math library must be disabled")
+	}
+}
+
+func TestEvalDecimal(t *testing.T) {
+	testCases := []struct {
+		input    string
+		opts     []Option
+		expected string
+	}{
+		{"19.99dec * 2", nil, "39.98"},
+		{"0.10dec + 0.20dec == 0.30dec", nil, ""},
+		{"10.00dec / 3", nil, "3.33"},
+		{"2.00dec / 3", nil, "0.67"},
+		{"1.005dec * 1", nil, "1.01"},
+		{"1.005dec * 1", []Option{WithDecimal(2, object.RoundHalfEven)}, "1.00"},
+		{"1.015dec * 1", []Option{WithDecimal(2, object.RoundHalfEven)}, "1.02"},
+		{"1.009dec * 1", []Option{WithDecimal(2, object.RoundDown)}, "1.00"},
+		{"-1.005dec * 1", nil, "-1.01"},
+		{"10.00dec / 3", []Option{WithDecimal(4, object.RoundHalfUp)}, "3.3333"},
+		{"decimal(5) + 0.5dec", nil, "5.5"},
+		{"decimal(\"12.345\")", nil, "12.345"},
+		{"decimal(0.1) + decimal(0.2)", nil, "0.3"},
+		{"7", nil, "7"},
+		{"-2.50dec", nil, "-2.50"},
+		{"19dec * 2", nil, "38"},
+		{"let d = 5; 19.99d", nil, "19.99"},
+	}
+
+	for _, tc := range testCases {
+		i := New(tc.opts...)
+		if tc.expected == "" {
+			if _, err := i.EvalDecimal(tc.input); err == nil {
+				t.Errorf("%s: expected an error for a boolean result", tc.input)
+			}
+			continue
+		}
+
+		got, err := i.EvalDecimalString(tc.input)
+		if err != nil {
+			t.Errorf("%s: %s", tc.input, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.input, tc.expected, got)
+		}
+	}
+
+	r, err := New().EvalDecimal("1.25dec + 1")
+	if err != nil || r.Cmp(big.NewRat(9, 4)) != 0 {
+		t.Errorf("expected 9/4, got %v (%v)", r, err)
+	}
+}
+
+func TestDecimalErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"1.5dec + 1.5", "ERROR: type mismatch: DECIMAL + FLOAT"},
+		{"1.5dec / 0", "ERROR: division by zero"},
+		{`decimal("1e5")`, `ERROR: decimal: cannot parse "1e5"`},
+		{`decimal("1/3")`, `ERROR: decimal: cannot parse "1/3"`},
+	}
+
+	for _, tc := range testCases {
+		_, err := New().EvalDecimal(tc.input)
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("%s: expected %q, got %v", tc.input, tc.expected, err)
+		}
+	}
+}
+
+func TestDecimalConversions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int
+	}{
+		{"int(19.99dec)", 19},
+		{"int(-19.99dec)", -19},
+		{"int(2.7)", 2},
+		{"math.floor(-1.5dec)", -2},
+		{"math.ceil(1.01dec)", 2},
+		{"math.round(2.5dec)", 3},
+		{`math.round(2.5dec, "half-even")`, 2},
+		{"if (math.max(1, 2.5dec) == 2.5dec) { 1 } else { 0 }", 1},
+		{"if (math.abs(-2.5dec) == 2.5dec) { 1 } else { 0 }", 1},
+	}
+
+	for _, tc := range testCases {
+		n, err := New().EvalInt(tc.input)
+		if err != nil || n != tc.expected {
+			t.Errorf("%s: expected %d, got %d (%v)", tc.input, tc.expected, n, err)
+		}
+	}
+}
+
+func TestEvalBigInt(t *testing.T) {
+	n, err := New().EvalBigInt("let f = fn(n) { if (n < 2) { 1 } else { n * f(n - 1) } }; f(30)")
+	expected, _ := new(big.Int).SetString("265252859812191058636308480000000", 10)
+	if err != nil || n.Cmp(expected) != 0 {
+		t.Errorf("expected %s, got %s (%v)", expected, n, err)
+	}
+
+	n, err = New().EvalBigInt("6 * 7")
+	if err != nil || n.Int64() != 42 {
+		t.Errorf("expected 42, got %s (%v)", n, err)
+	}
+
+	if _, err := New().EvalInt("9223372036854775807 + 1"); err == nil {
+		t.Errorf("expected EvalInt to reject a big integer")
+	}
+}
+
+func TestEvalRational(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected *big.Rat
+	}{
+		{"1/3 + 1/3 + 1/3", big.NewRat(1, 1)},
+		{"let third = 1 / 3; third * 2", big.NewRat(2, 3)},
+		{"math.abs(-1 / 4)", big.NewRat(1, 4)},
+		{"math.max(1 / 3, 1 / 4)", big.NewRat(1, 3)},
+		{"math.floor(7 / 2)", big.NewRat(3, 1)},
+		{"math.round(-5 / 2)", big.NewRat(-3, 1)},
+		{"int(-7 / 2)", big.NewRat(-3, 1)},
+	}
+
+	for _, tc := range testCases {
+		r, err := New(WithRational()).EvalRational(tc.input)
+		if err != nil || r.Cmp(tc.expected) != 0 {
+			t.Errorf("%s: expected %s, got %v (%v)", tc.input, tc.expected, r, err)
+		}
+	}
+
+	n, err := New().EvalInt("1/3 + 1/3 + 1/3")
+	if err != nil || n != 0 {
+		t.Errorf("expected integer division without WithRational, got %d (%v)", n, err)
+	}
+}
+
+func TestVM(t *testing.T) {
+	i := New(WithVM())
+	if err := i.Do("let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		input    string
+		expected int
+	}{
+		{"fib(20)", 6765},
+		{"math.max(fib(5), 3)", 5},
+		{`len(split("a,b,c", ","))`, 3},
+		{"int(10.00dec / 3)", 3},
+	}
+
+	for _, tc := range testCases {
+		n, err := i.EvalInt(tc.input)
+		if err != nil || n != tc.expected {
+			t.Errorf("%s: expected %d, got %d (%v)", tc.input, tc.expected, n, err)
+		}
+	}
+
+	if _, err := i.EvalInt("\nfib(true)"); err == nil || err.Error() != "ERROR: type mismatch: BOOLEAN < INTEGER" {
+		t.Errorf("expected a type mismatch, got %v", err)
+	}
+}
+
+func TestLoader(t *testing.T) {
+	// scripts read no file unless a loader is set
+	_, err := New().Eval(`import "lib"`)
+	var runtimeErr *ErrRuntime
+	if !errors.As(err, &runtimeErr) || runtimeErr.Message != `module "lib": no loader configured` {
+		t.Errorf("expected no loader, got %v", err)
+	}
+
+	fsys := fstest.MapFS{"lib.eval": {Data: []byte("export let x = 2;")}}
+	i := New(WithLoader(module.FS(fsys)))
+	if n, err := i.EvalInt(`import "lib"; x * 21`); err != nil || n != 42 {
+		t.Errorf("expected 42, got %d (%v)", n, err)
+	}
+}
+
+func TestUndefinedIdentifier(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithVM()}} {
+		i := New(opts...)
+		i.Set("rate", &object.Integer{Value: 3})
+
+		// nothing runs: the branch using the misspelled name is reported
+		// although it would not be taken
+		err := i.Do("let total = rate * 2;\nif (total > 10) { totl }")
+		var staticErr *ErrStatic
+		if !errors.As(err, &staticErr) || err.Error() != "Line 2 Undefined identifier: totl" {
+			t.Errorf("expected an undefined identifier, got %v", err)
+		}
+		if _, err := i.EvalInt("total"); err == nil {
+			t.Errorf("total must not be bound")
+		}
+
+		if n, err := i.EvalInt("let f = fn(x) { x * rate }; f(2)"); err != nil || n != 6 {
+			t.Errorf("expected 6, got %d (%v)", n, err)
+		}
+	}
+}
+
+func TestOptimizer(t *testing.T) {
+	i := New(WithOptimizer(), WithRational())
+	i.Set("x", &object.Integer{Value: 4})
+
+	if r, err := i.EvalRational("let half = fn(n) { n / 2 };\n1 * x + 0 + half(1)"); err != nil || r.String() != "9/2" {
+		t.Errorf("expected 9/2, got %v (%v)", r, err)
+	}
+	if _, err := i.EvalInt("if (true) {\n  x / 0\n}"); err == nil || err.Error() != "ERROR: division by zero" {
+		t.Errorf("expected a division by zero, got %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	i := New()
+
+	_, err := i.Eval("let x = ;")
+	var syntaxErr *ErrSyntax
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+
+	_, err = i.Eval("let x = 1;\nx / 0")
+	var runtimeErr *ErrRuntime
+	if !errors.As(err, &runtimeErr) || runtimeErr.Line != 2 || runtimeErr.Message != "division by zero" {
+		t.Errorf("expected a division by zero on line 2, got %v", err)
+	}
+
+	val, err := i.Eval("[x, 2]")
+	if err != nil || val.Inspect() != "[1, 2]" {
+		t.Errorf("expected [1, 2], got %v (%v)", val, err)
+	}
+}
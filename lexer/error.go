@@ -10,3 +10,11 @@ type ErrUnexpectedChar struct {
 func (e *ErrUnexpectedChar) Error() string {
 	return fmt.Sprintf("Line %d Unexpected character: %s", e.Line, string(e.Char))
 }
+
+type ErrUnterminatedString struct {
+	Line int
+}
+
+func (e *ErrUnterminatedString) Error() string {
+	return fmt.Sprintf("Line %d Unterminated string literal", e.Line)
+}
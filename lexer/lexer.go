@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"strings"
+
 	"github.com/NilCent/eval/token"
 )
 
@@ -9,6 +11,8 @@ type Lexer struct {
 	line    int
 	start   int
 	current int
+	// lineStart is the offset of the first character of the line.
+	lineStart int
 }
 
 func (l *Lexer) advance() byte {
@@ -28,6 +32,13 @@ func (l *Lexer) peek() byte {
 		return l.input[l.current]
 	}
 }
+
+func (l *Lexer) peekNext() byte {
+	if l.current+1 >= len(l.input) {
+		return 0
+	}
+	return l.input[l.current+1]
+}
 func New(input string) *Lexer {
 	l := &Lexer{
 		input: input,
@@ -47,11 +58,83 @@ func (l *Lexer) readIdentifier() string {
 	}
 	return l.input[l.start:l.current]
 }
-func (l *Lexer) readNumber() string {
+func (l *Lexer) readNumber() (token.TokenType, string, error) {
 	for isDigit(l.peek()) {
 		l.advance()
 	}
-	return l.input[l.start:l.current]
+	if l.readDecimalSuffix() {
+		return token.DECIMAL, l.input[l.start:l.current], nil
+	}
+	if isLetter(l.peek()) {
+		// a number directly followed by a unit, such as 30d or 1h30m
+		for isLetter(l.peek()) || isDigit(l.peek()) {
+			l.advance()
+		}
+		return token.DURATION, l.input[l.start:l.current], nil
+	}
+	if l.peek() != '.' || !isDigit(l.peekNext()) {
+		return token.INT, l.input[l.start:l.current], nil
+	}
+
+	l.advance()
+	for isDigit(l.peek()) {
+		l.advance()
+	}
+	// durations are whole numbers, so that 19.99d is a decimal
+	if l.readDecimalSuffix() || l.readSuffix("d") {
+		return token.DECIMAL, l.input[l.start:l.current], nil
+	}
+	if isLetter(l.peek()) {
+		return "", "", &ErrUnexpectedChar{Line: l.line, Char: l.peek()}
+	}
+	return token.FLOAT, l.input[l.start:l.current], nil
+}
+
+// readDecimalSuffix reads token.DecimalSuffix if it ends the number.
+func (l *Lexer) readDecimalSuffix() bool {
+	return l.readSuffix(token.DecimalSuffix)
+}
+
+// readSuffix reads suffix if it ends the number.
+func (l *Lexer) readSuffix(suffix string) bool {
+	end := l.current + len(suffix)
+	if !strings.HasPrefix(l.input[l.current:], suffix) ||
+		end < len(l.input) && (isLetter(l.input[end]) || isDigit(l.input[end])) {
+		return false
+	}
+	l.current = end
+	return true
+}
+
+// readString reads the characters after an opening quote up to the
+// closing one, resolving the \", \\, \n and \t escapes.
+func (l *Lexer) readString() (string, error) {
+	line := l.line
+	var out []byte
+	for {
+		ch := l.advance()
+		switch ch {
+		case 0:
+			return "", &ErrUnterminatedString{Line: line}
+		case '"':
+			return string(out), nil
+		case '\n':
+			l.line++
+			l.lineStart = l.current
+		case '\\':
+			switch next := l.advance(); next {
+			case 'n':
+				ch = '\n'
+			case 't':
+				ch = '\t'
+			case '"', '\\':
+				ch = next
+			default:
+				return "", &ErrUnexpectedChar{Line: l.line, Char: next}
+			}
+		}
+		out = append(out, ch)
+	}
 }
 
 func isDigit(ch byte) bool {
@@ -65,10 +148,12 @@ func (l *Lexer) NextToken() (token.Token, error) {
 	var tok token.Token
 
 Re:
+	column := l.current - l.lineStart + 1
 	ch := l.advance()
 	switch ch {
 	case '\n':
 		l.line++
+		l.lineStart = l.current
 		fallthrough
 	case '\t':
 		fallthrough
@@ -101,6 +186,16 @@ Re:
 		tok = l.newToken(token.SLASH, string(ch))
 	case '*':
 		tok = l.newToken(token.ASTERISK, string(ch))
+	case '&', '|':
+		if l.peek() != ch {
+			return tok, &ErrUnexpectedChar{Line: l.line, Char: ch}
+		}
+		l.advance()
+		if ch == '&' {
+			tok = l.newToken(token.AND, "&&")
+		} else {
+			tok = l.newToken(token.OR, "||")
+		}
 	case '<':
 		tok = l.newToken(token.LT, string(ch))
 	case '>':
@@ -109,14 +204,30 @@ Re:
 		tok = l.newToken(token.SEMICOLON, string(ch))
 	case ',':
 		tok = l.newToken(token.COMMA, string(ch))
+	case ':':
+		tok = l.newToken(token.COLON, string(ch))
+	case '.':
+		tok = l.newToken(token.DOT, string(ch))
 	case '{':
 		tok = l.newToken(token.LBRACE, string(ch))
 	case '}':
 		tok = l.newToken(token.RBRACE, string(ch))
+	case '[':
+		tok = l.newToken(token.LBRACKET, string(ch))
+	case ']':
+		tok = l.newToken(token.RBRACKET, string(ch))
 	case '(':
 		tok = l.newToken(token.LPAREN, string(ch))
 	case ')':
 		tok = l.newToken(token.RPAREN, string(ch))
+	case '"':
+		line := l.line
+		str, err := l.readString()
+		if err != nil {
+			return tok, err
+		}
+		tok = l.newToken(token.STRING, str)
+		tok.Line = line
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -126,10 +237,15 @@ Re:
 			literal := l.readIdentifier()
 			tok = l.newToken(token.LookupIdent(literal), literal)
 		} else if isDigit(ch) {
-			tok = l.newToken(token.INT, l.readNumber())
+			tokenType, literal, err := l.readNumber()
+			if err != nil {
+				return tok, err
+			}
+			tok = l.newToken(tokenType, literal)
 		} else {
 			return tok, &ErrUnexpectedChar{Line: l.line, Char: ch}
 		}
 	}
+	tok.Column = column
 	return tok, nil
 }
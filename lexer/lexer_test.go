@@ -26,6 +26,13 @@ if (5 < 10) {
 
 10 == 10;
 10 != 9;
+"foobar"
+"foo bar"
+import "a/b";
+3.14 a.b 1.
+30d 1h30m 19.99dec 1.5 days
+19dec 19d 19.5d 2decx
+a && b || c
 `
 
 	expectResult := []struct {
@@ -106,6 +113,31 @@ if (5 < 10) {
 		{token.NOT_EQ, "!=", nil},
 		{token.INT, "9", nil},
 		{token.SEMICOLON, ";", nil},
+		{token.STRING, "foobar", nil},
+		{token.STRING, "foo bar", nil},
+		{token.IMPORT, "import", nil},
+		{token.STRING, "a/b", nil},
+		{token.SEMICOLON, ";", nil},
+		{token.FLOAT, "3.14", nil},
+		{token.IDENT, "a", nil},
+		{token.DOT, ".", nil},
+		{token.IDENT, "b", nil},
+		{token.INT, "1", nil},
+		{token.DOT, ".", nil},
+		{token.DURATION, "30d", nil},
+		{token.DURATION, "1h30m", nil},
+		{token.DECIMAL, "19.99dec", nil},
+		{token.FLOAT, "1.5", nil},
+		{token.IDENT, "days", nil},
+		{token.DECIMAL, "19dec", nil},
+		{token.DURATION, "19d", nil},
+		{token.DECIMAL, "19.5d", nil},
+		{token.DURATION, "2decx", nil},
+		{token.IDENT, "a", nil},
+		{token.AND, "&&", nil},
+		{token.IDENT, "b", nil},
+		{token.OR, "||", nil},
+		{token.IDENT, "c", nil},
 		{token.EOF, "", nil},
 	}
 
@@ -119,3 +151,58 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestFloatSuffix(t *testing.T) {
+	for _, input := range []string{"1.5h", "1.5days", "19.99dx", "2.0dec1"} {
+		l := New(input)
+		_, err := l.NextToken()
+		if _, ok := err.(*ErrUnexpectedChar); !ok {
+			t.Errorf("%s: expected an unexpected character, got %v", input, err)
+		}
+	}
+}
+
+func TestSingleLogicalOperator(t *testing.T) {
+	for _, input := range []string{"a & b", "a | b"} {
+		l := New(input)
+		l.NextToken()
+		_, err := l.NextToken()
+		if _, ok := err.(*ErrUnexpectedChar); !ok {
+			t.Errorf("%s: expected an unexpected character, got %v", input, err)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	input := "let s = \"a\nb\" + x;\n\tf(10)"
+
+	expected := []struct {
+		literal      string
+		line, column int
+	}{
+		{"let", 1, 1},
+		{"s", 1, 5},
+		{"=", 1, 7},
+		{"a\nb", 1, 9},
+		{"+", 2, 4},
+		{"x", 2, 6},
+		{";", 2, 7},
+		{"f", 3, 2},
+		{"(", 3, 3},
+		{"10", 3, 4},
+		{")", 3, 6},
+		{"", 3, 7},
+	}
+
+	l := New(input)
+	for _, want := range expected {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.Literal != want.literal || tok.Line != want.line || tok.Column != want.column {
+			t.Errorf("expected %q at %d:%d, got %q at %d:%d",
+				want.literal, want.line, want.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
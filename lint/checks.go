@@ -0,0 +1,153 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/token"
+	"github.com/NilCent/eval/types"
+)
+
+// UnusedLet reports the variables bound by a let statement and never
+// read. Exported variables and those whose name starts with an
+// underscore are not reported.
+var UnusedLet = Check{
+	Name: "unused-let",
+	Doc:  "report let bindings never read",
+	Run: func(program *ast.Program, report Reporter) {
+		for _, s := range scopes(program) {
+			for _, let := range s.lets {
+				name := let.Name.Value
+				if !s.used[name] && !strings.HasPrefix(name, "_") {
+					report(let.Name.Token, "%s declared and not used", name)
+				}
+			}
+		}
+	},
+}
+
+// ShadowedParam reports the parameters of functions named after a
+// variable of an enclosing scope, which the function cannot read.
+var ShadowedParam = Check{
+	Name: "shadowed-param",
+	Doc:  "report parameters hiding a variable of an enclosing scope",
+	Run: func(program *ast.Program, report Reporter) {
+		for _, s := range scopes(program) {
+			for _, param := range s.params {
+				for outer := s.outer; outer != nil; outer = outer.outer {
+					if ident, ok := outer.declared[param.Value]; ok {
+						report(param.Token, "parameter %s shadows the variable declared on line %d",
+							param.Value, ident.Token.Line)
+						break
+					}
+				}
+			}
+		}
+	},
+}
+
+// Unreachable reports the statements following a return or a throw
+// statement in the same block, which never run.
+var Unreachable = Check{
+	Name: "unreachable",
+	Doc:  "report statements after a return or a throw",
+	Run: func(program *ast.Program, report Reporter) {
+		check := func(stmts []ast.Statement) {
+			for i := 0; i+1 < len(stmts); i++ {
+				var keyword string
+				switch stmts[i].(type) {
+				case *ast.ReturnStatement:
+					keyword = "return"
+				case *ast.ThrowStatement:
+					keyword = "throw"
+				default:
+					continue
+				}
+				report(statementToken(stmts[i+1]), "unreachable code after %s", keyword)
+				return
+			}
+		}
+
+		check(program.Statements)
+		ast.Inspect(program, func(node ast.Node) bool {
+			if block, ok := node.(*ast.BlockStatement); ok {
+				check(block.Statements)
+			}
+			return true
+		})
+	},
+}
+
+// ConstantCondition reports the if expressions whose condition is made
+// of literals only, so that the same branch is always taken.
+var ConstantCondition = Check{
+	Name: "constant-condition",
+	Doc:  "report if conditions made of literals only",
+	Run: func(program *ast.Program, report Reporter) {
+		ast.Inspect(program, func(node ast.Node) bool {
+			if exp, ok := node.(*ast.IfExpression); ok && constant(exp.Condition) {
+				report(exp.Token, "if condition is constant: %s", exp.Condition.String())
+			}
+			return true
+		})
+	},
+}
+
+// IntegerCondition reports the if expressions whose condition is an
+// integer. Every value other than false and null is true, so the
+// condition holds even when the integer is 0.
+var IntegerCondition = Check{
+	Name: "integer-condition",
+	Doc:  "report if conditions that are integers, true even when 0",
+	Run: func(program *ast.Program, report Reporter) {
+		info := &types.Info{}
+		types.CheckInfo(program, nil, info)
+
+		ast.Inspect(program, func(node ast.Node) bool {
+			if exp, ok := node.(*ast.IfExpression); ok {
+				switch info.Types[exp.Condition] {
+				case types.Integer, types.Number:
+					report(exp.Token, "if condition is an integer, true even when 0: %s", exp.Condition.String())
+				}
+			}
+			return true
+		})
+	},
+}
+
+// constant reports whether the value of exp depends on literals only.
+func constant(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.Boolean, *ast.IntegerLiteral, *ast.BigIntegerLiteral, *ast.FloatLiteral,
+		*ast.DecimalLiteral, *ast.DurationLiteral, *ast.StringLiteral, *ast.FunctionLiteral:
+		return true
+	case *ast.PrefixExpression:
+		return constant(exp.Right)
+	case *ast.InfixExpression:
+		return constant(exp.Left) && constant(exp.Right)
+	case *ast.ArrayLiteral, *ast.HashLiteral:
+		// never null or false, whatever their elements
+		return true
+	}
+	return false
+}
+
+func statementToken(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.ReturnStatement:
+		return stmt.Token
+	case *ast.ThrowStatement:
+		return stmt.Token
+	case *ast.ImportStatement:
+		return stmt.Token
+	case *ast.ExportStatement:
+		return stmt.Token
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	case *ast.BlockStatement:
+		return stmt.Token
+	}
+	return token.Token{}
+}
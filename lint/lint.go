@@ -0,0 +1,78 @@
+// Package lint reports code that is legal but most likely wrong, such
+// as variables never read or conditions that are always true.
+//
+// Each check is a Check, run on demand, so that checks can be enabled
+// one by one.
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/token"
+)
+
+// Finding is a piece of code reported by a check, positioned at the
+// token it starts with.
+type Finding struct {
+	Check   string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", f.Line, f.Column, f.Message, f.Check)
+}
+
+// Reporter records a finding at tok.
+type Reporter func(tok token.Token, format string, a ...interface{})
+
+// Check looks for one kind of mistake in a program, reporting each one
+// it finds.
+type Check struct {
+	Name string
+	Doc  string
+	Run  func(program *ast.Program, report Reporter)
+}
+
+// Default returns every check of the package.
+func Default() []Check {
+	return []Check{UnusedLet, ShadowedParam, Unreachable, ConstantCondition, IntegerCondition}
+}
+
+// Lookup returns the check of the package named name.
+func Lookup(name string) (Check, bool) {
+	for _, c := range Default() {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Check{}, false
+}
+
+// Run runs checks on program and returns their findings in source
+// order. The program is left untouched.
+func Run(program *ast.Program, checks []Check) []Finding {
+	findings := []Finding{}
+	for _, c := range checks {
+		name := c.Name
+		c.Run(program, func(tok token.Token, format string, a ...interface{}) {
+			findings = append(findings, Finding{
+				Check:   name,
+				Line:    tok.Line,
+				Column:  tok.Column,
+				Message: fmt.Sprintf(format, a...),
+			})
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings
+}
@@ -0,0 +1,126 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	return program
+}
+
+func findings(t *testing.T, input string, checks ...Check) []string {
+	got := []string{}
+	for _, f := range Run(parse(t, input), checks) {
+		got = append(got, f.String())
+	}
+	return got
+}
+
+func TestChecks(t *testing.T) {
+	testCases := []struct {
+		check    Check
+		input    string
+		expected []string
+	}{
+		{UnusedLet, "let a = 1; let b = 2; b", []string{"1:5: a declared and not used (unused-let)"}},
+		{UnusedLet, "let f = fn() { let x = 1; 2 }; f()", []string{"1:20: x declared and not used (unused-let)"}},
+		// read from a function declared before the variable, or from its own value
+		{UnusedLet, "let f = fn() { total }; let total = 1; f()", []string{}},
+		{UnusedLet, "let f = fn(n) { f(n) }", []string{}},
+		// bound in a branch of the enclosing function
+		{UnusedLet, "fn(c) { if (c) { let y = 1 } y }", []string{}},
+		{UnusedLet, "export let a = 1; let _b = 2", []string{}},
+		{UnusedLet, "try { 1 } catch (e) { let m = 1 }", []string{"1:27: m declared and not used (unused-let)"}},
+		// the inner x is another variable
+		{UnusedLet, "let x = 1; fn(x) { x }", []string{"1:5: x declared and not used (unused-let)"}},
+
+		{ShadowedParam, "let x = 1; fn(x) { x }", []string{"1:15: parameter x shadows the variable declared on line 1 (shadowed-param)"}},
+		{ShadowedParam, "fn(a) {\n fn(b, a) { b }\n}", []string{"2:8: parameter a shadows the variable declared on line 1 (shadowed-param)"}},
+		{ShadowedParam, "try { 1 } catch (e) { fn(e) { e } }", []string{"1:26: parameter e shadows the variable declared on line 1 (shadowed-param)"}},
+		{ShadowedParam, "fn(a) { a }; fn(a) { a }", []string{}},
+		{ShadowedParam, "let f = fn(a) { let b = a }; let g = fn(b) { b }", []string{}},
+
+		{Unreachable, "fn() {\n return 1\n 2\n 3\n}", []string{"3:2: unreachable code after return (unreachable)"}},
+		{Unreachable, "fn(x) { if (x) { throw \"bad\"; let y = 1 } x }", []string{"1:31: unreachable code after throw (unreachable)"}},
+		{Unreachable, "return 1; 2", []string{"1:11: unreachable code after return (unreachable)"}},
+		{Unreachable, "fn() { 1; return 2 }", []string{}},
+
+		{ConstantCondition, "if (true) { 1 }", []string{"1:1: if condition is constant: true (constant-condition)"}},
+		{ConstantCondition, "fn() { if (1 > 2) { 1 } }", []string{"1:8: if condition is constant: (1 > 2) (constant-condition)"}},
+		{ConstantCondition, `if (!"") { 1 }`, []string{`1:1: if condition is constant: (!"") (constant-condition)`}},
+		{ConstantCondition, "if ([]) { 1 }", []string{"1:1: if condition is constant: [] (constant-condition)"}},
+		{ConstantCondition, "let a = 1; if (a > 2) { 1 }", []string{}},
+
+		{IntegerCondition, "let n = 0; if (n) { 1 }", []string{"1:12: if condition is an integer, true even when 0: n (integer-condition)"}},
+		{IntegerCondition, "let f = fn(xs) { if (1 - 1) { xs } }", []string{"1:18: if condition is an integer, true even when 0: (1 - 1) (integer-condition)"}},
+		{IntegerCondition, "let n = 0; if (n > 1) { 1 }", []string{}},
+		// parameters are of unknown type
+		{IntegerCondition, "fn(n) { if (n) { 1 } }", []string{}},
+	}
+
+	for _, tc := range testCases {
+		got := findings(t, tc.input, tc.check)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: %s: expected %q, got %q", tc.check.Name, tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	input := `let rate = 0.1
+let unused = 2
+let price = fn(rate, qty) {
+	let n = 0
+	if (n) { return qty; rate }
+}
+if (true) { price(1, 2) }`
+
+	expected := []string{
+		"1:5: rate declared and not used (unused-let)",
+		"2:5: unused declared and not used (unused-let)",
+		"3:16: parameter rate shadows the variable declared on line 1 (shadowed-param)",
+		"5:2: if condition is an integer, true even when 0: n (integer-condition)",
+		"5:23: unreachable code after return (unreachable)",
+		"7:1: if condition is constant: true (constant-condition)",
+	}
+	program := parse(t, input)
+	before := program.String()
+
+	got := []string{}
+	for _, f := range Run(program, Default()) {
+		got = append(got, f.String())
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if program.String() != before {
+		t.Errorf("program modified")
+	}
+
+	got = findings(t, input, ShadowedParam, Unreachable)
+	expected = []string{expected[2], expected[4]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, c := range Default() {
+		got, ok := Lookup(c.Name)
+		if !ok || got.Name != c.Name || got.Doc == "" {
+			t.Errorf("Lookup(%q) = %v, %v", c.Name, got.Name, ok)
+		}
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Errorf("Lookup found an unknown check")
+	}
+}
@@ -0,0 +1,89 @@
+package lint
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/resolver"
+)
+
+// scope tracks the variables of the program, of a function call or of a
+// catch clause, mirroring the environments of the evaluator.
+type scope struct {
+	outer *scope
+	// declared holds the identifier first binding each variable of the
+	// scope.
+	declared map[string]*ast.Identifier
+	// used holds the variables of the scope read somewhere.
+	used map[string]bool
+	// lets are the let statements binding in the scope, in source order,
+	// those of exported variables excluded.
+	lets []*ast.LetStatement
+	// params are the parameters of the function of the scope.
+	params []*ast.Identifier
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, declared: make(map[string]*ast.Identifier), used: make(map[string]bool)}
+}
+
+func (s *scope) define(ident *ast.Identifier) {
+	if _, ok := s.declared[ident.Value]; !ok {
+		s.declared[ident.Value] = ident
+	}
+}
+
+// use marks the variable name read in s as used in the scope binding
+// it.
+func (s *scope) use(name string) {
+	for scope := s; scope != nil; scope = scope.outer {
+		if _, ok := scope.declared[name]; ok {
+			scope.used[name] = true
+			return
+		}
+	}
+}
+
+// scopes returns the scopes of program, in source order, with the
+// variables read in each.
+func scopes(program *ast.Program) []*scope {
+	w := &scopeWalker{}
+	resolver.Walk(program, w)
+	return w.all
+}
+
+type scopeWalker struct {
+	all []*scope
+	// scope is the innermost scope entered.
+	scope *scope
+}
+
+func (w *scopeWalker) Enter(node ast.Node, params []*ast.Identifier) {
+	w.scope = newScope(w.scope)
+	w.all = append(w.all, w.scope)
+	for _, param := range params {
+		w.scope.define(param)
+	}
+	if _, ok := node.(*ast.FunctionLiteral); ok {
+		w.scope.params = params
+	}
+}
+
+func (w *scopeWalker) Declare(decl ast.Statement, name *ast.Identifier) {
+	if name == nil {
+		return
+	}
+	w.scope.define(name)
+	// exported variables are read by the programs importing them
+	if let, ok := decl.(*ast.LetStatement); ok {
+		w.scope.lets = append(w.scope.lets, let)
+	}
+}
+
+func (w *scopeWalker) Bind(decl ast.Statement, name *ast.Identifier) {}
+
+func (w *scopeWalker) Read(ident *ast.Identifier, call bool) {
+	w.scope.use(ident.Value)
+}
+
+func (w *scopeWalker) Leave(node ast.Node) {
+	w.scope = w.scope.outer
+}
@@ -0,0 +1,404 @@
+package lsp
+
+import (
+	"strings"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/format"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/resolver"
+	"github.com/NilCent/eval/token"
+)
+
+type bindingKind int
+
+const (
+	letBinding bindingKind = iota
+	paramBinding
+	catchBinding
+	importBinding
+)
+
+// binding is a variable declared in a document, by a let statement, an
+// import alias or as the parameter of a function or a catch clause.
+type binding struct {
+	name *ast.Identifier
+	kind bindingKind
+	// decl is the let or import statement, the function literal or the
+	// try expression declaring the variable.
+	decl  ast.Node
+	scope *scope
+}
+
+// scope is the region of a document whose variables the evaluator binds
+// in the same environment: the program, a function or a catch clause.
+type scope struct {
+	outer *scope
+	// start and end delimit the region, end being the position of the
+	// closing brace of the body.
+	start, end pos
+	// bindings are the variables declared in the scope, in source
+	// order. A variable bound more than once appears once per binding.
+	bindings []*binding
+}
+
+func (s *scope) contains(p pos) bool {
+	return !p.before(s.start) && !s.end.before(p)
+}
+
+// reference is an identifier naming a variable, where it is read or
+// declared. binding is nil for variables the document does not declare,
+// such as builtins.
+type reference struct {
+	ident   *ast.Identifier
+	binding *binding
+}
+
+// analysis holds the variables of a document that parses and the
+// identifiers referring to them.
+type analysis struct {
+	source
+	program *ast.Program
+	global  *scope
+	// scopes are in source order, nested scopes after the scope they
+	// are nested in.
+	scopes     []*scope
+	functions  map[*ast.FunctionLiteral]*scope
+	references []reference
+	// closing maps the position of each opening bracket to that of its
+	// closing one.
+	closing map[pos]pos
+
+	// uses are the identifiers read, with the scope they are read in,
+	// until they are resolved.
+	uses []use
+	// scope is the innermost scope entered by the walk.
+	scope *scope
+}
+
+type use struct {
+	ident *ast.Identifier
+	scope *scope
+}
+
+// analyze parses text and resolves the identifiers of the program.
+func analyze(text string) (*analysis, error) {
+	program, err := parser.New(lexer.New(text)).ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &analysis{
+		source:    newSource(text),
+		program:   program,
+		functions: make(map[*ast.FunctionLiteral]*scope),
+		closing:   make(map[pos]pos),
+	}
+	if err := a.matchBrackets(); err != nil {
+		return nil, err
+	}
+
+	resolver.Walk(program, a)
+
+	for _, u := range a.uses {
+		a.references = append(a.references, reference{ident: u.ident, binding: resolve(u.ident, u.scope)})
+	}
+	a.uses = nil
+
+	return a, nil
+}
+
+func (a *analysis) matchBrackets() error {
+	l := lexer.New(a.text)
+	var open []pos
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			return err
+		}
+		switch tok.Type {
+		case token.LBRACE, token.LPAREN, token.LBRACKET:
+			open = append(open, pos{tok.Line, tok.Column})
+		case token.RBRACE, token.RPAREN, token.RBRACKET:
+			if n := len(open); n > 0 {
+				a.closing[open[n-1]] = pos{tok.Line, tok.Column}
+				open = open[:n-1]
+			}
+		case token.EOF:
+			return nil
+		}
+	}
+}
+
+func (a *analysis) newScope(outer *scope, start token.Token, body *ast.BlockStatement) *scope {
+	s := &scope{
+		outer: outer,
+		start: pos{start.Line, start.Column},
+		end:   a.closing[pos{body.Token.Line, body.Token.Column}],
+	}
+	a.scopes = append(a.scopes, s)
+	return s
+}
+
+// Enter records the scope of the program, a function or a catch clause
+// as it is walked by resolver.Walk.
+func (a *analysis) Enter(node ast.Node, params []*ast.Identifier) {
+	switch node := node.(type) {
+	case *ast.Program:
+		last := len(a.lines)
+		a.global = &scope{start: pos{1, 1}, end: pos{last, len(a.lines[last-1]) + 1}}
+		a.scopes = append(a.scopes, a.global)
+		a.scope = a.global
+	case *ast.FunctionLiteral:
+		a.scope = a.newScope(a.scope, node.Token, node.Body)
+		a.functions[node] = a.scope
+		for _, param := range params {
+			a.declare(param, paramBinding, node, a.scope)
+		}
+	case *ast.TryExpression:
+		a.scope = a.newScope(a.scope, node.Param.Token, node.Catch)
+		a.declare(node.Param, catchBinding, node, a.scope)
+	}
+}
+
+func (a *analysis) Declare(decl ast.Statement, name *ast.Identifier) {
+	switch decl := decl.(type) {
+	case *ast.LetStatement:
+		a.declare(name, letBinding, decl, a.scope)
+	case *ast.ExportStatement:
+		a.declare(name, letBinding, decl.Statement, a.scope)
+	case *ast.ImportStatement:
+		if name != nil {
+			a.declare(name, importBinding, decl, a.scope)
+		}
+	}
+}
+
+func (a *analysis) Bind(decl ast.Statement, name *ast.Identifier) {}
+
+func (a *analysis) Read(ident *ast.Identifier, call bool) {
+	a.uses = append(a.uses, use{ident: ident, scope: a.scope})
+}
+
+func (a *analysis) Leave(node ast.Node) {
+	a.scope = a.scope.outer
+}
+
+func (a *analysis) declare(name *ast.Identifier, kind bindingKind, decl ast.Node, s *scope) {
+	b := &binding{name: name, kind: kind, decl: decl, scope: s}
+	s.bindings = append(s.bindings, b)
+	a.uses = append(a.uses, use{ident: name, scope: s})
+}
+
+// resolve returns the binding ident refers to when read in scope s. In
+// a scope binding the name more than once, the binding is the last one
+// before ident. From a nested function, which may run later, a name
+// bound after ident refers to its first binding. Otherwise a name not
+// bound yet is looked up in the enclosing scope, as the evaluator does.
+func resolve(ident *ast.Identifier, s *scope) *binding {
+	at := identPos(ident)
+	for scope := s; scope != nil; scope = scope.outer {
+		var first, last *binding
+		for _, b := range scope.bindings {
+			if b.name.Value != ident.Value {
+				continue
+			}
+			if first == nil {
+				first = b
+			}
+			if !at.before(identPos(b.name)) {
+				last = b
+			}
+		}
+		if last != nil {
+			return last
+		}
+		if first != nil && scope != s {
+			return first
+		}
+	}
+	return nil
+}
+
+func identPos(ident *ast.Identifier) pos {
+	return pos{ident.Token.Line, ident.Token.Column}
+}
+
+// identRange returns the range of ident.
+func (a *analysis) identRange(ident *ast.Identifier) Range {
+	start := identPos(ident)
+	return a.rangeOf(start, pos{start.line, start.column + len(ident.Value)})
+}
+
+// reference returns the reference to a variable at p, including the
+// position just past its identifier.
+func (a *analysis) reference(p pos) (reference, bool) {
+	for _, ref := range a.references {
+		start := identPos(ref.ident)
+		end := pos{start.line, start.column + len(ref.ident.Value)}
+		if !p.before(start) && !end.before(p) {
+			return ref, true
+		}
+	}
+	return reference{}, false
+}
+
+// innermost returns the innermost scope containing p.
+func (a *analysis) innermost(p pos) *scope {
+	s := a.global
+	for _, scope := range a.scopes {
+		if scope.contains(p) {
+			s = scope
+		}
+	}
+	return s
+}
+
+// visible returns the variables in scope at p, innermost first, each
+// name once. In the innermost scope, only the variables bound before p
+// are visible; in the enclosing ones, evaluated before p runs, all are.
+func (a *analysis) visible(p pos) []*binding {
+	seen := make(map[string]bool)
+	var vars []*binding
+	inner := a.innermost(p)
+	for s := inner; s != nil; s = s.outer {
+		for _, b := range s.bindings {
+			if seen[b.name.Value] || s == inner && !identPos(b.name).before(p) {
+				continue
+			}
+			seen[b.name.Value] = true
+			vars = append(vars, b)
+		}
+	}
+	return vars
+}
+
+// end returns the position just past the last token of node.
+func (a *analysis) end(node ast.Node) pos {
+	var last pos
+	ast.Inspect(node, func(node ast.Node) bool {
+		if tok, ok := tokenOf(node); ok {
+			if end := a.tokenEnd(tok); last.before(end) {
+				last = end
+			}
+		}
+		return true
+	})
+	return last
+}
+
+func (a *analysis) tokenEnd(tok token.Token) pos {
+	start := pos{tok.Line, tok.Column}
+	if closing, ok := a.closing[start]; ok {
+		return pos{closing.line, closing.column + 1}
+	}
+	if tok.Type == token.STRING {
+		return a.stringEnd(start)
+	}
+	return pos{tok.Line, tok.Column + len(tok.Literal)}
+}
+
+// stringEnd returns the position past the closing quote of the string
+// literal starting at start.
+func (a *analysis) stringEnd(start pos) pos {
+	i := start.column
+	for line := start.line; line <= len(a.lines); line++ {
+		text := a.lines[line-1]
+		for i < len(text) {
+			switch text[i] {
+			case '\\':
+				i += 2
+				continue
+			case '"':
+				return pos{line, i + 2}
+			}
+			i++
+		}
+		i = 0
+	}
+	return a.global.end
+}
+
+func tokenOf(node ast.Node) (token.Token, bool) {
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		return node.Token, true
+	case *ast.ReturnStatement:
+		return node.Token, true
+	case *ast.ThrowStatement:
+		return node.Token, true
+	case *ast.ImportStatement:
+		return node.Token, true
+	case *ast.ExportStatement:
+		return node.Token, true
+	case *ast.ExpressionStatement:
+		return node.Token, true
+	case *ast.BlockStatement:
+		return node.Token, true
+	case *ast.Identifier:
+		return node.Token, true
+	case *ast.Boolean:
+		return node.Token, true
+	case *ast.IntegerLiteral:
+		return node.Token, true
+	case *ast.BigIntegerLiteral:
+		return node.Token, true
+	case *ast.FloatLiteral:
+		return node.Token, true
+	case *ast.DecimalLiteral:
+		return node.Token, true
+	case *ast.DurationLiteral:
+		return node.Token, true
+	case *ast.StringLiteral:
+		return node.Token, true
+	case *ast.PrefixExpression:
+		return node.Token, true
+	case *ast.InfixExpression:
+		return node.Token, true
+	case *ast.MemberExpression:
+		return node.Token, true
+	case *ast.IndexExpression:
+		return node.Token, true
+	case *ast.ArrayLiteral:
+		return node.Token, true
+	case *ast.HashLiteral:
+		return node.Token, true
+	case *ast.IfExpression:
+		return node.Token, true
+	case *ast.TryExpression:
+		return node.Token, true
+	case *ast.FunctionLiteral:
+		return node.Token, true
+	case *ast.CallExpression:
+		return node.Token, true
+	}
+	return token.Token{}, false
+}
+
+// signature returns the source declaring b, a function bound by a let
+// statement being shown without its body.
+func signature(b *binding) string {
+	switch decl := b.decl.(type) {
+	case *ast.LetStatement:
+		if fl, ok := decl.Value.(*ast.FunctionLiteral); ok {
+			return "let " + b.name.Value + " = " + parameters(fl)
+		}
+		return format.Node(decl)
+	case *ast.ImportStatement:
+		return format.Node(decl)
+	case *ast.FunctionLiteral:
+		return "(parameter) " + b.name.Value + " of " + parameters(decl)
+	case *ast.TryExpression:
+		return "catch (" + b.name.Value + ")"
+	}
+	return b.name.Value
+}
+
+func parameters(fl *ast.FunctionLiteral) string {
+	params := make([]string, len(fl.Parameters))
+	for i, param := range fl.Parameters {
+		params[i] = param.Value
+	}
+	return "fn(" + strings.Join(params, ", ") + ")"
+}
@@ -0,0 +1,428 @@
+// Package lsp implements a language server for scripts, speaking the
+// Language Server Protocol over a stream such as the standard input and
+// output of an editor's child process.
+//
+// The server reports the errors of ParseProgram as diagnostics and
+// answers hover, definition, document symbol and completion requests
+// from the variables of the last text of each document that parsed, so
+// that they keep working while an edit is in progress.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/stdlib"
+)
+
+// Serve answers the messages of a client read from in, writing its
+// responses and notifications to out. It returns nil when the client
+// exits after shutting the server down or when in ends.
+func Serve(in io.Reader, out io.Writer) error {
+	rt := &object.Runtime{}
+	for _, lib := range stdlib.Default() {
+		stdlib.Install(rt, lib)
+	}
+
+	s := &server{
+		conn:      newConn(in, out),
+		documents: make(map[string]*document),
+		builtins:  rt.Builtins,
+	}
+	return s.serve()
+}
+
+var errNoShutdown = errors.New("exit without shutdown")
+
+type server struct {
+	conn      *conn
+	documents map[string]*document
+	builtins  map[string]object.Object
+	shutdown  bool
+}
+
+// document is an open document.
+type document struct {
+	// source is the current text.
+	source
+	// analysis is that of the last text that parsed, nil until one does.
+	analysis *analysis
+}
+
+func (s *server) serve() error {
+	for {
+		body, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			err := s.conn.replyError(nil, &ResponseError{Code: codeParseError, Message: err.Error()})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errNoShutdown
+			}
+			return nil
+		}
+
+		result, err := s.handle(&msg)
+		var rerr *ResponseError
+		switch {
+		case errors.As(err, &rerr):
+			if msg.ID != nil {
+				err = s.conn.replyError(msg.ID, rerr)
+			} else {
+				err = nil
+			}
+		case err == nil && msg.ID != nil:
+			err = s.conn.reply(msg.ID, result)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle returns the result of a request, or nil for a notification. It
+// returns a *ResponseError when the request fails and any other error
+// when the connection does.
+func (s *server) handle(msg *message) (interface{}, error) {
+	if msg.JSONRPC != "2.0" || msg.Method == "" {
+		return nil, &ResponseError{Code: codeInvalidRequest, Message: "invalid request"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:       TextDocumentSyncFull,
+				HoverProvider:          true,
+				DefinitionProvider:     true,
+				DocumentSymbolProvider: true,
+				CompletionProvider:     &CompletionOptions{TriggerCharacters: []string{"."}},
+			},
+			ServerInfo: &ServerInfo{Name: "evallsp"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decode(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decode(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			return nil, s.update(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decode(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, s.publish(params.TextDocument.URI, []Diagnostic{})
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := decode(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params), nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := decode(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params), nil
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := decode(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.symbols(params), nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := decode(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params), nil
+	}
+
+	if msg.ID == nil {
+		// notifications the server does not support are ignored
+		return nil, nil
+	}
+	return nil, &ResponseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &ResponseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// update sets the text of the document at uri and publishes its
+// diagnostics.
+func (s *server) update(uri, text string) error {
+	doc, ok := s.documents[uri]
+	if !ok {
+		doc = &document{}
+		s.documents[uri] = doc
+	}
+	doc.source = newSource(text)
+
+	diagnostics := []Diagnostic{}
+	a, err := analyze(text)
+	if err != nil {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    doc.lineRange(errorLine(err)),
+			Severity: SeverityError,
+			Source:   "eval",
+			Message:  err.Error(),
+		})
+	} else {
+		doc.analysis = a
+	}
+
+	return s.publish(uri, diagnostics)
+}
+
+func (s *server) publish(uri string, diagnostics []Diagnostic) error {
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+// errorLine returns the line of an error of the lexer or the parser.
+func errorLine(err error) int {
+	switch err := err.(type) {
+	case *lexer.ErrUnexpectedChar:
+		return err.Line
+	case *lexer.ErrUnterminatedString:
+		return err.Line
+	case *parser.ErrUnexpectedToken:
+		return err.Line
+	case *parser.ErrNoPrefixParseFn:
+		return err.Line
+	case *parser.ErrInteger:
+		return err.Line
+	case *parser.ErrFloat:
+		return err.Line
+	case *parser.ErrDuration:
+		return err.Line
+	case *parser.ErrDecimal:
+		return err.Line
+	}
+	return 1
+}
+
+// analysis returns the analysis of the document at uri, nil if it is
+// not open or never parsed.
+func (s *server) analysis(uri string) *analysis {
+	if doc, ok := s.documents[uri]; ok {
+		return doc.analysis
+	}
+	return nil
+}
+
+func (s *server) hover(params TextDocumentPositionParams) *Hover {
+	a := s.analysis(params.TextDocument.URI)
+	if a == nil {
+		return nil
+	}
+	ref, ok := a.reference(a.pos(params.Position))
+	if !ok {
+		return nil
+	}
+
+	var text string
+	if ref.binding != nil {
+		text = signature(ref.binding)
+	} else if obj, ok := s.builtins[ref.ident.Value]; ok {
+		text = "(builtin) " + ref.ident.Value
+		if _, ok := obj.(*object.Module); ok {
+			text = "(builtin module) " + ref.ident.Value
+		}
+	} else {
+		return nil
+	}
+
+	r := a.identRange(ref.ident)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```eval\n" + text + "\n```"},
+		Range:    &r,
+	}
+}
+
+func (s *server) definition(params TextDocumentPositionParams) *Location {
+	a := s.analysis(params.TextDocument.URI)
+	if a == nil {
+		return nil
+	}
+	ref, ok := a.reference(a.pos(params.Position))
+	if !ok || ref.binding == nil {
+		return nil
+	}
+	return &Location{URI: params.TextDocument.URI, Range: a.identRange(ref.binding.name)}
+}
+
+func (s *server) symbols(params DocumentSymbolParams) []DocumentSymbol {
+	a := s.analysis(params.TextDocument.URI)
+	if a == nil {
+		return []DocumentSymbol{}
+	}
+	return a.symbols(a.global)
+}
+
+// symbols returns the let statements and import aliases of scope s,
+// those binding functions having the symbols of the function as
+// children.
+func (a *analysis) symbols(s *scope) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, b := range s.bindings {
+		sym := DocumentSymbol{Name: b.name.Value, SelectionRange: a.identRange(b.name)}
+
+		switch decl := b.decl.(type) {
+		case *ast.LetStatement:
+			sym.Kind = SymbolVariable
+			sym.Range = a.rangeOf(pos{decl.Token.Line, decl.Token.Column}, a.end(decl))
+			if fl, ok := decl.Value.(*ast.FunctionLiteral); ok {
+				sym.Kind = SymbolFunction
+				sym.Detail = parameters(fl)
+				sym.Children = a.symbols(a.functions[fl])
+			}
+		case *ast.ImportStatement:
+			sym.Kind = SymbolModule
+			sym.Detail = strconv.Quote(decl.Path.Value)
+			sym.Range = a.rangeOf(pos{decl.Token.Line, decl.Token.Column}, a.end(decl))
+		default:
+			continue
+		}
+
+		symbols = append(symbols, sym)
+	}
+	return symbols
+}
+
+var keywords = []string{
+	"as", "catch", "else", "export", "false", "finally", "fn",
+	"if", "import", "let", "return", "throw", "true", "try",
+}
+
+// completion returns the variables in scope at the position, the
+// builtins and the keywords, or the members of a builtin module after
+// its name and a dot. Clients filter the items by the word typed.
+func (s *server) completion(params TextDocumentPositionParams) []CompletionItem {
+	items := []CompletionItem{}
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return items
+	}
+
+	p := doc.pos(params.Position)
+	if name, ok := doc.member(p); ok {
+		if m, ok := s.builtins[name].(*object.Module); ok {
+			for _, member := range m.Exports {
+				obj, _ := m.Env.Get(member)
+				items = append(items, CompletionItem{Label: member, Kind: builtinKind(obj)})
+			}
+		}
+		return items
+	}
+
+	seen := make(map[string]bool)
+	if doc.analysis != nil {
+		for _, b := range doc.analysis.visible(p) {
+			seen[b.name.Value] = true
+			items = append(items, CompletionItem{Label: b.name.Value, Kind: bindingKindOf(b), Detail: signature(b)})
+		}
+	}
+
+	names := make([]string, 0, len(s.builtins))
+	for name := range s.builtins {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		items = append(items, CompletionItem{Label: name, Kind: builtinKind(s.builtins[name]), Detail: "builtin"})
+	}
+
+	for _, kw := range keywords {
+		items = append(items, CompletionItem{Label: kw, Kind: CompletionKeyword})
+	}
+	return items
+}
+
+// member returns the name before the dot preceding the word at p, when
+// the word is the member of a module being typed.
+func (s source) member(p pos) (string, bool) {
+	if p.line > len(s.lines) {
+		return "", false
+	}
+	text := s.lines[p.line-1]
+	if p.column-1 < len(text) {
+		text = text[:p.column-1]
+	}
+
+	text = strings.TrimRightFunc(text, isWordRune)
+	if !strings.HasSuffix(text, ".") {
+		return "", false
+	}
+	text = text[:len(text)-1]
+	word := strings.TrimRightFunc(text, isWordRune)
+	return text[len(word):], true
+}
+
+func isWordRune(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_'
+}
+
+func bindingKindOf(b *binding) CompletionItemKind {
+	switch decl := b.decl.(type) {
+	case *ast.LetStatement:
+		if _, ok := decl.Value.(*ast.FunctionLiteral); ok {
+			return CompletionFunction
+		}
+	case *ast.ImportStatement:
+		return CompletionModule
+	}
+	return CompletionVariable
+}
+
+func builtinKind(obj object.Object) CompletionItemKind {
+	switch obj.(type) {
+	case *object.Module:
+		return CompletionModule
+	case *object.Builtin, *object.Function:
+		return CompletionFunction
+	}
+	return CompletionVariable
+}
@@ -0,0 +1,473 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// client talks to a server running in the same process.
+type client struct {
+	t    *testing.T
+	conn *conn
+	in   chan message
+	done chan error
+	id   int
+	// notifications are those received while waiting for a response.
+	notifications []message
+}
+
+func newClient(t *testing.T) *client {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{
+		t:    t,
+		conn: newConn(clientIn, clientOut),
+		in:   make(chan message),
+		done: make(chan error, 1),
+	}
+	go func() {
+		err := Serve(serverIn, serverOut)
+		serverOut.Close()
+		c.done <- err
+	}()
+	go func() {
+		defer close(c.in)
+		for {
+			body, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Errorf("malformed message %s: %v", body, err)
+				return
+			}
+			c.in <- msg
+		}
+	}()
+	t.Cleanup(func() { clientOut.Close() })
+
+	c.call("initialize", map[string]interface{}{}, nil)
+	c.notify("initialized", map[string]interface{}{})
+	return c
+}
+
+func (c *client) send(v interface{}) {
+	c.t.Helper()
+	if err := c.conn.write(v); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// call sends a request and decodes its result into result, returning
+// the error of the response.
+func (c *client) call(method string, params, result interface{}) *ResponseError {
+	c.t.Helper()
+	c.id++
+	id := json.RawMessage(strconv.Itoa(c.id))
+	c.send(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Method  string          `json:"method"`
+		Params  interface{}     `json:"params"`
+	}{"2.0", id, method, params})
+
+	for msg := range c.in {
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if string(msg.ID) != string(id) {
+			c.t.Fatalf("response id wrong. want=%s, got=%s", id, msg.ID)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("result %s: %v", msg.Result, err)
+			}
+		}
+		return nil
+	}
+	c.t.Fatalf("no response to %s", method)
+	return nil
+}
+
+// diagnostics returns the diagnostics published next.
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+	var msg message
+	if len(c.notifications) > 0 {
+		msg, c.notifications = c.notifications[0], c.notifications[1:]
+	} else {
+		var ok bool
+		if msg, ok = <-c.in; !ok {
+			c.t.Fatal("no diagnostics")
+		}
+	}
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("notification wrong. want=textDocument/publishDiagnostics, got=%s", msg.Method)
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params
+}
+
+const uri = "file:///rules/discount.ev"
+
+// open opens a document and returns its diagnostics.
+func (c *client) open(text string) []Diagnostic {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "eval", Version: 1, Text: text},
+	})
+	return c.diagnostics().Diagnostics
+}
+
+func at(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func span(line, start, end int) Range {
+	return Range{Start: Position{line, start}, End: Position{line, end}}
+}
+
+const script = `let rate = 0.1
+let discount = fn(price, qty) {
+	let total = price * qty
+	if (total > 100) { total * rate } else { 0 }
+}
+let safe = fn(x) { try { discount(x, 1) } catch (e) { e } }
+discount(200, upper("é"))
+`
+
+func TestInitialize(t *testing.T) {
+	c := newClient(t)
+
+	var result InitializeResult
+	if err := c.call("initialize", map[string]interface{}{}, &result); err != nil {
+		t.Fatal(err)
+	}
+	caps := result.Capabilities
+	if caps.TextDocumentSync != TextDocumentSyncFull || !caps.HoverProvider || !caps.DefinitionProvider ||
+		!caps.DocumentSymbolProvider || caps.CompletionProvider == nil {
+		t.Errorf("capabilities wrong. got=%+v", caps)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+
+	diagnostics := c.open("let a = 1\nlet b = (a + \nlet c = 3")
+	if len(diagnostics) != 1 {
+		t.Fatalf("diagnostics wrong. got=%+v", diagnostics)
+	}
+	d := diagnostics[0]
+	if d.Severity != SeverityError || d.Range != span(2, 0, 9) {
+		t.Errorf("diagnostic wrong. got=%+v", d)
+	}
+	if d.Message != "Line 3 Unrecognized Token: no prefix parse function for LET" {
+		t.Errorf("message wrong. got=%q", d.Message)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let a = 1\nlet b = (a + 2)\n"}},
+	})
+	if got := c.diagnostics(); got.URI != uri || len(got.Diagnostics) != 0 {
+		t.Errorf("diagnostics wrong. got=%+v", got)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: `let s = "é` + "\n"}},
+	})
+	if got := c.diagnostics().Diagnostics; len(got) != 1 || got[0].Range != span(0, 0, 10) {
+		t.Errorf("diagnostics wrong. got=%+v", got)
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	if got := c.diagnostics().Diagnostics; len(got) != 0 {
+		t.Errorf("diagnostics wrong. got=%+v", got)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	c := newClient(t)
+	c.open(script)
+
+	testCases := []struct {
+		at   TextDocumentPositionParams
+		want *Range
+	}{
+		// a let name where it is declared and read from a function
+		{at(0, 5), &Range{Start: Position{0, 4}, End: Position{0, 8}}},
+		{at(3, 30), &Range{Start: Position{0, 4}, End: Position{0, 8}}},
+		// parameters
+		{at(2, 14), &Range{Start: Position{1, 18}, End: Position{1, 23}}},
+		{at(2, 22), &Range{Start: Position{1, 25}, End: Position{1, 28}}},
+		// a let hoisted in the function scope
+		{at(3, 5), &Range{Start: Position{2, 5}, End: Position{2, 10}}},
+		// the end of an identifier
+		{at(3, 10), &Range{Start: Position{2, 5}, End: Position{2, 10}}},
+		// a catch parameter and a global read from a nested function
+		{at(5, 54), &Range{Start: Position{5, 49}, End: Position{5, 50}}},
+		{at(5, 28), &Range{Start: Position{1, 4}, End: Position{1, 12}}},
+		// builtins, numbers and blanks have none
+		{at(6, 14), nil},
+		{at(6, 10), nil},
+		{at(4, 0), nil},
+	}
+
+	for _, tt := range testCases {
+		var got *Location
+		if err := c.call("textDocument/definition", tt.at, &got); err != nil {
+			t.Fatal(err)
+		}
+		if tt.want == nil {
+			if got != nil {
+				t.Errorf("definition at %+v wrong. want=nil, got=%+v", tt.at.Position, got)
+			}
+			continue
+		}
+		if got == nil || got.URI != uri || got.Range != *tt.want {
+			t.Errorf("definition at %+v wrong. want=%+v, got=%+v", tt.at.Position, tt.want, got)
+		}
+	}
+}
+
+func TestDefinitionRebound(t *testing.T) {
+	c := newClient(t)
+	c.open("let x = 1\nlet f = fn() { x }\nlet y = x\nlet x = 2\nx")
+
+	testCases := []struct {
+		at   TextDocumentPositionParams
+		line int
+	}{
+		// the binding in force where x is read
+		{at(2, 8), 0},
+		{at(4, 0), 3},
+		// a function may run after any binding, the first is shown
+		{at(1, 15), 0},
+	}
+
+	for _, tt := range testCases {
+		var got *Location
+		if err := c.call("textDocument/definition", tt.at, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || got.Range.Start.Line != tt.line {
+			t.Errorf("definition at %+v wrong. want line %d, got=%+v", tt.at.Position, tt.line, got)
+		}
+	}
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	c.open(script)
+
+	testCases := []struct {
+		at   TextDocumentPositionParams
+		want string
+	}{
+		{at(3, 30), "let rate = 0.1;"},
+		{at(6, 2), "let discount = fn(price, qty)"},
+		{at(2, 14), "(parameter) price of fn(price, qty)"},
+		{at(5, 54), "catch (e)"},
+		{at(6, 15), "(builtin) upper"},
+		{at(6, 10), ""},
+	}
+
+	for _, tt := range testCases {
+		var got *Hover
+		if err := c.call("textDocument/hover", tt.at, &got); err != nil {
+			t.Fatal(err)
+		}
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("hover at %+v wrong. want=nil, got=%+v", tt.at.Position, got)
+			}
+			continue
+		}
+		want := "```eval\n" + tt.want + "\n```"
+		if got == nil || got.Contents.Value != want || got.Contents.Kind != "markdown" {
+			t.Errorf("hover at %+v wrong. want=%q, got=%+v", tt.at.Position, want, got)
+		}
+	}
+
+	var got *Hover
+	c.call("textDocument/hover", at(6, 15), &got)
+	if got == nil || got.Range == nil || *got.Range != span(6, 14, 19) {
+		t.Errorf("hover range wrong. got=%+v", got)
+	}
+}
+
+func TestHoverAfterEdit(t *testing.T) {
+	c := newClient(t)
+	c.open("let a = 1\na")
+
+	// the text does not parse, the last analysis is used
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let a = 1\na +"}},
+	})
+	c.diagnostics()
+
+	var got *Hover
+	if err := c.call("textDocument/hover", at(1, 0), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Contents.Value != "```eval\nlet a = 1;\n```" {
+		t.Errorf("hover wrong. got=%+v", got)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	c := newClient(t)
+	c.open(script + `import "lib/tax" as tax` + "\n")
+
+	var got []DocumentSymbol
+	if err := c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []DocumentSymbol{
+		{Name: "rate", Kind: SymbolVariable, Range: span(0, 0, 14), SelectionRange: span(0, 4, 8)},
+		{
+			Name: "discount", Detail: "fn(price, qty)", Kind: SymbolFunction,
+			Range:          Range{Start: Position{1, 0}, End: Position{4, 1}},
+			SelectionRange: span(1, 4, 12),
+			Children: []DocumentSymbol{
+				{Name: "total", Kind: SymbolVariable, Range: span(2, 1, 24), SelectionRange: span(2, 5, 10)},
+			},
+		},
+		{Name: "safe", Detail: "fn(x)", Kind: SymbolFunction, Range: span(5, 0, 59), SelectionRange: span(5, 4, 8)},
+		{Name: "tax", Detail: `"lib/tax"`, Kind: SymbolModule, Range: span(7, 0, 23), SelectionRange: span(7, 20, 23)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("symbols wrong.\nwant=%+v\ngot= %+v", want, got)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	c.open(script)
+
+	labels := func(params TextDocumentPositionParams) map[string]CompletionItem {
+		var items []CompletionItem
+		if err := c.call("textDocument/completion", params, &items); err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[string]CompletionItem)
+		for _, item := range items {
+			found[item.Label] = item
+		}
+		return found
+	}
+
+	// within the body of discount, before total is bound
+	got := labels(at(2, 1))
+	for _, name := range []string{"price", "qty", "rate", "discount", "safe", "upper", "math", "let"} {
+		if _, ok := got[name]; !ok {
+			t.Errorf("completion missing %s", name)
+		}
+	}
+	if _, ok := got["total"]; ok {
+		t.Errorf("completion has total before its let statement")
+	}
+	if _, ok := got["e"]; ok {
+		t.Errorf("completion has a catch parameter out of its clause")
+	}
+	if item := got["discount"]; item.Kind != CompletionFunction || item.Detail != "let discount = fn(price, qty)" {
+		t.Errorf("item wrong. got=%+v", item)
+	}
+	if item := got["upper"]; item.Kind != CompletionFunction || item.Detail != "builtin" {
+		t.Errorf("item wrong. got=%+v", item)
+	}
+
+	if _, ok := labels(at(3, 1))["total"]; !ok {
+		t.Errorf("completion missing total")
+	}
+	if _, ok := labels(at(5, 53))["e"]; !ok {
+		t.Errorf("completion missing e")
+	}
+	if _, ok := labels(at(6, 0))["price"]; ok {
+		t.Errorf("completion has a parameter out of its function")
+	}
+}
+
+func TestCompletionMember(t *testing.T) {
+	c := newClient(t)
+	c.open("let a = math.ab")
+
+	var items []CompletionItem
+	if err := c.call("textDocument/completion", at(0, 15), &items); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, item := range items {
+		if item.Label == "abs" {
+			found = true
+		}
+		if item.Label == "let" || item.Label == "a" {
+			t.Errorf("completion of members has %s", item.Label)
+		}
+	}
+	if !found {
+		t.Errorf("completion missing abs. got=%+v", items)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := newClient(t)
+
+	err := c.call("textDocument/rename", at(0, 0), nil)
+	if err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("error wrong. got=%v", err)
+	}
+
+	err = c.call("textDocument/hover", "here", nil)
+	if err == nil || err.Code != codeInvalidParams {
+		t.Errorf("error wrong. got=%v", err)
+	}
+
+	// documents not open have nothing
+	var hover *Hover
+	if err := c.call("textDocument/hover", at(0, 0), &hover); err != nil || hover != nil {
+		t.Errorf("hover wrong. got=%v, %v", hover, err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	c := newClient(t)
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+
+	c = newClient(t)
+	c.notify("exit", nil)
+	if err := <-c.done; !errors.Is(err, errNoShutdown) {
+		t.Errorf("Serve returned %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package lsp
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// pos is the position of a byte in a source, both its line and its
+// column counted from 1, as in tokens.
+type pos struct {
+	line, column int
+}
+
+func (p pos) before(q pos) bool {
+	return p.line < q.line || p.line == q.line && p.column < q.column
+}
+
+// source is the text of a document, split in lines to convert the
+// positions of tokens, in bytes, to those of the protocol, in UTF-16
+// code units.
+type source struct {
+	text  string
+	lines []string
+}
+
+func newSource(text string) source {
+	return source{text: text, lines: strings.Split(text, "\n")}
+}
+
+// position returns the protocol position of p, moved within the text.
+func (s source) position(p pos) Position {
+	if p.line < 1 {
+		return Position{}
+	}
+	if p.line > len(s.lines) {
+		last := len(s.lines) - 1
+		return Position{Line: last, Character: utf16Len(s.lines[last])}
+	}
+
+	text := s.lines[p.line-1]
+	column := p.column
+	if column < 1 {
+		column = 1
+	}
+	if column > len(text)+1 {
+		column = len(text) + 1
+	}
+	return Position{Line: p.line - 1, Character: utf16Len(text[:column-1])}
+}
+
+func (s source) rangeOf(start, end pos) Range {
+	return Range{Start: s.position(start), End: s.position(end)}
+}
+
+// pos returns the position of the byte at the protocol position p,
+// which a position past the end of its line designates.
+func (s source) pos(p Position) pos {
+	if p.Line >= len(s.lines) {
+		return pos{line: p.Line + 1, column: 1}
+	}
+
+	text := s.lines[p.Line]
+	units := 0
+	for i, r := range text {
+		if units >= p.Character {
+			return pos{line: p.Line + 1, column: i + 1}
+		}
+		units += utf16RuneLen(r)
+	}
+	return pos{line: p.Line + 1, column: len(text) + 1}
+}
+
+// lineRange returns the range of the whole line, counted from 1.
+func (s source) lineRange(line int) Range {
+	if line < 1 {
+		line = 1
+	}
+	if line > len(s.lines) {
+		line = len(s.lines)
+	}
+	return s.rangeOf(pos{line, 1}, pos{line, len(s.lines[line-1]) + 1})
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		// encoded as a surrogate pair
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,48 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoLoader is the error of imports evaluated without a loader.
+var ErrNoLoader = errors.New("no loader configured")
+
+type ErrInvalidPath struct {
+	Path string
+}
+
+func (e *ErrInvalidPath) Error() string {
+	return fmt.Sprintf("invalid module path %q", e.Path)
+}
+
+type ErrCycle struct {
+	Chain []string
+}
+
+func (e *ErrCycle) Error() string {
+	return "import cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+type ErrModule struct {
+	Path string
+	Err  error
+}
+
+func (e *ErrModule) Error() string {
+	return fmt.Sprintf("module %q: %s", e.Path, e.Err.Error())
+}
+
+func (e *ErrModule) Unwrap() error {
+	return e.Err
+}
+
+type ErrRuntime struct {
+	Line    int
+	Message string
+}
+
+func (e *ErrRuntime) Error() string {
+	return fmt.Sprintf("Line %d %s", e.Line, e.Message)
+}
@@ -0,0 +1,36 @@
+package module
+
+import (
+	"io/fs"
+)
+
+// Extension is appended to import paths by the loader returned by FS,
+// so `import "pricing/tiers"` reads the file pricing/tiers.eval.
+const Extension = ".eval"
+
+// Loader returns the source code of the module with the given path.
+type Loader interface {
+	Load(path string) (string, error)
+}
+
+type fsLoader struct {
+	fsys fs.FS
+}
+
+// FS returns a Loader reading modules from fsys.
+func FS(fsys fs.FS) Loader {
+	return &fsLoader{fsys: fsys}
+}
+
+func (l *fsLoader) Load(path string) (string, error) {
+	if !fs.ValidPath(path) {
+		return "", &ErrInvalidPath{Path: path}
+	}
+
+	src, err := fs.ReadFile(l.fsys, path+Extension)
+	if err != nil {
+		return "", err
+	}
+
+	return string(src), nil
+}
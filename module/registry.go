@@ -0,0 +1,98 @@
+package module
+
+import (
+	"path"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/resolver"
+)
+
+// Registry evaluates every imported module once and caches it. It
+// implements object.Importer.
+type Registry struct {
+	loader  Loader
+	runtime *object.Runtime
+	modules map[string]*object.Module
+	loading []string
+}
+
+// NewRegistry returns a registry loading modules through loader. The
+// modules are evaluated with rt as their runtime. With a nil loader,
+// every import fails with ErrNoLoader.
+func NewRegistry(loader Loader, rt *object.Runtime) *Registry {
+	return &Registry{
+		loader:  loader,
+		runtime: rt,
+		modules: make(map[string]*object.Module),
+	}
+}
+
+func (r *Registry) Import(p string) (*object.Module, error) {
+	p = path.Clean(p)
+	if module, ok := r.modules[p]; ok {
+		return module, nil
+	}
+
+	for i, loading := range r.loading {
+		if loading == p {
+			chain := append([]string{}, r.loading[i:]...)
+			return nil, &ErrCycle{Chain: append(chain, p)}
+		}
+	}
+
+	r.loading = append(r.loading, p)
+	defer func() {
+		r.loading = r.loading[:len(r.loading)-1]
+	}()
+
+	module, err := r.evalModule(p)
+	if err != nil {
+		return nil, &ErrModule{Path: p, Err: err}
+	}
+
+	r.modules[p] = module
+	return module, nil
+}
+
+func (r *Registry) evalModule(p string) (*object.Module, error) {
+	if r.loader == nil {
+		return nil, ErrNoLoader
+	}
+	src, err := r.loader.Load(p)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := parser.New(lexer.New(src)).ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+
+	env := object.NewEnvironment()
+	env.SetRuntime(r.runtime)
+
+	err = resolver.Resolve(program, func(name string) bool {
+		return evaluator.Defined(env, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	evaluated := evaluator.Eval(program, env)
+	if errObj, ok := evaluated.(*object.Error); ok {
+		return nil, &ErrRuntime{Line: errObj.Line, Message: errObj.Message}
+	}
+
+	module := &object.Module{Path: p, Env: env}
+	for _, stmt := range program.Statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			module.Exports = append(module.Exports, export.Statement.Name.Value)
+		}
+	}
+
+	return module, nil
+}
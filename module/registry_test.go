@@ -0,0 +1,172 @@
+package module
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+)
+
+type countingLoader struct {
+	Loader
+	loads map[string]int
+}
+
+func (l *countingLoader) Load(path string) (string, error) {
+	l.loads[path]++
+	return l.Loader.Load(path)
+}
+
+func testImport(t *testing.T, fsys fstest.MapFS, input string) (object.Object, *countingLoader) {
+	loader := &countingLoader{Loader: FS(fsys), loads: map[string]int{}}
+	rt := &object.Runtime{}
+	rt.Importer = NewRegistry(loader, rt)
+
+	env := object.NewEnvironment()
+	env.SetRuntime(rt)
+
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse %q: %s", input, err)
+	}
+
+	return evaluator.Eval(program, env), loader
+}
+
+func TestImport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pricing/tiers.eval": {Data: []byte(`
+import "pricing/base";
+let hidden = 3;
+export let tier = fn(x) { base(x) * hidden };
+`)},
+		"pricing/base.eval": {Data: []byte(`export let base = fn(x) { x + 1 };`)},
+	}
+
+	evaluated, loader := testImport(t, fsys, `
+import "pricing/tiers";
+import "pricing/base";
+import "./pricing/tiers";
+tier(1) + base(1)`)
+
+	integer, ok := evaluated.(*object.Integer)
+	if !ok || integer.Value != 8 {
+		t.Fatalf("expected 8, got %#v", evaluated)
+	}
+	if loader.loads["pricing/tiers"] != 1 || loader.loads["pricing/base"] != 1 {
+		t.Errorf("modules must be loaded once. got=%v", loader.loads)
+	}
+}
+
+func TestImportHidesUnexported(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib.eval": {Data: []byte(`let secret = 1; export let open = 2;`)},
+	}
+
+	evaluated, _ := testImport(t, fsys, `import "lib"; secret`)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: secret" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.eval":      {Data: []byte(`import "b"; export let a = 1;`)},
+		"b.eval":      {Data: []byte(`import "c";`)},
+		"c.eval":      {Data: []byte(`import "a";`)},
+		"self.eval":   {Data: []byte(`import "self";`)},
+		"broken.eval": {Data: []byte(`let = 1;`)},
+		"failing.eval": {Data: []byte(`
+let x = 1;
+x + true;`)},
+	}
+
+	testCases := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`import "self"`, `module "self": Line 1 import cycle: self -> self`},
+		{`import "a"`, `module "a": Line 1 module "b": Line 1 module "c": Line 1 import cycle: a -> b -> c -> a`},
+		{`import "missing"`, `module "missing": open missing.eval: file does not exist`},
+		{`import "../escape"`, `module "../escape": invalid module path "../escape"`},
+		{`import "broken"`, `module "broken": Line 1 Unexpected Token: expected IDENT, got =`},
+		{`import "failing"`, `module "failing": Line 3 type mismatch: INTEGER + BOOLEAN`},
+	}
+
+	for _, tc := range testCases {
+		evaluated, _ := testImport(t, fsys, tc.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tc.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tc.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestImportCycleError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.eval": {Data: []byte(`import "b";`)},
+		"b.eval": {Data: []byte(`import "a";`)},
+	}
+	rt := &object.Runtime{}
+	registry := NewRegistry(FS(fsys), rt)
+	rt.Importer = registry
+
+	_, err := registry.Import("a")
+
+	var modErr *ErrModule
+	if !errors.As(err, &modErr) || modErr.Path != "a" {
+		t.Fatalf("expected *ErrModule for a, got %#v", err)
+	}
+	if _, err := registry.Import("a"); err == nil {
+		t.Errorf("failed modules must not be cached")
+	}
+}
+
+func TestImportWithoutLoader(t *testing.T) {
+	rt := &object.Runtime{}
+	registry := NewRegistry(nil, rt)
+	rt.Importer = registry
+
+	_, err := registry.Import("lib")
+
+	var modErr *ErrModule
+	if !errors.As(err, &modErr) || !errors.Is(err, ErrNoLoader) {
+		t.Fatalf("expected *ErrModule wrapping ErrNoLoader, got %#v", err)
+	}
+	if err.Error() != `module "lib": no loader configured` {
+		t.Errorf("wrong error message. got=%q", err.Error())
+	}
+}
+
+func TestImportAsNamespace(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pricing/tiers.eval": {Data: []byte(`let rate = 3; export let tier = fn(x) { x * rate };`)},
+	}
+
+	evaluated, _ := testImport(t, fsys, `import "pricing/tiers" as tiers; tiers.tier(2)`)
+	integer, ok := evaluated.(*object.Integer)
+	if !ok || integer.Value != 6 {
+		t.Fatalf("expected 6, got %#v", evaluated)
+	}
+
+	evaluated, _ = testImport(t, fsys, `import "pricing/tiers" as tiers; tiers.rate`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "unknown member of MODULE: rate" {
+		t.Fatalf("expected unexported member error, got %#v", evaluated)
+	}
+}
@@ -0,0 +1,48 @@
+package object
+
+import (
+	"hash/fnv"
+	"math/big"
+)
+
+const BIG_INTEGER_OBJ = "BIG_INTEGER"
+
+// BigInteger holds the integers outside the range of int64. Arithmetic
+// promotes integers to it on overflow and NewInteger demotes results
+// back to Integer when they fit.
+type BigInteger struct {
+	Value *big.Int
+}
+
+func (b *BigInteger) Type() ObjectType { return BIG_INTEGER_OBJ }
+func (b *BigInteger) Inspect() string  { return b.Value.String() }
+
+func (b *BigInteger) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write(b.Value.Bytes())
+	if b.Value.Sign() < 0 {
+		h.Write([]byte{'-'})
+	}
+
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+// NewInteger returns an Integer when v fits in an int64 and a
+// BigInteger otherwise.
+func NewInteger(v *big.Int) Object {
+	if v.IsInt64() {
+		return NativeInt(v.Int64())
+	}
+	return &BigInteger{Value: v}
+}
+
+// ToBigInt returns the value of an Integer or BigInteger.
+func ToBigInt(obj Object) (*big.Int, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value), true
+	case *BigInteger:
+		return obj.Value, true
+	}
+	return nil, false
+}
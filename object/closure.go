@@ -0,0 +1,51 @@
+package object
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/code"
+)
+
+const COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+
+// CompiledFunction is the bytecode of a function literal, or of a block
+// run in a frame of its own such as the clauses of a try expression.
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumParameters int
+	// Slots names the variables of the scope created by each call,
+	// parameters first. Blocks sharing the scope of their caller have
+	// none.
+	Slots []string
+	// Lines maps the offsets of the instructions which may fail to
+	// their line in the source.
+	Lines   []code.Line
+	Literal *ast.FunctionLiteral
+	// Constants is the pool of the program the function belongs to,
+	// which it keeps when stored and called by another program.
+	Constants []Object
+}
+
+func (cf *CompiledFunction) Type() ObjectType { return COMPILED_FUNCTION_OBJ }
+func (cf *CompiledFunction) Inspect() string  { return "compiled function" }
+
+// Scope holds the variables of a call to a compiled function. Closures
+// keep a reference to the scope they were created in, so they observe
+// later assignments to its variables as the evaluator does.
+type Scope struct {
+	Slots []Object
+	Names []string
+	Outer *Scope
+	// Bound holds the names imported into the scope at run time.
+	Bound map[string]Object
+}
+
+// Closure is a compiled function along with the scope it was created in.
+type Closure struct {
+	Fn    *CompiledFunction
+	Scope *Scope
+}
+
+func (c *Closure) Type() ObjectType { return FUNCTION_OBJ }
+func (c *Closure) Inspect() string {
+	return inspectFunction(c.Fn.Literal.Parameters, c.Fn.Literal.Body)
+}
@@ -0,0 +1,58 @@
+package object
+
+import (
+	"math/big"
+)
+
+const DECIMAL_OBJ = "DECIMAL"
+
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // away from zero on ties
+	RoundHalfEven                     // to the even neighbour on ties
+	RoundDown                         // toward zero
+)
+
+// DecimalContext sets the number of fractional digits kept by decimal
+// arithmetic and how the digits beyond it are rounded.
+type DecimalContext struct {
+	Scale    int
+	Rounding RoundingMode
+}
+
+var DefaultDecimalContext = DecimalContext{Scale: 2, Rounding: RoundHalfUp}
+
+// Decimal is an exact decimal number. Scale is the number of fractional
+// digits it is printed with, Value being a multiple of 10^-Scale.
+type Decimal struct {
+	Value *big.Rat
+	Scale int
+}
+
+func (d *Decimal) Type() ObjectType { return DECIMAL_OBJ }
+func (d *Decimal) Inspect() string  { return d.Value.FloatString(d.Scale) }
+
+// Round returns r rounded to scale fractional digits.
+func (c DecimalContext) Round(r *big.Rat, scale int) *Decimal {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+
+	num := new(big.Int).Mul(r.Num(), unit)
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+
+	if m.Sign() != 0 && c.Rounding != RoundDown {
+		// compare twice the remainder with the denominator to find ties
+		half := new(big.Int).Abs(m)
+		half.Lsh(half, 1)
+		switch half.Cmp(r.Denom()) {
+		case 1:
+			q.Add(q, big.NewInt(int64(r.Sign())))
+		case 0:
+			if c.Rounding == RoundHalfUp || q.Bit(0) == 1 {
+				q.Add(q, big.NewInt(int64(r.Sign())))
+			}
+		}
+	}
+
+	return &Decimal{Value: new(big.Rat).SetFrac(q, unit), Scale: scale}
+}
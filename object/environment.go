@@ -1,9 +1,15 @@
 package object
 
+import (
+	"sort"
+	"time"
+)
+
+// NewEnclosedEnvironment returns an environment enclosed by outer. Its
+// store is allocated by the first Set, since the environments of calls
+// often bind nothing by name.
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
-	return env
+	return &Environment{outer: outer, runtime: outer.runtime}
 }
 
 func NewEnvironment() *Environment {
@@ -11,13 +17,65 @@ func NewEnvironment() *Environment {
 	return &Environment{store: s, outer: nil}
 }
 
+// NewSlotEnvironment returns an environment enclosed by outer holding
+// the variables named by names in slots, which resolved identifiers
+// address by index.
+func NewSlotEnvironment(outer *Environment, names []string) *Environment {
+	var env *Environment
+	if len(names) <= len(fewSlotsEnvironment{}.slots) {
+		// a single allocation for the environment and its slots
+		few := &fewSlotsEnvironment{}
+		env = &few.env
+		env.slots = few.slots[:len(names)]
+	} else {
+		env = &Environment{slots: make([]Object, len(names))}
+	}
+	env.names = names
+	env.outer = outer
+	env.runtime = outer.runtime
+	return env
+}
+
+// fewSlotsEnvironment is the environment of most calls, which have few
+// variables.
+type fewSlotsEnvironment struct {
+	env   Environment
+	slots [4]Object
+}
+
+// Runtime holds the interpreter-wide state shared by an environment
+// and every environment enclosed by it.
+type Runtime struct {
+	Importer Importer
+	// Builtins are visible in every environment unless shadowed.
+	Builtins map[string]Object
+	// Now is the clock read by scripts, time.Now when nil.
+	Now func() time.Time
+	// Decimal rounds the results of decimal arithmetic,
+	// DefaultDecimalContext when nil.
+	Decimal *DecimalContext
+	// Rational makes the division of integers exact, producing a
+	// Rational when the division leaves a remainder.
+	Rational bool
+	// Hook is called by the evaluator as it runs, when set.
+	Hook Hook
+}
+
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	slots   []Object
+	names   []string
+	outer   *Environment
+	runtime *Runtime
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
+	if !ok {
+		if i := e.slotIndex(name); i >= 0 && e.slots[i] != nil {
+			obj, ok = e.slots[i], true
+		}
+	}
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
@@ -25,6 +83,66 @@ func (e *Environment) Get(name string) (Object, bool) {
 }
 
 func (e *Environment) Set(name string, val Object) Object {
+	if i := e.slotIndex(name); i >= 0 {
+		e.slots[i] = val
+		return val
+	}
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
 	e.store[name] = val
 	return val
 }
+
+// Names returns the sorted names of the variables bound in e itself,
+// not in the environments enclosing it.
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store)+len(e.names))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	for i, name := range e.names {
+		if e.slots[i] != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Outer returns the environment enclosing e, nil for a global one.
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Slot returns the variable in slot i of the environment depth levels
+// up, nil while it is unset.
+func (e *Environment) Slot(depth, i int) Object {
+	for ; depth > 0; depth-- {
+		e = e.outer
+	}
+	return e.slots[i]
+}
+
+func (e *Environment) SetSlot(i int, val Object) {
+	e.slots[i] = val
+}
+
+func (e *Environment) slotIndex(name string) int {
+	for i, n := range e.names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Runtime returns the state shared with the interpreter, or nil for
+// an environment created outside of one.
+func (e *Environment) Runtime() *Runtime {
+	return e.runtime
+}
+
+func (e *Environment) SetRuntime(rt *Runtime) {
+	e.runtime = rt
+}
@@ -0,0 +1,18 @@
+package object
+
+import "github.com/NilCent/eval/ast"
+
+// Hook observes the evaluation of a program by the evaluator, as a
+// debugger does. The virtual machine does not call it.
+type Hook interface {
+	// Statement is called before stmt is evaluated in env. A non-nil
+	// error stops the evaluation, which returns it.
+	Statement(stmt ast.Statement, env *Environment) *Error
+	// Call is called before the body of fn is evaluated in env, which
+	// holds its arguments.
+	Call(fn *Function, env *Environment)
+	// Return is called once the body of fn has been evaluated to
+	// result. result is nil when the body ended with a call in tail
+	// position, which replaces the call of fn.
+	Return(fn *Function, result Object)
+}
@@ -0,0 +1,40 @@
+package object
+
+import "sort"
+
+// Module is a script evaluated once in its own environment. Only the
+// names listed in Exports are visible to the modules importing it,
+// either bound directly or as members of the namespace.
+type Module struct {
+	Path    string
+	Env     *Environment
+	Exports []string
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "module " + m.Path }
+func (m *Module) Member(name string) (Object, bool) {
+	for _, export := range m.Exports {
+		if export == name {
+			return m.Env.Get(name)
+		}
+	}
+	return nil, false
+}
+
+// NewNamespace returns a module exporting members, for host
+// applications grouping their functions under one name.
+func NewNamespace(name string, members map[string]Object) *Module {
+	m := &Module{Path: name, Env: NewEnvironment()}
+	for member, val := range members {
+		m.Env.Set(member, val)
+		m.Exports = append(m.Exports, member)
+	}
+	sort.Strings(m.Exports)
+	return m
+}
+
+// Importer resolves the path of an import statement to a module.
+type Importer interface {
+	Import(path string) (*Module, error)
+}
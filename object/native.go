@@ -0,0 +1,335 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const NATIVE_OBJ = "NATIVE"
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bigIntType   = reflect.TypeOf((*big.Int)(nil))
+)
+
+// Allowlist names the exported fields and methods scripts may reach on
+// each Go type. Members of types missing from the list are hidden.
+type Allowlist struct {
+	members map[reflect.Type]map[string]bool
+}
+
+func NewAllowlist() *Allowlist {
+	return &Allowlist{members: make(map[reflect.Type]map[string]bool)}
+}
+
+// Allow exposes the named fields and methods of the type of sample,
+// which may be a value or a pointer: Allow(&Order{}, "Total", "Customer").
+func (a *Allowlist) Allow(sample interface{}, names ...string) *Allowlist {
+	t := baseType(reflect.TypeOf(sample))
+	if a.members[t] == nil {
+		a.members[t] = make(map[string]bool)
+	}
+	for _, name := range names {
+		a.members[t][name] = true
+	}
+	return a
+}
+
+func (a *Allowlist) allowed(t reflect.Type, name string) bool {
+	return a != nil && a.members[baseType(t)][name]
+}
+
+func baseType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Native exposes a Go value to scripts. Fields are read and methods
+// called through the dot operator when the allowlist permits them.
+type Native struct {
+	Value reflect.Value
+	Allow *Allowlist
+}
+
+// NewNative converts v to an object, wrapping it in a Native unless it
+// maps onto a script type such as an integer, boolean or string.
+func NewNative(v interface{}, allow *Allowlist) Object {
+	return fromReflect(reflect.ValueOf(v), allow)
+}
+
+func (n *Native) Type() ObjectType { return NATIVE_OBJ }
+
+// Inspect returns the name of the Go type followed by its allowlisted
+// fields, as in order{ID: 7, Paid: true}. The value itself is never
+// formatted, so its String method and hidden fields stay out of reach.
+func (n *Native) Inspect() string {
+	return n.inspect(make(map[uintptr]bool))
+}
+
+// inspect formats n, printing the values met again through seen
+// pointers as {...} so that cycles end.
+func (n *Native) inspect(seen map[uintptr]bool) string {
+	t := baseType(n.Value.Type())
+	name := t.Name()
+	if name == "" {
+		name = t.Kind().String()
+	}
+
+	v := n.Value
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return name + "(nil)"
+		}
+		if v.Kind() == reflect.Ptr {
+			if seen[v.Pointer()] {
+				return name + "{...}"
+			}
+			seen[v.Pointer()] = true
+			defer delete(seen, v.Pointer())
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return name
+	}
+
+	t = v.Type()
+	fields := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || !n.Allow.allowed(t, field.Name) {
+			continue
+		}
+		var text string
+		switch val := fromReflect(v.Field(i), n.Allow).(type) {
+		case *Native:
+			text = val.inspect(seen)
+		default:
+			text = val.Inspect()
+		}
+		fields = append(fields, field.Name+": "+text)
+	}
+
+	return name + "{" + strings.Join(fields, ", ") + "}"
+}
+
+func (n *Native) Member(name string) (Object, bool) {
+	if !n.Allow.allowed(n.Value.Type(), name) {
+		return nil, false
+	}
+
+	if method := n.Value.MethodByName(name); method.IsValid() {
+		return &Builtin{Name: name, Fn: func(args ...Object) Object {
+			return n.call(name, method, args)
+		}}, true
+	}
+
+	v := n.Value
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return &Error{Message: fmt.Sprintf("nil pointer dereference: %s", name)}, true
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	field := v.FieldByName(name)
+	if !field.IsValid() || !field.CanInterface() {
+		return nil, false
+	}
+
+	return fromReflect(field, n.Allow), true
+}
+
+func (n *Native) call(name string, method reflect.Value, args []Object) (result Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = &Error{Message: fmt.Sprintf("%s: %v", name, r)}
+		}
+	}()
+
+	t := method.Type()
+	if !t.IsVariadic() && len(args) != t.NumIn() ||
+		t.IsVariadic() && len(args) < t.NumIn()-1 {
+		return &Error{Message: fmt.Sprintf("%s: wrong number of arguments. got=%d, want=%d",
+			name, len(args), t.NumIn())}
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var pt reflect.Type
+		if t.IsVariadic() && i >= t.NumIn()-1 {
+			pt = t.In(t.NumIn() - 1).Elem()
+		} else {
+			pt = t.In(i)
+		}
+
+		v, err := toReflect(arg, pt)
+		if err != nil {
+			return &Error{Message: fmt.Sprintf("%s: argument %d: %s", name, i+1, err)}
+		}
+		in[i] = v
+	}
+
+	out := method.Call(in)
+
+	if len(out) > 0 && t.Out(len(out)-1) == errorType {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return &Error{Message: fmt.Sprintf("%s: %s", name, err)}
+		}
+		out = out[:len(out)-1]
+	}
+
+	switch len(out) {
+	case 0:
+		return NULL
+	case 1:
+		return fromReflect(out[0], n.Allow)
+	default:
+		return &Error{Message: fmt.Sprintf("%s: methods returning %d values are not supported", name, len(out))}
+	}
+}
+
+func fromReflect(v reflect.Value, allow *Allowlist) Object {
+	if !v.IsValid() {
+		return NULL
+	}
+
+	switch v.Type() {
+	case timeType:
+		return &Time{Value: v.Interface().(time.Time)}
+	case durationType:
+		return &Duration{Value: time.Duration(v.Int())}
+	case bigIntType:
+		if v.IsNil() {
+			return NULL
+		}
+		return NewInteger(new(big.Int).Set(v.Interface().(*big.Int)))
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return NativeBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NativeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return &BigInteger{Value: new(big.Int).SetUint64(v.Uint())}
+		}
+		return NativeInt(int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}
+	case reflect.String:
+		return &String{Value: v.String()}
+	case reflect.Interface:
+		return fromReflect(v.Elem(), allow)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return NULL
+		}
+		elements := make([]Object, v.Len())
+		for i := range elements {
+			elements[i] = fromReflect(v.Index(i), allow)
+		}
+		return &Array{Elements: elements}
+	case reflect.Ptr, reflect.Map, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return NULL
+		}
+	}
+
+	if obj, ok := v.Interface().(Object); ok {
+		return obj
+	}
+
+	return &Native{Value: v, Allow: allow}
+}
+
+func toReflect(obj Object, t reflect.Type) (reflect.Value, error) {
+	if t == bigIntType {
+		if n, ok := ToBigInt(obj); ok {
+			return reflect.ValueOf(new(big.Int).Set(n)), nil
+		}
+	}
+
+	switch obj := obj.(type) {
+	case *Integer:
+		v := reflect.New(t).Elem()
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(obj.Value) {
+				return v, fmt.Errorf("%d overflows %s", obj.Value, t)
+			}
+			v.SetInt(obj.Value)
+			return v, nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if obj.Value < 0 || v.OverflowUint(uint64(obj.Value)) {
+				return v, fmt.Errorf("%d overflows %s", obj.Value, t)
+			}
+			v.SetUint(uint64(obj.Value))
+			return v, nil
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(obj.Value))
+			return v, nil
+		}
+	case *BigInteger:
+		v := reflect.New(t).Elem()
+		switch t.Kind() {
+		case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+			if obj.Value.IsUint64() && !v.OverflowUint(obj.Value.Uint64()) {
+				v.SetUint(obj.Value.Uint64())
+				return v, nil
+			}
+			return v, fmt.Errorf("%s overflows %s", obj.Value, t)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			return v, fmt.Errorf("%s overflows %s", obj.Value, t)
+		}
+	case *Float:
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return reflect.ValueOf(obj.Value).Convert(t), nil
+		}
+	case *Boolean:
+		if t.Kind() == reflect.Bool {
+			return reflect.ValueOf(obj.Value).Convert(t), nil
+		}
+	case *String:
+		if t.Kind() == reflect.String {
+			return reflect.ValueOf(obj.Value).Convert(t), nil
+		}
+	case *Time:
+		if t == timeType {
+			return reflect.ValueOf(obj.Value), nil
+		}
+	case *Duration:
+		if t == durationType {
+			return reflect.ValueOf(obj.Value), nil
+		}
+	case *Null:
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+	case *Native:
+		if obj.Value.Type().AssignableTo(t) {
+			return obj.Value, nil
+		}
+	}
+
+	if reflect.TypeOf(obj).AssignableTo(t) {
+		return reflect.ValueOf(obj), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot use %s as %s", obj.Type(), t)
+}
@@ -0,0 +1,239 @@
+package object_test
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/stdlib"
+)
+
+type customer struct {
+	Tier   int
+	Name   string
+	Secret string
+	secret string
+}
+
+type order struct {
+	ID       uint32
+	Paid     bool
+	Customer *customer
+	Lines    []int
+	Internal string
+}
+
+func (o *order) Total() int {
+	total := 0
+	for _, l := range o.Lines {
+		total += l
+	}
+	return total
+}
+
+func (o *order) Discount(percent uint8) (int, error) {
+	if percent > 100 {
+		return 0, errors.New("percent out of range")
+	}
+	return o.Total() * int(percent) / 100, nil
+}
+
+func (o *order) Sum(base int, extra ...int) int {
+	for _, e := range extra {
+		base += e
+	}
+	return base
+}
+
+func (o *order) Cancel() {}
+
+func (o *order) String() string { return "order:" + o.Internal }
+
+type node struct {
+	Value int
+	Next  *node
+}
+
+func testNative(t *testing.T, input string, v interface{}) object.Object {
+	allow := object.NewAllowlist().
+		Allow(&order{}, "ID", "Paid", "Customer", "Total", "Discount", "Sum").
+		Allow(customer{}, "Tier", "Name", "secret").
+		Allow(node{}, "Value", "Next")
+
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse %q: %s", input, err)
+	}
+
+	rt := &object.Runtime{}
+	for _, lib := range stdlib.Default() {
+		stdlib.Install(rt, lib)
+	}
+	env := object.NewEnvironment()
+	env.SetRuntime(rt)
+	env.Set("order", object.NewNative(v, allow))
+
+	return evaluator.Eval(program, env)
+}
+
+func TestNative(t *testing.T) {
+	o := &order{ID: 7, Paid: true, Customer: &customer{Tier: 2, Name: "ann"}, Lines: []int{60, 50}}
+
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`order.ID`, int64(7)},
+		{`order.Paid`, true},
+		{`order.Customer.Name`, "ann"},
+		{`order.Total() > 100`, true},
+		{`order.Total() > 100 && order.Customer.Tier == 2`, true},
+		{`order.Total() > 200 || order.Customer.Tier != 2`, false},
+		{`order.Discount(10)`, int64(11)},
+		{`order.Sum(1, 2, 3)`, int64(6)},
+		{`order.Sum(1)`, int64(1)},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testNative(t, tc.input, o)
+
+		switch expected := tc.expected.(type) {
+		case int64:
+			integer, ok := evaluated.(*object.Integer)
+			if !ok || integer.Value != expected {
+				t.Errorf("%s: expected %d, got %#v", tc.input, expected, evaluated)
+			}
+		case bool:
+			if evaluated != object.NativeBool(expected) {
+				t.Errorf("%s: expected %t, got %#v", tc.input, expected, evaluated)
+			}
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("%s: expected %q, got %#v", tc.input, expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestNativeErrors(t *testing.T) {
+	o := &order{Lines: []int{10}}
+
+	testCases := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`order.Internal`, "unknown member of NATIVE: Internal"},
+		{`order.Cancel()`, "unknown member of NATIVE: Cancel"},
+		{`order.Customer.Tier`, "member access on NULL: Tier"},
+		{`order.Discount(200)`, "Discount: percent out of range"},
+		{`order.Discount(300)`, "Discount: argument 1: 300 overflows uint8"},
+		{`order.Discount(true)`, "Discount: argument 1: cannot use BOOLEAN as uint8"},
+		{`order.Discount()`, "Discount: wrong number of arguments. got=0, want=1"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testNative(t, tc.input, o)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tc.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tc.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestNativeNilPointer(t *testing.T) {
+	var o *order
+
+	evaluated := testNative(t, `order`, o)
+	if evaluated != object.NULL {
+		t.Fatalf("nil pointers must convert to null. got=%#v", evaluated)
+	}
+
+	allow := object.NewAllowlist().Allow(&order{}, "ID")
+	native := &object.Native{Value: reflect.ValueOf(o), Allow: allow}
+	val, ok := native.Member("ID")
+	if errObj, isErr := val.(*object.Error); !ok || !isErr || errObj.Message != "nil pointer dereference: ID" {
+		t.Errorf("expected nil pointer error, got %#v", val)
+	}
+}
+
+func TestNativeUnexportedField(t *testing.T) {
+	allow := object.NewAllowlist().Allow(customer{}, "secret")
+	native := object.NewNative(customer{secret: "x"}, allow).(object.Accessor)
+
+	if _, ok := native.Member("secret"); ok {
+		t.Errorf("unexported fields must stay hidden")
+	}
+}
+
+func TestNativeInspect(t *testing.T) {
+	o := &order{ID: 7, Customer: &customer{Tier: 2, Name: "ann", Secret: "s3cr3t"}, Internal: "hunter2"}
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`format("%v", order)`, "order{ID: 7, Paid: false, Customer: customer{Tier: 2, Name: ann}}"},
+		{`format("%s", order.Customer)`, "customer{Tier: 2, Name: ann}"},
+		{`try { throw order } catch (e) { e.message }`, "order{ID: 7, Paid: false, Customer: customer{Tier: 2, Name: ann}}"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testNative(t, tc.input, o)
+		str, ok := evaluated.(*object.String)
+		if !ok || str.Value != tc.expected {
+			t.Errorf("%s: expected %q, got %#v", tc.input, tc.expected, evaluated)
+			continue
+		}
+		if strings.Contains(str.Value, "hunter2") || strings.Contains(str.Value, "s3cr3t") {
+			t.Errorf("%s: hidden data printed: %s", tc.input, str.Value)
+		}
+	}
+
+	n := &node{Value: 1}
+	n.Next = n
+	if got := testNative(t, `order`, n).Inspect(); got != "node{Value: 1, Next: node{...}}" {
+		t.Errorf("expected the cycle to be cut, got %s", got)
+	}
+}
+
+func TestNativeBigIntegers(t *testing.T) {
+	if obj := object.NewNative(uint64(1<<63), nil); obj.Inspect() != "9223372036854775808" {
+		t.Errorf("expected a big integer, got %#v", obj)
+	}
+	if _, ok := object.NewNative(big.NewInt(42), nil).(*object.Integer); !ok {
+		t.Errorf("expected a small *big.Int to become an integer")
+	}
+
+	evaluated := testNative(t, `order.Discount(99999999999999999999)`, &order{})
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "Discount: argument 1: 99999999999999999999 overflows uint8" {
+		t.Errorf("expected an overflow error, got %#v", evaluated)
+	}
+}
+
+func TestNativeInt(t *testing.T) {
+	if object.NativeInt(7) != object.NativeInt(7) || object.NativeInt(-128) != object.NativeInt(-128) {
+		t.Errorf("small integers must be shared")
+	}
+	if object.NativeInt(1<<20) == object.NativeInt(1<<20) {
+		t.Errorf("large integers must be allocated")
+	}
+	for _, n := range []int64{-129, -128, 0, 1023, 1024} {
+		if got := object.NativeInt(n).Value; got != n {
+			t.Errorf("expected %d, got %d", n, got)
+		}
+	}
+}
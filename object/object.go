@@ -4,21 +4,38 @@ import (
 	"bytes"
 	"github.com/NilCent/eval/ast"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
 type ObjectType string
 
 const (
-	NULL_OBJ  = "NULL"
-	ERROR_OBJ = "ERROR"
+	NULL_OBJ      = "NULL"
+	ERROR_OBJ     = "ERROR"
+	EXCEPTION_OBJ = "EXCEPTION"
 
 	INTEGER_OBJ = "INTEGER"
+	FLOAT_OBJ   = "FLOAT"
 	BOOLEAN_OBJ = "BOOLEAN"
+	STRING_OBJ  = "STRING"
 
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 
 	FUNCTION_OBJ = "FUNCTION"
+	BUILTIN_OBJ  = "BUILTIN"
+
+	ARRAY_OBJ  = "ARRAY"
+	HASH_OBJ   = "HASH"
+	MODULE_OBJ = "MODULE"
+)
+
+// The evaluator compares booleans and null by identity, so objects
+// handed to scripts must use these values.
+var (
+	NULL  = &Null{}
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
 )
 
 type Object interface {
@@ -26,13 +43,51 @@ type Object interface {
 	Inspect() string
 }
 
+func NativeBool(input bool) *Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
 type Integer struct {
 	Value int64
 }
 
+// Integers are immutable, so that the small ones, most results of
+// arithmetic, are shared rather than allocated by each operation.
+const (
+	minSmallInteger = -128
+	maxSmallInteger = 1023
+)
+
+var smallIntegers = func() []Integer {
+	integers := make([]Integer, maxSmallInteger-minSmallInteger+1)
+	for i := range integers {
+		integers[i].Value = int64(i + minSmallInteger)
+	}
+	return integers
+}()
+
+// NativeInt returns the Integer holding input, shared by every call for
+// small values.
+func NativeInt(input int64) *Integer {
+	if input >= minSmallInteger && input <= maxSmallInteger {
+		return &smallIntegers[input-minSmallInteger]
+	}
+	return &Integer{Value: input}
+}
+
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string  { return strconv.FormatFloat(f.Value, 'f', -1, 64) }
+
 type Boolean struct {
 	Value bool
 }
@@ -40,6 +95,13 @@ type Boolean struct {
 func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
 func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
 
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Inspect() string  { return s.Value }
+
 type Null struct{}
 
 func (n *Null) Type() ObjectType { return NULL_OBJ }
@@ -52,25 +114,57 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+// Error aborts evaluation until it is caught by a try expression.
+// Value holds the thrown object and is nil for runtime errors.
 type Error struct {
 	Message string
+	Line    int
+	Value   Object
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 
+// Exception is the value bound to the parameter of a catch clause.
+type Exception struct {
+	Message string
+	Line    int
+	Value   Object
+}
+
+func (e *Exception) Type() ObjectType { return EXCEPTION_OBJ }
+func (e *Exception) Inspect() string  { return e.Message }
+func (e *Exception) Member(name string) (Object, bool) {
+	switch name {
+	case "message":
+		return &String{Value: e.Message}, true
+	case "line":
+		return NativeInt(int64(e.Line)), true
+	case "value":
+		return e.Value, e.Value != nil
+	}
+	return nil, false
+}
+
 type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	// Slots names the variables of a call when the function literal
+	// has been resolved, nil otherwise.
+	Slots []string
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
 func (f *Function) Inspect() string {
+	return inspectFunction(f.Parameters, f.Body)
+}
+
+func inspectFunction(parameters []*ast.Identifier, body *ast.BlockStatement) string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range f.Parameters {
+	for _, p := range parameters {
 		params = append(params, p.String())
 	}
 
@@ -78,8 +172,32 @@ func (f *Function) Inspect() string {
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") {\n")
-	out.WriteString(f.Body.String())
+	out.WriteString(body.String())
 	out.WriteString("\n}")
 
 	return out.String()
 }
+
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin " + b.Name }
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string {
+	elements := []string{}
+	for _, e := range a.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
@@ -0,0 +1,55 @@
+package object
+
+import "math/big"
+
+const RATIONAL_OBJ = "RATIONAL"
+
+// Rational is an exact fraction, produced by integer division in
+// rational mode. Its num and den members are the numerator and the
+// positive denominator in lowest terms. Integers have them too, with a
+// den of 1, since whole quotients are integers.
+type Rational struct {
+	Value *big.Rat
+}
+
+func (r *Rational) Type() ObjectType { return RATIONAL_OBJ }
+func (r *Rational) Inspect() string  { return r.Value.String() }
+
+func (r *Rational) Member(name string) (Object, bool) {
+	switch name {
+	case "num":
+		return NewInteger(new(big.Int).Set(r.Value.Num())), true
+	case "den":
+		return NewInteger(new(big.Int).Set(r.Value.Denom())), true
+	}
+	return nil, false
+}
+
+// Member returns the integer itself as num and 1 as den.
+func (i *Integer) Member(name string) (Object, bool) {
+	return integerMember(i, name)
+}
+
+// Member returns the integer itself as num and 1 as den.
+func (b *BigInteger) Member(name string) (Object, bool) {
+	return integerMember(b, name)
+}
+
+func integerMember(i Object, name string) (Object, bool) {
+	switch name {
+	case "num":
+		return i, true
+	case "den":
+		return NativeInt(1), true
+	}
+	return nil, false
+}
+
+// NewRational returns an integer when the denominator of v is 1 and a
+// Rational otherwise.
+func NewRational(v *big.Rat) Object {
+	if v.IsInt() {
+		return NewInteger(new(big.Int).Set(v.Num()))
+	}
+	return &Rational{Value: v}
+}
@@ -0,0 +1,114 @@
+package object
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TIME_OBJ     = "TIME"
+	DURATION_OBJ = "DURATION"
+)
+
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Type() ObjectType { return TIME_OBJ }
+func (t *Time) Inspect() string  { return t.Value.Format(time.RFC3339) }
+
+// Member returns the components of the time. Weekdays run from 1 for
+// Monday to 7 for Sunday.
+func (t *Time) Member(name string) (Object, bool) {
+	var value int
+	switch name {
+	case "year":
+		value = t.Value.Year()
+	case "month":
+		value = int(t.Value.Month())
+	case "day":
+		value = t.Value.Day()
+	case "hour":
+		value = t.Value.Hour()
+	case "minute":
+		value = t.Value.Minute()
+	case "second":
+		value = t.Value.Second()
+	case "weekday":
+		value = int(t.Value.Weekday())
+		if value == 0 {
+			value = 7
+		}
+	case "yearday":
+		value = t.Value.YearDay()
+	case "unix":
+		return NativeInt(t.Value.Unix()), true
+	default:
+		return nil, false
+	}
+	return NativeInt(int64(value)), true
+}
+
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Type() ObjectType { return DURATION_OBJ }
+
+// Inspect formats the duration with the units of duration literals,
+// so that 36h prints as 1d12h.
+func (d *Duration) Inspect() string {
+	v := d.Value
+	if v == 0 {
+		return "0s"
+	}
+
+	var out strings.Builder
+	if v < 0 {
+		out.WriteString("-")
+		v = -v
+	}
+
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+		{"ms", time.Millisecond},
+	}
+	for _, unit := range units {
+		if n := v / unit.size; n > 0 {
+			out.WriteString(strconv.FormatInt(int64(n), 10) + unit.name)
+			v -= n * unit.size
+		}
+	}
+	if v > 0 {
+		out.WriteString(v.String())
+	}
+
+	return out.String()
+}
+
+// Member returns the length of the duration in whole units.
+func (d *Duration) Member(name string) (Object, bool) {
+	var unit time.Duration
+	switch name {
+	case "days":
+		unit = 24 * time.Hour
+	case "hours":
+		unit = time.Hour
+	case "minutes":
+		unit = time.Minute
+	case "seconds":
+		unit = time.Second
+	case "milliseconds":
+		unit = time.Millisecond
+	default:
+		return nil, false
+	}
+	return NativeInt(int64(d.Value / unit)), true
+}
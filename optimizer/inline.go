@@ -0,0 +1,141 @@
+package optimizer
+
+import "github.com/NilCent/eval/ast"
+
+// inlineFunction is a function whose body is an expression of its
+// parameters and literals only, such as fn(x) { x * 2 }. Such a
+// function calls none, so is not recursive, and its calls with literal
+// arguments may be replaced by its body without changing which
+// expression fails first.
+type inlineFunction struct {
+	params []string
+	body   ast.Expression
+}
+
+// countBindings counts the declarations of each name in program, which
+// is nil when an import without alias may bind any name.
+func countBindings(program *ast.Program) map[string]int {
+	bindings := make(map[string]int)
+	dynamic := false
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			bindings[node.Name.Value]++
+		case *ast.FunctionLiteral:
+			for _, param := range node.Parameters {
+				bindings[param.Value]++
+			}
+		case *ast.TryExpression:
+			if node.Param != nil {
+				bindings[node.Param.Value]++
+			}
+		case *ast.ImportStatement:
+			if node.Alias != nil {
+				bindings[node.Alias.Value]++
+			} else {
+				dynamic = true
+			}
+		}
+		return true
+	})
+
+	if dynamic {
+		return nil
+	}
+	return bindings
+}
+
+// register records the function bound by a top-level let statement for
+// inlining in the statements following it. The name must be bound
+// nowhere else, so that every later call refers to that function.
+func (o *optimizer) register(stmt ast.Statement) {
+	if export, ok := stmt.(*ast.ExportStatement); ok {
+		stmt = export.Statement
+	}
+	let, ok := stmt.(*ast.LetStatement)
+	if !ok || o.bindings == nil || o.bindings[let.Name.Value] != 1 {
+		return
+	}
+
+	fn, ok := let.Value.(*ast.FunctionLiteral)
+	if !ok || len(fn.Body.Statements) != 1 {
+		return
+	}
+	es, ok := fn.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return
+	}
+
+	params := make([]string, len(fn.Parameters))
+	seen := make(map[string]bool)
+	for i, param := range fn.Parameters {
+		if seen[param.Value] {
+			return
+		}
+		seen[param.Value] = true
+		params[i] = param.Value
+	}
+	if !trivial(es.Expression, seen) {
+		return
+	}
+
+	o.inline[let.Name.Value] = &inlineFunction{params: params, body: es.Expression}
+}
+
+// trivial reports whether exp consists of operators applied to literals
+// and parameters.
+func trivial(exp ast.Expression, params map[string]bool) bool {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		return params[exp.Value]
+	case *ast.PrefixExpression:
+		return trivial(exp.Right, params)
+	case *ast.InfixExpression:
+		return trivial(exp.Left, params) && trivial(exp.Right, params)
+	}
+	return isLiteral(exp)
+}
+
+// inlineCall returns the body of the function called by call with the
+// parameters replaced by the arguments. Only calls passing literals are
+// inlined: other arguments could fail, or be evaluated several times.
+func (o *optimizer) inlineCall(call *ast.CallExpression) (ast.Expression, bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+	fn, ok := o.inline[ident.Value]
+	if !ok || len(call.Arguments) != len(fn.params) {
+		return nil, false
+	}
+
+	args := make(map[string]ast.Expression)
+	for i, arg := range call.Arguments {
+		if !isLiteral(arg) {
+			return nil, false
+		}
+		args[fn.params[i]] = arg
+	}
+
+	return substitute(fn.body, args), true
+}
+
+// substitute copies exp, a trivial expression, replacing parameters by
+// the matching arguments.
+func substitute(exp ast.Expression, args map[string]ast.Expression) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		return args[exp.Value]
+	case *ast.PrefixExpression:
+		return &ast.PrefixExpression{Token: exp.Token, Operator: exp.Operator, Right: substitute(exp.Right, args)}
+	case *ast.InfixExpression:
+		return &ast.InfixExpression{
+			Token:    exp.Token,
+			Operator: exp.Operator,
+			Left:     substitute(exp.Left, args),
+			Right:    substitute(exp.Right, args),
+		}
+	}
+	return exp
+}
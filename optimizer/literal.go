@@ -0,0 +1,56 @@
+package optimizer
+
+import (
+	"strconv"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/token"
+)
+
+// isLiteral reports whether exp evaluates to a constant without fail.
+func isLiteral(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.IntegerLiteral, *ast.BigIntegerLiteral, *ast.FloatLiteral,
+		*ast.DecimalLiteral, *ast.DurationLiteral, *ast.StringLiteral,
+		*ast.Boolean:
+		return true
+	}
+	return false
+}
+
+// literal returns the literal evaluating to val, written at line. Values
+// of other types than those of literals have none.
+func literal(val object.Object, line int) (ast.Expression, bool) {
+	switch val := val.(type) {
+	case *object.Integer:
+		tok := token.New(token.INT, strconv.FormatInt(val.Value, 10), line)
+		return &ast.IntegerLiteral{Token: tok, Value: val.Value, Object: val}, true
+	case *object.BigInteger:
+		tok := token.New(token.INT, val.Value.String(), line)
+		return &ast.BigIntegerLiteral{Token: tok, Value: val.Value, Object: val}, true
+	case *object.Float:
+		tok := token.New(token.FLOAT, val.Inspect(), line)
+		return &ast.FloatLiteral{Token: tok, Value: val.Value, Object: val}, true
+	case *object.Decimal:
+		tok := token.New(token.DECIMAL, val.Inspect()+token.DecimalSuffix, line)
+		return &ast.DecimalLiteral{Token: tok, Value: val.Value, Scale: val.Scale, Object: val}, true
+	case *object.String:
+		tok := token.New(token.STRING, val.Value, line)
+		return &ast.StringLiteral{Token: tok, Value: val.Value, Object: val}, true
+	case *object.Boolean:
+		if val.Value {
+			return &ast.Boolean{Token: trueToken(line), Value: true}, true
+		}
+		return &ast.Boolean{Token: token.New(token.FALSE, "false", line), Value: false}, true
+	}
+	return nil, false
+}
+
+func trueToken(line int) token.Token {
+	return token.New(token.TRUE, "true", line)
+}
+
+func falseToken(line int) token.Token {
+	return token.New(token.FALSE, "false", line)
+}
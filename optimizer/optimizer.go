@@ -0,0 +1,300 @@
+// Package optimizer rewrites programs into equivalent ones doing less
+// work at run time. It folds operators applied to literals, drops the
+// branches of if expressions whose condition is a literal and inlines
+// calls of trivial functions.
+//
+// An optimized program produces the same value or the same error, at
+// the same line, as the original. Operations failing on their literal
+// operands, such as 1 / 0, are therefore left to fail at run time, and
+// identities such as x * 1 are not simplified, since they fail when x
+// is not a number.
+package optimizer
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/resolver"
+)
+
+// Optimize returns an optimized copy of program, leaving program
+// untouched. Constants are folded with the semantics selected by rt,
+// which may be nil for the defaults; the program must be evaluated with
+// the same runtime.
+func Optimize(program *ast.Program, rt *object.Runtime) *ast.Program {
+	env := object.NewEnvironment()
+	env.SetRuntime(rt)
+
+	o := &optimizer{
+		env:      env,
+		bindings: countBindings(program),
+		inline:   make(map[string]*inlineFunction),
+	}
+
+	statements := make([]ast.Statement, 0, len(program.Statements))
+	for i, stmt := range program.Statements {
+		statements = o.appendStatement(statements, stmt, i == len(program.Statements)-1)
+		o.register(stmt)
+	}
+
+	return &ast.Program{Statements: statements}
+}
+
+type optimizer struct {
+	// env evaluates operators applied to literals.
+	env *object.Environment
+	// bindings counts the declarations of each name in the program,
+	// or is nil when an import without alias binds unknown names.
+	bindings map[string]int
+	// inline holds the functions whose calls are replaced by their
+	// body, once their let statement has been passed.
+	inline map[string]*inlineFunction
+}
+
+func (o *optimizer) block(block *ast.BlockStatement) *ast.BlockStatement {
+	statements := make([]ast.Statement, 0, len(block.Statements))
+	for i, stmt := range block.Statements {
+		statements = o.appendStatement(statements, stmt, i == len(block.Statements)-1)
+	}
+	return &ast.BlockStatement{Token: block.Token, Statements: statements}
+}
+
+// appendStatement appends the optimized form of stmt to statements.
+// Since blocks share the environment of the enclosing call, the taken
+// branch of an if statement is spliced into the block holding it, after
+// the declarations of the dropped one. The value of a block being the
+// value of its last statement, statements having no effect are dropped
+// unless last.
+func (o *optimizer) appendStatement(statements []ast.Statement, stmt ast.Statement, last bool) []ast.Statement {
+	stmt = o.statement(stmt)
+
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return append(statements, stmt)
+	}
+
+	if ie, ok := es.Expression.(*ast.IfExpression); ok && isTrue(ie.Condition) &&
+		len(ie.Consequence.Statements) > 0 {
+		if ie.Alternative != nil {
+			statements = append(statements, &ast.ExpressionStatement{
+				Token: es.Token,
+				Expression: &ast.IfExpression{
+					Token:       ie.Token,
+					Condition:   &ast.Boolean{Token: falseToken(ie.Token.Line), Value: false},
+					Consequence: ie.Alternative,
+				},
+			})
+		}
+		return append(statements, ie.Consequence.Statements...)
+	}
+	if !last && inert(es.Expression) {
+		return statements
+	}
+	return append(statements, stmt)
+}
+
+func (o *optimizer) statement(stmt ast.Statement) ast.Statement {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return &ast.LetStatement{Token: stmt.Token, Name: stmt.Name, Value: o.expression(stmt.Value)}
+	case *ast.ExportStatement:
+		return &ast.ExportStatement{Token: stmt.Token, Statement: o.statement(stmt.Statement).(*ast.LetStatement)}
+	case *ast.ReturnStatement:
+		return &ast.ReturnStatement{Token: stmt.Token, ReturnValue: o.expression(stmt.ReturnValue)}
+	case *ast.ThrowStatement:
+		return &ast.ThrowStatement{Token: stmt.Token, Value: o.expression(stmt.Value)}
+	case *ast.ExpressionStatement:
+		return &ast.ExpressionStatement{Token: stmt.Token, Expression: o.expression(stmt.Expression)}
+	case *ast.BlockStatement:
+		return o.block(stmt)
+	}
+	return stmt
+}
+
+func (o *optimizer) expression(exp ast.Expression) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.PrefixExpression:
+		folded := &ast.PrefixExpression{Token: exp.Token, Operator: exp.Operator, Right: o.expression(exp.Right)}
+		if isLiteral(folded.Right) {
+			return o.fold(folded, exp.Token.Line)
+		}
+		return folded
+
+	case *ast.InfixExpression:
+		folded := &ast.InfixExpression{
+			Token:    exp.Token,
+			Operator: exp.Operator,
+			Left:     o.expression(exp.Left),
+			Right:    o.expression(exp.Right),
+		}
+		if isLiteral(folded.Left) && isLiteral(folded.Right) {
+			return o.fold(folded, exp.Token.Line)
+		}
+		return folded
+
+	case *ast.IfExpression:
+		return o.ifExpression(exp)
+
+	case *ast.TryExpression:
+		te := &ast.TryExpression{
+			Token:      exp.Token,
+			Block:      o.block(exp.Block),
+			Param:      exp.Param,
+			CatchSlots: exp.CatchSlots,
+		}
+		if exp.Catch != nil {
+			te.Catch = o.block(exp.Catch)
+		}
+		if exp.Finally != nil {
+			te.Finally = o.block(exp.Finally)
+		}
+		return te
+
+	case *ast.MemberExpression:
+		return &ast.MemberExpression{Token: exp.Token, Object: o.expression(exp.Object), Property: exp.Property}
+
+	case *ast.IndexExpression:
+		return &ast.IndexExpression{Token: exp.Token, Left: o.expression(exp.Left), Index: o.expression(exp.Index)}
+
+	case *ast.ArrayLiteral:
+		return &ast.ArrayLiteral{Token: exp.Token, Elements: o.expressions(exp.Elements)}
+
+	case *ast.HashLiteral:
+		return &ast.HashLiteral{Token: exp.Token, Keys: o.expressions(exp.Keys), Values: o.expressions(exp.Values)}
+
+	case *ast.FunctionLiteral:
+		return &ast.FunctionLiteral{
+			Token:      exp.Token,
+			Parameters: exp.Parameters,
+			Body:       o.block(exp.Body),
+			Slots:      exp.Slots,
+		}
+
+	case *ast.CallExpression:
+		call := &ast.CallExpression{
+			Token:     exp.Token,
+			Function:  o.expression(exp.Function),
+			Arguments: o.expressions(exp.Arguments),
+		}
+		if inlined, ok := o.inlineCall(call); ok {
+			return o.expression(inlined)
+		}
+		return call
+	}
+
+	return exp
+}
+
+func (o *optimizer) expressions(exps []ast.Expression) []ast.Expression {
+	optimized := make([]ast.Expression, len(exps))
+	for i, exp := range exps {
+		optimized[i] = o.expression(exp)
+	}
+	return optimized
+}
+
+// ifExpression reduces an if expression whose condition is a literal to
+// its taken branch: the expression the branch consists of, or else an
+// if expression always taking it, which appendStatement may splice.
+// The variables the dropped branch declares are still variables of the
+// enclosing call, so its let and import statements are kept in a branch
+// never taken.
+func (o *optimizer) ifExpression(exp *ast.IfExpression) ast.Expression {
+	condition := o.expression(exp.Condition)
+	if !isLiteral(condition) {
+		ie := &ast.IfExpression{Token: exp.Token, Condition: condition, Consequence: o.block(exp.Consequence)}
+		if exp.Alternative != nil {
+			ie.Alternative = o.block(exp.Alternative)
+		}
+		return ie
+	}
+
+	taken, dropped := exp.Alternative, exp.Consequence
+	if evaluator.IsTruthy(o.eval(condition)) {
+		taken, dropped = exp.Consequence, exp.Alternative
+	}
+	declarations := o.declarations(dropped)
+	if taken == nil {
+		// evaluates to null
+		if declarations == nil {
+			declarations = &ast.BlockStatement{Token: exp.Consequence.Token}
+		}
+		return &ast.IfExpression{Token: exp.Token, Condition: condition, Consequence: declarations}
+	}
+
+	block := o.block(taken)
+	if declarations == nil && len(block.Statements) == 1 {
+		if es, ok := block.Statements[0].(*ast.ExpressionStatement); ok {
+			return es.Expression
+		}
+	}
+	return &ast.IfExpression{
+		Token:       exp.Token,
+		Condition:   &ast.Boolean{Token: trueToken(exp.Token.Line), Value: true},
+		Consequence: block,
+		Alternative: declarations,
+	}
+}
+
+// declarations returns a block of the statements of block declaring
+// variables in the enclosing call, see resolver.Declarations, nil if
+// there are none. Those in the value of a let statement are kept with
+// it.
+func (o *optimizer) declarations(block *ast.BlockStatement) *ast.BlockStatement {
+	if block == nil {
+		return nil
+	}
+	var statements []ast.Statement
+	nested := make(map[ast.Statement]bool)
+	resolver.Declarations(block, func(decl ast.Statement, name *ast.Identifier) {
+		if nested[decl] {
+			return
+		}
+		resolver.Declarations(decl, func(decl ast.Statement, name *ast.Identifier) {
+			nested[decl] = true
+		})
+		statements = append(statements, o.statement(decl))
+	})
+	if statements == nil {
+		return nil
+	}
+	return &ast.BlockStatement{Token: block.Token, Statements: statements}
+}
+
+// fold evaluates an operator applied to literals, keeping the
+// expression when it fails or its value has no literal form.
+func (o *optimizer) fold(exp ast.Expression, line int) ast.Expression {
+	val := o.eval(exp)
+	if lit, ok := literal(val, line); ok {
+		return lit
+	}
+	return exp
+}
+
+func (o *optimizer) eval(exp ast.Expression) object.Object {
+	return evaluator.Eval(exp, o.env)
+}
+
+// inert reports whether evaluating exp has no effect.
+func inert(exp ast.Expression) bool {
+	if ie, ok := exp.(*ast.IfExpression); ok {
+		return isLiteral(ie.Condition) && inertBlock(ie.Consequence) &&
+			(ie.Alternative == nil || inertBlock(ie.Alternative))
+	}
+	return isLiteral(exp)
+}
+
+func inertBlock(block *ast.BlockStatement) bool {
+	for _, stmt := range block.Statements {
+		es, ok := stmt.(*ast.ExpressionStatement)
+		if !ok || !inert(es.Expression) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTrue(exp ast.Expression) bool {
+	b, ok := exp.(*ast.Boolean)
+	return ok && b.Value
+}
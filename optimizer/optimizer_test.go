@@ -0,0 +1,151 @@
+package optimizer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/resolver"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	return program
+}
+
+func TestOptimize(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"-(2 - 5)", "3"},
+		{"!(1 < 2)", "false"},
+		{`"a" + "b" == "ab"`, "true"},
+		{"1.5 + 1", "2.5"},
+		{"0.10dec + 0.20dec", "0.30dec"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"x + 2 * 3", "(x + 6)"},
+		// failing and non-constant operations are kept
+		{"1 / 0", "(1 / 0)"},
+		{"1 + true", "(1 + true)"},
+		{"1 * x + 0", "((1 * x) + 0)"},
+		// dead branches
+		{"if (1 > 2) { a } else { b }", "b"},
+		{"if (1 < 2) { a } else { b }", "a"},
+		{"if (0) { a }", "a"},
+		{"if (true) { let y = 1; y }", "let y = 1;y"},
+		{"let f = fn() { if (true) { return 1; } 2 }", "let f = fn() return 1;2;"},
+		{"f(1); 2; if (false) { 3 }; 4", "f(1)4"},
+		{"if (false) { let x = 1; x } else { 2 }", "iffalse let x = 1;2"},
+		{"let v = if (false) { let x = 1; x } else { 2 }", "let v = iftrue 2else let x = 1;;"},
+		{"if (false) { if (y) { let x = 1; }; fn() { let z = 2; } }", "iffalse let x = 1;"},
+		{"if (false) { let a = if (y) { let b = 1; b }; import \"m\"; }", `iffalse let a = ify let b = 1;b;import "m";`},
+		{"if (x) { 1 + 1 } else { 2 * 2 }", "ifx 2else 4"},
+		// inlining
+		{"let double = fn(x) { x * 2 }; double(21)", "let double = fn(x) (x * 2);42"},
+		{"let sub = fn(a, b) { a - b }; sub(1, 2) + sub(3, y)", "let sub = fn(a, b) (a - b);(-1 + sub(3, y))"},
+		{"let div = fn(a, b) { a / b }; div(1, 0)", "let div = fn(a, b) (a / b);(1 / 0)"},
+		{"let k = fn() { 1 }; k(2)", "let k = fn() 1;k(2)"},
+		{"double(1); let double = fn(x) { x * 2 };", "double(1)let double = fn(x) (x * 2);"},
+		{"let f = fn(x) { x }; let g = fn(f) { f }; f(1)", "let f = fn(x) x;let g = fn(f) f;f(1)"},
+		{"let f = fn(x) { g(x) }; f(1)", "let f = fn(x) g(x);f(1)"},
+		{`let f = fn(x) { x }; import "m"; f(1)`, `let f = fn(x) x;import "m";f(1)`},
+	}
+
+	for _, tc := range testCases {
+		program := parse(t, tc.input)
+		before := program.String()
+
+		got := Optimize(program, nil).String()
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.input, tc.expected, got)
+		}
+		if program.String() != before {
+			t.Errorf("%s: the program was modified", tc.input)
+		}
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	testCases := []struct {
+		input    string
+		rt       *object.Runtime
+		expected string
+	}{
+		{"7 / 2", nil, "3"},
+		{"7 / 2", &object.Runtime{Rational: true}, "(7 / 2)"},
+		{"8 / 2", &object.Runtime{Rational: true}, "4"},
+		{"1.00dec / 3", nil, "0.33dec"},
+		{"1.00dec / 3", &object.Runtime{Decimal: &object.DecimalContext{Scale: 4}}, "0.3333dec"},
+	}
+
+	for _, tc := range testCases {
+		got := Optimize(parse(t, tc.input), tc.rt).String()
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.input, tc.expected, got)
+		}
+	}
+}
+
+// TestSemantics checks that optimized programs resolve and evaluate as
+// the original ones, errors and their lines included.
+func TestSemantics(t *testing.T) {
+	inputs := []string{
+		"1 + 2 * 3",
+		"1 / 0",
+		"let a = 1;\nlet b = 0;\n10 / (a - 1)",
+		"if (1 > 2) { 1 }",
+		"let x = 5; if (true) { }",
+		"let x = 5; if (false) { 1 }; x",
+		"let x = 5; if (false) { 1 }",
+		"if (true) { let y = 2; };\ny * 2",
+		"let f = fn(n) {\n  if (true) { return n; }\n  n + 1\n};\nf(1)",
+		"let div = fn(a, b) {\n  a / b\n};\ndiv(1, 2) + div(1, 0)",
+		"let neg = fn(x) {\n  -x\n};\nneg(\"a\")",
+		"let k = fn() { 1 };\nk(1, 2)",
+		"let inc = fn(x) { x + 1 };\nlet inc = fn(x) { x + 2 };\ninc(1)",
+		"let f = fn(x) { x * 2 };\nlet g = fn() { f(3) };\ng()",
+		"let f = fn(x) { x * 2 };\nlet g = fn(f) { f(3) };\ng(fn(x) { x })",
+		"try { 1 / 0 } catch (e) { if (true) { e.message } }",
+		"let r = try { if (false) { 1 } } finally { 2 }; r",
+		"throw 1 + 1",
+		"\"a\" + 1",
+		"if (1 < 2 == true) { \"yes\" } else { \"no\" }",
+		"let f = fn(c) { if (false) { let x = 5; }; if (c) { x } else { 0 } }; f(false)",
+		"let f = fn(c) { if (false) { let x = 5; }; if (c) { x } else { 0 } }; f(true)",
+		"if (false) { let y = 1; }; let g = fn() { y }; 0",
+		"let f = fn() { if (true) { 1 } else { let x = 2; }; x }; f()",
+		"let f = fn() { if (false) { try { let x = 1; } catch (e) { let y = 2; } }; x }; f()",
+	}
+
+	for _, input := range inputs {
+		program := parse(t, input)
+		want := describe(resolver.Resolve(program, nil), evaluator.Eval(program, object.NewEnvironment()))
+		optimized := Optimize(parse(t, input), nil)
+		got := describe(resolver.Resolve(optimized, nil), evaluator.Eval(optimized, object.NewEnvironment()))
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", input, want, got)
+		}
+	}
+}
+
+func describe(err error, obj object.Object) string {
+	if err != nil {
+		return err.Error()
+	}
+	switch obj := obj.(type) {
+	case nil:
+		return "nothing"
+	case *object.Error:
+		return fmt.Sprintf("ERROR line %d: %s", obj.Line, obj.Message)
+	}
+	return fmt.Sprintf("%s %s", obj.Type(), obj.Inspect())
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+var durationUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// parseDuration parses a sequence of integers each followed by a unit,
+// from ms to w (weeks): 30d, 2h, 1h30m.
+func parseDuration(s string) (time.Duration, error) {
+	var total time.Duration
+
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, errors.New("expected a number before " + strconv.Quote(s))
+		}
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		j := i
+		for j < len(s) && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		unit, ok := durationUnits[s[i:j]]
+		if !ok {
+			return 0, errors.New("unknown unit " + strconv.Quote(s[i:j]))
+		}
+
+		if n > int64(math.MaxInt64/unit) || total > math.MaxInt64-time.Duration(n)*unit {
+			return 0, errors.New("value out of range")
+		}
+		total += time.Duration(n) * unit
+		s = s[j:]
+	}
+
+	return total, nil
+}
@@ -32,3 +32,32 @@ type ErrInteger struct {
 func (e *ErrInteger) Error() string {
 	return fmt.Sprintf("Line %d Could not parse %s as integer: %s", e.Line, e.TokenLiteral, e.Err.Error())
 }
+
+type ErrFloat struct {
+	Err          error
+	Line         int
+	TokenLiteral string
+}
+
+func (e *ErrFloat) Error() string {
+	return fmt.Sprintf("Line %d Could not parse %s as float: %s", e.Line, e.TokenLiteral, e.Err.Error())
+}
+
+type ErrDuration struct {
+	Err          error
+	Line         int
+	TokenLiteral string
+}
+
+func (e *ErrDuration) Error() string {
+	return fmt.Sprintf("Line %d Could not parse %s as duration: %s", e.Line, e.TokenLiteral, e.Err.Error())
+}
+
+type ErrDecimal struct {
+	Line         int
+	TokenLiteral string
+}
+
+func (e *ErrDecimal) Error() string {
+	return fmt.Sprintf("Line %d Could not parse %s as decimal", e.Line, e.TokenLiteral)
+}
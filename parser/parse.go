@@ -1,9 +1,13 @@
 package parser
 
 import (
+	"errors"
 	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/object"
 	"github.com/NilCent/eval/token"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 func (p *Parser) ParseProgram() (*ast.Program, error) {
@@ -36,6 +40,12 @@ func (p *Parser) parseStatement() (ast.Statement, error) {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -99,6 +109,78 @@ func (p *Parser) parseReturnStatement() (*ast.ReturnStatement, error) {
 	return stmt, nil
 }
 
+func (p *Parser) parseThrowStatement() (*ast.ThrowStatement, error) {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
+	err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Value, err = p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peekToken.Is(token.SEMICOLON) {
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseImportStatement() (*ast.ImportStatement, error) {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	err := p.expectPeek(token.STRING)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekToken.Is(token.AS) {
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.expectPeek(token.IDENT)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if p.peekToken.Is(token.SEMICOLON) {
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseExportStatement() (*ast.ExportStatement, error) {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	err := p.expectPeek(token.LET)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Statement, err = p.parseLetStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
 func (p *Parser) parseExpressionStatement() (*ast.ExpressionStatement, error) {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
@@ -160,15 +242,73 @@ func (p *Parser) parseIntegerLiteral() (ast.Expression, error) {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if n, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			return &ast.BigIntegerLiteral{Token: p.curToken, Value: n, Object: &object.BigInteger{Value: n}}, nil
+		}
+	}
 	if err != nil {
 		return nil, &ErrInteger{Err: err, Line: p.curToken.Line, TokenLiteral: p.curToken.Literal}
 	}
 
 	lit.Value = value
+	lit.Object = object.NativeInt(value)
+
+	return lit, nil
+}
+
+func (p *Parser) parseFloatLiteral() (ast.Expression, error) {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		return nil, &ErrFloat{Err: err, Line: p.curToken.Line, TokenLiteral: p.curToken.Literal}
+	}
+
+	lit.Value = value
+	lit.Object = &object.Float{Value: value}
+
+	return lit, nil
+}
+
+func (p *Parser) parseDurationLiteral() (ast.Expression, error) {
+	lit := &ast.DurationLiteral{Token: p.curToken}
+
+	value, err := parseDuration(p.curToken.Literal)
+	if err != nil {
+		return nil, &ErrDuration{Err: err, Line: p.curToken.Line, TokenLiteral: p.curToken.Literal}
+	}
+
+	lit.Value = value
+	lit.Object = &object.Duration{Value: value}
+
+	return lit, nil
+}
+
+func (p *Parser) parseDecimalLiteral() (ast.Expression, error) {
+	lit := &ast.DecimalLiteral{Token: p.curToken}
+
+	digits := strings.TrimSuffix(p.curToken.Literal, token.DecimalSuffix)
+	digits = strings.TrimSuffix(digits, "d")
+	value, ok := new(big.Rat).SetString(digits)
+	if !ok {
+		return nil, &ErrDecimal{Line: p.curToken.Line, TokenLiteral: p.curToken.Literal}
+	}
+
+	lit.Value = value
+	if dot := strings.IndexByte(digits, '.'); dot >= 0 {
+		lit.Scale = len(digits) - dot - 1
+	}
+	lit.Object = &object.Decimal{Value: value, Scale: lit.Scale}
 
 	return lit, nil
 }
 
+func (p *Parser) parseStringLiteral() (ast.Expression, error) {
+	value := p.curToken.Literal
+	return &ast.StringLiteral{Token: p.curToken, Value: value, Object: &object.String{Value: value}}, nil
+}
+
 func (p *Parser) parsePrefixExpression() (ast.Expression, error) {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -280,6 +420,80 @@ func (p *Parser) parseIfExpression() (ast.Expression, error) {
 	return expression, nil
 }
 
+func (p *Parser) parseTryExpression() (ast.Expression, error) {
+	expression := &ast.TryExpression{Token: p.curToken}
+
+	err := p.expectPeek(token.LBRACE)
+	if err != nil {
+		return nil, err
+	}
+
+	expression.Block, err = p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peekToken.Is(token.CATCH) {
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.expectPeek(token.LPAREN)
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.expectPeek(token.IDENT)
+		if err != nil {
+			return nil, err
+		}
+		expression.Param = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		err = p.expectPeek(token.RPAREN)
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.expectPeek(token.LBRACE)
+		if err != nil {
+			return nil, err
+		}
+
+		expression.Catch, err = p.parseBlockStatement()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peekToken.Is(token.FINALLY) {
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.expectPeek(token.LBRACE)
+		if err != nil {
+			return nil, err
+		}
+
+		expression.Finally, err = p.parseBlockStatement()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		return nil, &ErrUnexpectedToken{
+			Line:     p.peekToken.Line,
+			Expected: token.CATCH + " or " + token.FINALLY,
+			Got:      string(p.peekToken.Type),
+		}
+	}
+
+	return expression, nil
+}
+
 func (p *Parser) parseBlockStatement() (*ast.BlockStatement, error) {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
@@ -383,9 +597,13 @@ func (p *Parser) parseCallExpression(function ast.Expression) (ast.Expression, e
 }
 
 func (p *Parser) parseCallArguments() ([]ast.Expression, error) {
+	return p.parseExpressionList(token.RPAREN)
+}
+
+func (p *Parser) parseExpressionList(end token.TokenType) ([]ast.Expression, error) {
 	args := []ast.Expression{}
 
-	if p.peekToken.Is(token.RPAREN) {
+	if p.peekToken.Is(end) {
 		err := p.advance()
 		if err != nil {
 			return nil, err
@@ -419,10 +637,102 @@ func (p *Parser) parseCallArguments() ([]ast.Expression, error) {
 		args = append(args, arg)
 	}
 
-	err = p.expectPeek(token.RPAREN)
+	err = p.expectPeek(end)
 	if err != nil {
 		return nil, err
 	}
 
 	return args, nil
-}
\ No newline at end of file
+}
+
+func (p *Parser) parseArrayLiteral() (ast.Expression, error) {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+
+	elements, err := p.parseExpressionList(token.RBRACKET)
+	if err != nil {
+		return nil, err
+	}
+	array.Elements = elements
+
+	return array, nil
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) (ast.Expression, error) {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+	exp.Index, err = p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.expectPeek(token.RBRACKET)
+	if err != nil {
+		return nil, err
+	}
+
+	return exp, nil
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) (ast.Expression, error) {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+
+	err := p.expectPeek(token.IDENT)
+	if err != nil {
+		return nil, err
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp, nil
+}
+
+func (p *Parser) parseHashLiteral() (ast.Expression, error) {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Keys = []ast.Expression{}
+	hash.Values = []ast.Expression{}
+
+	for !p.peekToken.Is(token.RBRACE) {
+		err := p.advance()
+		if err != nil {
+			return nil, err
+		}
+		key, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.expectPeek(token.COLON)
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if !p.peekToken.Is(token.RBRACE) {
+			err = p.expectPeek(token.COMMA)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err := p.expectPeek(token.RBRACE)
+	if err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
@@ -105,6 +105,24 @@ func TestInteger(t *testing.T) {
 }
 
 
+func TestBigInteger(t *testing.T) {
+	input := "1111111111111111111111111111111111111111111"
+
+	program, err := New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	lit, ok := stmt.Expression.(*ast.BigIntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.BigIntegerLiteral. got=%T", stmt.Expression)
+	}
+	if lit.Value.String() != input {
+		t.Errorf("lit.Value not %s. got=%s", input, lit.Value)
+	}
+}
+
 func TestIdentifier(t *testing.T) {
 	testCases := []struct {
 		input         string
@@ -271,7 +289,17 @@ func TestErrorCase(t *testing.T) {
 		expectedError error
 	}{
 		{"@", &lexer.ErrUnexpectedChar{}},
-		{"1111111111111111111111111111111111111111111", &ErrInteger{}},
+		{"09", &ErrInteger{}},
+		{"try { 1 }", &ErrUnexpectedToken{}},
+		{"try { 1 } catch { 2 }", &ErrUnexpectedToken{}},
+		{"import lib", &ErrUnexpectedToken{}},
+		{"export 1", &ErrUnexpectedToken{}},
+		{`"abc`, &lexer.ErrUnterminatedString{}},
+		{"a.1", &ErrUnexpectedToken{}},
+		{"5x", &ErrDuration{}},
+		{"1h30", &ErrDuration{}},
+		{"99999999999999w", &ErrDuration{}},
+		{"{1 2}", &ErrUnexpectedToken{}},
 	}
 
 	for _, tc := range testCases {
@@ -288,4 +316,178 @@ func TestErrorCase(t *testing.T) {
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestTryExpression(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedParam   string
+		expectedCatch   bool
+		expectedFinally bool
+	}{
+		{"try { x } catch (e) { y }", "e", true, false},
+		{"try { x } finally { y }", "", false, true},
+		{"try { throw x; } catch (err) { y } finally { z }", "err", true, true},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program, err := p.ParseProgram()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+				1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+
+		exp, ok := stmt.Expression.(*ast.TryExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.TryExpression. got=%T", stmt.Expression)
+		}
+		if len(exp.Block.Statements) != 1 {
+			t.Errorf("try block is not 1 statement. got=%d", len(exp.Block.Statements))
+		}
+		if (exp.Catch != nil) != tc.expectedCatch {
+			t.Errorf("catch clause presence wrong. got=%v", exp.Catch)
+		}
+		if tc.expectedCatch && !testIdentifier(t, exp.Param, tc.expectedParam) {
+			return
+		}
+		if (exp.Finally != nil) != tc.expectedFinally {
+			t.Errorf("finally clause presence wrong. got=%v", exp.Finally)
+		}
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	l := lexer.New("throw 5;")
+	p := New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ThrowStatement. got=%T",
+			program.Statements[0])
+	}
+	testLiteralExpression(t, stmt.Value, 5)
+}
+
+func TestImportExportStatements(t *testing.T) {
+	l := lexer.New(`import "pricing/tiers"; export let x = 5;`)
+	p := New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			2, len(program.Statements))
+	}
+
+	imp, ok := program.Statements[0].(*ast.ImportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ImportStatement. got=%T",
+			program.Statements[0])
+	}
+	if imp.Path.Value != "pricing/tiers" {
+		t.Errorf("imp.Path.Value not %q. got=%q", "pricing/tiers", imp.Path.Value)
+	}
+
+	exp, ok := program.Statements[1].(*ast.ExportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not ast.ExportStatement. got=%T",
+			program.Statements[1])
+	}
+	testIdentifier(t, exp.Statement.Name, "x")
+	testLiteralExpression(t, exp.Statement.Value, 5)
+}
+
+func TestMemberExpression(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"math.max(a, b)", "math.max(a, b)"},
+		{"user.age + 1", "(user.age + 1)"},
+		{"-user.age", "(-user.age)"},
+		{"a.b.c * 2", "(a.b.c * 2)"},
+		{"f(x).y", "f(x).y"},
+		{`{"age": 1 + 2, true: x}.age`, `{"age": (1 + 2), true: x}.age`},
+		{"a * [1, 2][b * c] * d", "((a * ([1, 2][(b * c)])) * d)"},
+		{"add(a[1], b.c[2])", "add((a[1]), (b.c[2]))"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program, err := p.ParseProgram()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if program.String() != tc.expected {
+			t.Errorf("expected=%q, got=%q", tc.expected, program.String())
+		}
+	}
+}
+
+func TestLogicalExpression(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"a && b", "(a && b)"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c", "((a && b) || c)"},
+		{"a == 1 && !b", "((a == 1) && (!b))"},
+		{"a || b || c", "((a || b) || c)"},
+		{"(a || b) && c.d > 2", "((a || b) && (c.d > 2))"},
+	}
+
+	for _, tc := range testCases {
+		program, err := New(lexer.New(tc.input)).ParseProgram()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if program.String() != tc.expected {
+			t.Errorf("expected=%q, got=%q", tc.expected, program.String())
+		}
+	}
+}
+
+func TestHashLiteral(t *testing.T) {
+	l := lexer.New(`{"one": 1, "two": 2}`)
+	p := New(l)
+	program, err := p.ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+	if len(hash.Keys) != 2 || len(hash.Values) != 2 {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Keys))
+	}
+	testLiteralExpression(t, hash.Values[0], 1)
+	testLiteralExpression(t, hash.Values[1], 2)
+}
@@ -0,0 +1,52 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/NilCent/eval/ast"
+)
+
+// dump prints the tree rooted at node, a node per line indented by its
+// depth.
+func dump(out io.Writer, node ast.Node, depth int) {
+	fmt.Fprintf(out, "%s%s\n", strings.Repeat("  ", depth), label(node))
+	for _, child := range children(node) {
+		dump(out, child, depth+1)
+	}
+}
+
+// label returns the type of node, followed by its name, value or
+// operator for the nodes having one.
+func label(node ast.Node) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+
+	switch node := node.(type) {
+	case *ast.Identifier:
+		return name + " " + node.Value
+	case *ast.StringLiteral:
+		return name + " " + strconv.Quote(node.Value)
+	case *ast.IntegerLiteral, *ast.BigIntegerLiteral, *ast.FloatLiteral,
+		*ast.DecimalLiteral, *ast.DurationLiteral, *ast.Boolean:
+		return name + " " + node.TokenLiteral()
+	case *ast.PrefixExpression:
+		return name + " " + node.Operator
+	case *ast.InfixExpression:
+		return name + " " + node.Operator
+	}
+	return name
+}
+
+func children(node ast.Node) []ast.Node {
+	var nodes []ast.Node
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == node {
+			return true
+		}
+		nodes = append(nodes, n)
+		return false
+	})
+	return nodes
+}
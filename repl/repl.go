@@ -0,0 +1,215 @@
+// Package repl implements an interactive session evaluating the lines
+// read from a terminal in a single interpreter, so that each entry sees
+// the variables bound by the previous ones.
+//
+// An entry whose braces, brackets or parentheses are not closed, or
+// whose string is not terminated, continues on the next line; an empty
+// line ends it anyway. Lines starting with a colon are commands:
+//
+//	:env            list the variables of the session
+//	:ast <expr>     print the syntax tree of expr
+//	:tokens <expr>  print the tokens of expr
+//	:load <file>    evaluate a file in the session
+//	:reset          start a new session, keeping the -var bindings
+//	:history        list the entries read so far
+//	:help           list the commands
+//	:quit           end the session
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/NilCent/eval"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/token"
+)
+
+const (
+	prompt       = ">> "
+	continuation = ".. "
+)
+
+// interpreter is the part of the interpreter of package eval used by
+// a session.
+type interpreter interface {
+	Eval(input string) (object.Object, error)
+	Env() *object.Environment
+	Set(name string, val object.Object)
+}
+
+type REPL struct {
+	opts    []eval.Option
+	interp  interpreter
+	vars    map[string]object.Object
+	history []string
+}
+
+// New returns a REPL whose sessions use interpreters built with opts.
+func New(opts ...eval.Option) *REPL {
+	return &REPL{opts: opts, interp: eval.New(opts...), vars: make(map[string]object.Object)}
+}
+
+// Set binds name to val in the session and in those started by
+// :reset.
+func (r *REPL) Set(name string, val object.Object) {
+	r.vars[name] = val
+	r.interp.Set(name, val)
+}
+
+// History returns the entries read so far, commands included.
+func (r *REPL) History() []string {
+	return r.history
+}
+
+// Run reads entries from in and writes their results to out until in
+// ends or :quit is entered.
+func (r *REPL) Run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	var entry []string
+
+	fmt.Fprint(out, prompt)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if len(entry) == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			command := strings.TrimSpace(line)
+			r.history = append(r.history, command)
+			if !r.command(command, out) {
+				return nil
+			}
+			fmt.Fprint(out, prompt)
+			continue
+		}
+
+		entry = append(entry, line)
+		src := strings.Join(entry, "\n")
+		if line != "" && incomplete(src) {
+			fmt.Fprint(out, continuation)
+			continue
+		}
+		entry = nil
+
+		if strings.TrimSpace(src) != "" {
+			r.history = append(r.history, src)
+			r.eval(src, out)
+		}
+		fmt.Fprint(out, prompt)
+	}
+
+	return scanner.Err()
+}
+
+func (r *REPL) eval(src string, out io.Writer) {
+	result, err := r.interp.Eval(src)
+	if err != nil {
+		fmt.Fprintln(out, describe(err))
+		return
+	}
+	if result != nil {
+		fmt.Fprintln(out, result.Inspect())
+	}
+}
+
+// describe returns the message of err, prefixed with its line.
+func describe(err error) string {
+	var runtimeErr *eval.ErrRuntime
+	if errors.As(err, &runtimeErr) && runtimeErr.Line > 0 {
+		return fmt.Sprintf("Line %d %s", runtimeErr.Line, runtimeErr.Message)
+	} else if runtimeErr != nil {
+		return runtimeErr.Message
+	}
+	return err.Error()
+}
+
+// command runs a command line, reporting whether the session goes on.
+func (r *REPL) command(line string, out io.Writer) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ":quit", ":q":
+		return false
+	case ":help":
+		fmt.Fprintln(out, ":env            list the variables of the session")
+		fmt.Fprintln(out, ":ast <expr>     print the syntax tree of expr")
+		fmt.Fprintln(out, ":tokens <expr>  print the tokens of expr")
+		fmt.Fprintln(out, ":load <file>    evaluate a file in the session")
+		fmt.Fprintln(out, ":reset          start a new session")
+		fmt.Fprintln(out, ":history        list the entries read so far")
+		fmt.Fprintln(out, ":quit           end the session")
+	case ":env":
+		env := r.interp.Env()
+		for _, name := range env.Names() {
+			val, _ := env.Get(name)
+			fmt.Fprintf(out, "%s = %s\n", name, val.Inspect())
+		}
+	case ":ast":
+		program, err := parser.New(lexer.New(arg)).ParseProgram()
+		if err != nil {
+			fmt.Fprintln(out, err)
+			break
+		}
+		dump(out, program, 0)
+	case ":tokens":
+		l := lexer.New(arg)
+		for {
+			tok, err := l.NextToken()
+			if err != nil {
+				fmt.Fprintln(out, err)
+				break
+			}
+			if tok.Type == token.EOF {
+				break
+			}
+			fmt.Fprintf(out, "%d\t%s\t%s\n", tok.Line, tok.Type, tok.Literal)
+		}
+	case ":load":
+		src, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			break
+		}
+		r.eval(string(src), out)
+	case ":reset":
+		r.interp = eval.New(r.opts...)
+		for name, val := range r.vars {
+			r.interp.Set(name, val)
+		}
+	case ":history":
+		for i, entry := range r.history {
+			fmt.Fprintf(out, "%d\t%s\n", i+1, strings.ReplaceAll(entry, "\n", "\n\t"))
+		}
+	default:
+		fmt.Fprintf(out, "unknown command %s, see :help\n", name)
+	}
+	return true
+}
+
+// incomplete reports whether src opens more braces, brackets or
+// parentheses than it closes, or ends in a string.
+func incomplete(src string) bool {
+	l := lexer.New(src)
+	depth := 0
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			var unterminated *lexer.ErrUnterminatedString
+			return errors.As(err, &unterminated)
+		}
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
+		case token.EOF:
+			return depth > 0
+		}
+	}
+}
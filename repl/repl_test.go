@@ -0,0 +1,109 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NilCent/eval/object"
+)
+
+func run(t *testing.T, r *REPL, input string) string {
+	var out bytes.Buffer
+	if err := r.Run(strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestSession(t *testing.T) {
+	input := "let x = 2\nx * 21\nlet f = fn(n) {\n  n + x\n}\nf(1)\nx / 0\nlet = 1\n"
+	expected := ">> >> 42\n>> .. .. >> 3\n>> Line 1 division by zero\n>> " +
+		"Line 1 Unexpected Token: expected IDENT, got =\n>> "
+
+	if got := run(t, New(), input); got != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, got)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 2", false},
+		{"let f = fn(x) {", true},
+		{"f(1,", true},
+		{"[1, [2]", true},
+		{"if (a) { b } else {\n c", true},
+		{"if (a) { b } else {\n c }", false},
+		{"\"open", true},
+		{"\"a { b\"", false},
+		{"1 }", false},
+		{"let = )", false},
+	}
+
+	for _, tc := range testCases {
+		if got := incomplete(tc.input); got != tc.expected {
+			t.Errorf("%q: expected %t, got %t", tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestEmptyLineEndsEntry(t *testing.T) {
+	expected := ">> .. Line 2 Unrecognized Token: no prefix parse function for EOF\n>> 1\n>> "
+	if got := run(t, New(), "f(1,\n\n1\n"); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rates.eval")
+	if err := os.WriteFile(path, []byte("let rate = 3;\nrate * base"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.Set("base", &object.Integer{Value: 5})
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{":load " + path, "15\n"},
+		{":env", "base = 5\nrate = 3\n"},
+		{":ast -a + f(1)", "Program\n  ExpressionStatement\n    InfixExpression +\n" +
+			"      PrefixExpression -\n        Identifier a\n" +
+			"      CallExpression\n        Identifier f\n        IntegerLiteral 1\n"},
+		{":tokens let s = \"x\";", "1\tLET\tlet\n1\tIDENT\ts\n1\t=\t=\n1\tSTRING\tx\n1\t;\t;\n"},
+		{":tokens 1 @", "1\tINT\t1\nLine 1 Unexpected character: @\n"},
+		{":ast let", "Line 1 Unexpected Token: expected IDENT, got EOF\n"},
+		{":load " + filepath.Join(dir, "none.eval"), "open " + filepath.Join(dir, "none.eval") + ": no such file or directory\n"},
+		{":reset", ""},
+		{":env", "base = 5\n"},
+		{":nope", "unknown command :nope, see :help\n"},
+	}
+
+	for _, tc := range testCases {
+		got := run(t, r, tc.input+"\n")
+		expected := ">> " + tc.expected + ">> "
+		if got != expected {
+			t.Errorf("%s: expected\n%q\ngot\n%q", tc.input, expected, got)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	r := New()
+	got := run(t, r, "1\nlet f = fn() {\n2 }\n:env\n:history\n:quit\n3\n")
+	expected := ">> 1\n>> .. >> f = fn() {\n2\n}\n>> 1\t1\n2\tlet f = fn() {\n\t2 }\n3\t:env\n4\t:history\n>> "
+	if got != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, got)
+	}
+	if n := len(r.History()); n != 5 {
+		t.Errorf("expected 5 entries, got %d", n)
+	}
+}
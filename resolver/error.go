@@ -0,0 +1,12 @@
+package resolver
+
+import "fmt"
+
+type ErrUndefined struct {
+	Name string
+	Line int
+}
+
+func (e *ErrUndefined) Error() string {
+	return fmt.Sprintf("Line %d Undefined identifier: %s", e.Line, e.Name)
+}
@@ -0,0 +1,134 @@
+package resolver
+
+import (
+	"sort"
+
+	"github.com/NilCent/eval/ast"
+)
+
+// FreeVariable is a variable a program reads without binding it, such
+// as an input of a rule or a builtin, which the host has to provide.
+type FreeVariable struct {
+	Name       string
+	References []Reference
+}
+
+// Reference is an occurrence of a free variable.
+type Reference struct {
+	Line int
+	// Call is set when the variable is called, as in name(...), rather
+	// than read as a value.
+	Call bool
+}
+
+// Called reports whether v is called at least once.
+func (v FreeVariable) Called() bool {
+	for _, ref := range v.References {
+		if ref.Call {
+			return true
+		}
+	}
+	return false
+}
+
+// Read reports whether v is used at least once as a plain value.
+func (v FreeVariable) Read() bool {
+	for _, ref := range v.References {
+		if !ref.Call {
+			return true
+		}
+	}
+	return false
+}
+
+// Free returns the free variables of program, those bound neither by a
+// let statement, an import alias, a function parameter nor a catch
+// parameter in scope, sorted by name. The references of each are in
+// source order. Unlike Resolve, Free leaves program untouched.
+//
+// A let statement or an import alias binds its name from there on, so
+// that the price of let price = price * 2 is free. Function literals
+// being called later, their code sees all the variables of the
+// enclosing scopes.
+//
+// Names bound by an import without alias are only known at run time, so
+// the variables within the reach of one are reported, although the
+// module may provide them.
+func Free(program *ast.Program) []FreeVariable {
+	f := &freeVariables{
+		found:     make(map[string]*FreeVariable),
+		bound:     make(map[*scope]map[string]bool),
+		functions: make(map[*scope]bool),
+	}
+	Walk(program, f)
+
+	vars := make([]FreeVariable, 0, len(f.found))
+	for _, v := range f.found {
+		vars = append(vars, *v)
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
+
+type freeVariables struct {
+	found map[string]*FreeVariable
+	// scope is the innermost scope entered.
+	scope *scope
+	// bound holds the variables of each scope whose declaration has
+	// been passed.
+	bound map[*scope]map[string]bool
+	// functions holds the scopes of function literals.
+	functions map[*scope]bool
+}
+
+func (f *freeVariables) Enter(node ast.Node, params []*ast.Identifier) {
+	f.scope = newScope(f.scope)
+	if _, ok := node.(*ast.FunctionLiteral); ok {
+		f.functions[f.scope] = true
+	}
+	for _, param := range params {
+		f.scope.define(param.Value)
+		f.bind(param.Value)
+	}
+}
+
+func (f *freeVariables) Declare(decl ast.Statement, name *ast.Identifier) {
+	if name != nil {
+		f.scope.define(name.Value)
+	}
+}
+
+func (f *freeVariables) Bind(decl ast.Statement, name *ast.Identifier) {
+	f.bind(name.Value)
+}
+
+func (f *freeVariables) bind(name string) {
+	if f.bound[f.scope] == nil {
+		f.bound[f.scope] = make(map[string]bool)
+	}
+	f.bound[f.scope][name] = true
+}
+
+func (f *freeVariables) Leave(node ast.Node) {
+	f.scope = f.scope.outer
+}
+
+// Read records a reference to ident unless a scope binds it.
+func (f *freeVariables) Read(ident *ast.Identifier, call bool) {
+	// the scopes enclosing a function literal have all their variables
+	// by the time it is called
+	later := false
+	for scope := f.scope; scope != nil; scope = scope.outer {
+		if f.bound[scope][ident.Value] || later && scope.has(ident.Value) {
+			return
+		}
+		later = later || f.functions[scope]
+	}
+
+	v, ok := f.found[ident.Value]
+	if !ok {
+		v = &FreeVariable{Name: ident.Value}
+		f.found[ident.Value] = v
+	}
+	v.References = append(v.References, Reference{Line: ident.Token.Line, Call: call})
+}
@@ -0,0 +1,94 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFree(t *testing.T) {
+	// each variable is listed with the lines of its references, those
+	// calling it marked with ()
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"price * qty", "price 1; qty 1"},
+		{"discount(price, 2)", "discount 1(); price 1"},
+		{"let total = price;\nmax(total, price)", "max 2(); price 1 2"},
+		{"len(tags) + len", "len 1() 1; tags 1"},
+		{"let f = fn(x) { x + rate }; f(1)", "rate 1"},
+		{"fn(a) { fn(b) { a + b + c } }", "c 1"},
+		{"fn() { if (ok) { let y = 1; } y }", "ok 1"},
+		{"try { risky() } catch (e) { e.message + fallback }", "fallback 1; risky 1()"},
+		{"try { 1 } catch (e) { 2 }; e", "e 1"},
+		{"customer.address.city", "customer 1"},
+		{"lookup(key)(value)", "key 1; lookup 1(); value 1"},
+		{"fn() { let y = 1 }; y", "y 1"},
+		// bound once the let statement has run
+		{"let price = price * 2; price", "price 1"},
+		{"total;\nlet total = 1;\ntotal", "total 1"},
+		{"fn() { y; let y = 1; y }", "y 1"},
+		{"try { e } catch (e) { let e = e + 1; e }", "e 1"},
+		// bound later in the program, or by an import alias
+		{"let f = fn() { g() }; let g = fn() { 1 };", ""},
+		{"import \"m\" as m; m.x(y)", "y 1"},
+		{"import \"m\"; helper(1)", "helper 1()"},
+		{"let a = 1;\nlet b = a + c;\nif (d) {\n  e(b)\n}", "c 2; d 3; e 4()"},
+	}
+
+	for _, tc := range testCases {
+		vars := Free(parse(t, tc.input))
+
+		var got []string
+		for _, v := range vars {
+			refs := []string{v.Name}
+			for _, ref := range v.References {
+				if ref.Call {
+					refs = append(refs, fmt.Sprintf("%d()", ref.Line))
+				} else {
+					refs = append(refs, fmt.Sprintf("%d", ref.Line))
+				}
+			}
+			got = append(got, strings.Join(refs, " "))
+		}
+		if s := strings.Join(got, "; "); s != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.input, tc.expected, s)
+		}
+	}
+}
+
+func TestFreeUsage(t *testing.T) {
+	vars := Free(parse(t, "len(tags) + len + max(1)"))
+	if len(vars) != 3 {
+		t.Fatalf("expected 3 free variables, got %d", len(vars))
+	}
+
+	testCases := []struct {
+		name         string
+		called, read bool
+	}{
+		{"len", true, true},
+		{"max", true, false},
+		{"tags", false, true},
+	}
+
+	for i, tc := range testCases {
+		v := vars[i]
+		if v.Name != tc.name || v.Called() != tc.called || v.Read() != tc.read {
+			t.Errorf("expected %s called=%t read=%t, got %s called=%t read=%t",
+				tc.name, tc.called, tc.read, v.Name, v.Called(), v.Read())
+		}
+	}
+}
+
+func TestFreeUntouched(t *testing.T) {
+	program := parse(t, "fn(a) { a + b }")
+	Free(program)
+
+	for _, ident := range identifiers(program, "a") {
+		if ident.Local {
+			t.Errorf("Free annotated %s", ident.Value)
+		}
+	}
+}
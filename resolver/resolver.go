@@ -0,0 +1,89 @@
+// Package resolver binds the identifiers of a program to the variables
+// they denote ahead of evaluation, so that the evaluator reads local
+// variables by index and undefined variables are reported before the
+// program runs. It also lists the free variables of a program, which
+// the host has to provide.
+package resolver
+
+import "github.com/NilCent/eval/ast"
+
+// Resolve annotates the identifiers of program denoting variables of a
+// function call or a catch clause with their slot, and the function
+// literals and catch clauses with the names of their slots. defined
+// tells whether a global variable not declared by program, such as a
+// builtin or a host value, exists; it may be nil.
+//
+// Resolve returns an *ErrUndefined for the first identifier naming no
+// variable. Names bound by an import without alias are only known at
+// run time, so no identifier within the reach of one is reported.
+func Resolve(program *ast.Program, defined func(name string) bool) error {
+	r := &resolver{defined: defined}
+	Walk(program, r)
+	return r.err
+}
+
+type resolver struct {
+	defined func(name string) bool
+	global  *scope
+	// scope is the innermost scope entered.
+	scope *scope
+	err   error
+}
+
+func (r *resolver) Enter(node ast.Node, params []*ast.Identifier) {
+	r.scope = newScope(r.scope)
+	if r.global == nil {
+		r.global = r.scope
+	}
+	for _, param := range params {
+		r.scope.define(param.Value)
+		r.scope.bind(param)
+	}
+}
+
+func (r *resolver) Declare(decl ast.Statement, name *ast.Identifier) {
+	if name == nil {
+		r.scope.imports = true
+		return
+	}
+	r.scope.define(name.Value)
+}
+
+func (r *resolver) Bind(decl ast.Statement, name *ast.Identifier) {
+	r.scope.bind(name)
+}
+
+func (r *resolver) Read(ident *ast.Identifier, call bool) {
+	r.identifier(ident, r.scope)
+}
+
+func (r *resolver) Leave(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.FunctionLiteral:
+		node.Slots = r.scope.names
+	case *ast.TryExpression:
+		node.CatchSlots = r.scope.names
+	}
+	r.scope = r.scope.outer
+}
+
+func (r *resolver) identifier(ident *ast.Identifier, s *scope) {
+	ident.Local, ident.Depth, ident.Slot = false, 0, 0
+
+	depth := 0
+	for scope := s; !scope.global(); scope = scope.outer {
+		if slot, ok := scope.slots[ident.Value]; ok {
+			ident.Local, ident.Depth, ident.Slot = true, depth, slot
+			return
+		}
+		depth++
+	}
+
+	if r.err != nil || r.global.has(ident.Value) || s.dynamic() {
+		return
+	}
+	if r.defined != nil && r.defined(ident.Value) {
+		return
+	}
+	r.err = &ErrUndefined{Name: ident.Value, Line: ident.Token.Line}
+}
@@ -0,0 +1,124 @@
+package resolver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	return program
+}
+
+// identifiers returns the identifiers of program named name, in source
+// order.
+func identifiers(program *ast.Program, name string) []*ast.Identifier {
+	var found []*ast.Identifier
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Value == name {
+			found = append(found, ident)
+		}
+		return true
+	})
+	return found
+}
+
+func TestSlots(t *testing.T) {
+	type binding struct {
+		local       bool
+		depth, slot int
+	}
+	testCases := []struct {
+		input    string
+		name     string
+		expected []binding
+	}{
+		{"let x = 1; x", "x", []binding{{false, 0, 0}, {false, 0, 0}}},
+		{"fn(a, b) { b }", "b", []binding{{true, 0, 1}, {true, 0, 1}}},
+		{"fn(a) { if (a) { let y = a; y } }", "y", []binding{{true, 0, 1}, {true, 0, 1}}},
+		{"fn(a) { fn(b) { fn() { a } } }", "a", []binding{{true, 0, 0}, {true, 2, 0}}},
+		{"fn(a) { try { a } catch (e) { let z = e; a } }", "a", []binding{{true, 0, 0}, {true, 0, 0}, {true, 1, 0}}},
+		{"fn() { try { 1 } catch (e) { let z = e; z } }", "z", []binding{{true, 0, 1}, {true, 0, 1}}},
+		{"fn() { try { let t = 1 } finally { t } }", "t", []binding{{true, 0, 0}, {true, 0, 0}}},
+		{"fn() { import \"m\" as m; m.x }", "m", []binding{{true, 0, 0}, {true, 0, 0}}},
+		{"let g = 1; fn(x) { g + x }", "g", []binding{{false, 0, 0}, {false, 0, 0}}},
+		{"fn(x) { x.x }", "x", []binding{{true, 0, 0}, {true, 0, 0}, {false, 0, 0}}},
+	}
+
+	for _, tc := range testCases {
+		program := parse(t, tc.input)
+		if err := Resolve(program, nil); err != nil {
+			t.Errorf("%s: %v", tc.input, err)
+			continue
+		}
+
+		idents := identifiers(program, tc.name)
+		if len(idents) != len(tc.expected) {
+			t.Errorf("%s: found %d identifiers, want %d", tc.input, len(idents), len(tc.expected))
+			continue
+		}
+		for i, ident := range idents {
+			got := binding{ident.Local, ident.Depth, ident.Slot}
+			if got != tc.expected[i] {
+				t.Errorf("%s: %s #%d resolved to %+v, want %+v", tc.input, tc.name, i, got, tc.expected[i])
+			}
+		}
+	}
+}
+
+func TestScopeNames(t *testing.T) {
+	program := parse(t, "fn(a, b) { let c = 1; try { let d = 2 } catch (e) { let f = 3 }; fn(g) { g } }")
+	if err := Resolve(program, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if got := strings.Join(fn.Slots, " "); got != "a b c d" {
+		t.Errorf("wrong function slots. got=%q", got)
+	}
+
+	try := fn.Body.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.TryExpression)
+	if got := strings.Join(try.CatchSlots, " "); got != "e f" {
+		t.Errorf("wrong catch slots. got=%q", got)
+	}
+}
+
+func TestUndefined(t *testing.T) {
+	defined := func(name string) bool { return name == "len" }
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"len(x)", "Line 1 Undefined identifier: x"},
+		{"let f = fn() {\n  g()\n};", "Line 2 Undefined identifier: g"},
+		{"if (false) { a } else { b }", "Line 1 Undefined identifier: a"},
+		{"try { 1 } catch (e) { 2 }; e", "Line 1 Undefined identifier: e"},
+		{"fn() { let y = 1 }; y", "Line 1 Undefined identifier: y"},
+		{"x.len", "Line 1 Undefined identifier: x"},
+		// declared later in the program, or bound by an import
+		{"let f = fn() { g() }; let g = fn() { 1 };", ""},
+		{"len(\"a\")", ""},
+		{"import \"m\"; x", ""},
+		{"fn() { import \"m\"; x }", ""},
+		{"fn() { import \"m\" }; x", "Line 1 Undefined identifier: x"},
+	}
+
+	for _, tc := range testCases {
+		err := Resolve(parse(t, tc.input), defined)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.input, tc.expected, got)
+		}
+	}
+}
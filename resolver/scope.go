@@ -0,0 +1,58 @@
+package resolver
+
+import "github.com/NilCent/eval/ast"
+
+// scope tracks the variables of a function call or a catch clause,
+// which the evaluator stores in the slots of an environment. The global
+// scope has no slots: its variables are looked up by name.
+type scope struct {
+	outer *scope
+	slots map[string]int
+	names []string
+	// imports is set when an import without alias binds names in the
+	// scope at run time.
+	imports bool
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, slots: make(map[string]int), names: []string{}}
+}
+
+func (s *scope) global() bool {
+	return s.outer == nil
+}
+
+func (s *scope) define(name string) int {
+	if slot, ok := s.slots[name]; ok {
+		return slot
+	}
+	slot := len(s.names)
+	s.slots[name] = slot
+	s.names = append(s.names, name)
+	return slot
+}
+
+func (s *scope) has(name string) bool {
+	_, ok := s.slots[name]
+	return ok
+}
+
+// bind annotates the identifier declaring a variable of s.
+func (s *scope) bind(ident *ast.Identifier) {
+	if s.global() {
+		ident.Local, ident.Depth, ident.Slot = false, 0, 0
+		return
+	}
+	ident.Local, ident.Depth, ident.Slot = true, 0, s.slots[ident.Value]
+}
+
+// dynamic reports whether an import without alias may bind names in s
+// or an enclosing scope.
+func (s *scope) dynamic() bool {
+	for ; s != nil; s = s.outer {
+		if s.imports {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package resolver
+
+import "github.com/NilCent/eval/ast"
+
+// Visitor receives the scopes of a program and the variables they bind
+// and read from Walk.
+type Visitor interface {
+	// Enter is called on entering the scope of the program, of a
+	// function literal or of the catch clause of a try expression, with
+	// the parameters the function or the clause binds.
+	Enter(node ast.Node, params []*ast.Identifier)
+	// Declare is called after Enter for each declaration of the scope,
+	// as reported by Declarations.
+	Declare(decl ast.Statement, name *ast.Identifier)
+	// Bind is called once the let, export or import statement decl has
+	// bound name: after the value of a let statement is computed.
+	Bind(decl ast.Statement, name *ast.Identifier)
+	// Read is called for each identifier naming a variable rather than
+	// declaring it. call is set when the variable is called, as in
+	// name(...).
+	Read(ident *ast.Identifier, call bool)
+	// Leave is called on leaving the scope node entered.
+	Leave(node ast.Node)
+}
+
+// Walk reports the scopes of program to v in source order, mirroring
+// the environments of the evaluator.
+func Walk(program *ast.Program, v Visitor) {
+	enter(program, program, nil, v)
+}
+
+func enter(node ast.Node, body ast.Node, params []*ast.Identifier, v Visitor) {
+	v.Enter(node, params)
+	Declarations(body, v.Declare)
+	walk(body, v)
+	v.Leave(node)
+}
+
+func walk(node ast.Node, v Visitor) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			v.Read(node, false)
+		case *ast.CallExpression:
+			if ident, ok := node.Function.(*ast.Identifier); ok {
+				v.Read(ident, true)
+			} else {
+				walk(node.Function, v)
+			}
+			for _, arg := range node.Arguments {
+				walk(arg, v)
+			}
+			return false
+		case *ast.ExportStatement:
+			walk(node.Statement.Value, v)
+			v.Bind(node, node.Statement.Name)
+			return false
+		case *ast.LetStatement:
+			walk(node.Value, v)
+			v.Bind(node, node.Name)
+			return false
+		case *ast.ImportStatement:
+			if node.Alias != nil {
+				v.Bind(node, node.Alias)
+			}
+			return false
+		case *ast.MemberExpression:
+			// the property is a name, not a variable
+			walk(node.Object, v)
+			return false
+		case *ast.FunctionLiteral:
+			enter(node, node.Body, node.Parameters, v)
+			return false
+		case *ast.TryExpression:
+			walk(node.Block, v)
+			if node.Catch != nil {
+				enter(node, node.Catch, []*ast.Identifier{node.Param}, v)
+			}
+			if node.Finally != nil {
+				walk(node.Finally, v)
+			}
+			return false
+		}
+		return true
+	})
+}
+
+// Declarations calls declare, in source order, for the let, export and
+// import statements of node binding a variable in the scope holding
+// node. The evaluator binds them in the environment of the enclosing
+// call wherever they appear, blocks having none of their own, while
+// function literals and catch clauses have scopes of their own. name is
+// nil for an import without alias, which binds names only known at run
+// time.
+func Declarations(node ast.Node, declare func(decl ast.Statement, name *ast.Identifier)) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.TryExpression:
+			Declarations(node.Block, declare)
+			if node.Finally != nil {
+				Declarations(node.Finally, declare)
+			}
+			return false
+		case *ast.ExportStatement:
+			declare(node, node.Statement.Name)
+			Declarations(node.Statement.Value, declare)
+			return false
+		case *ast.LetStatement:
+			declare(node, node.Name)
+		case *ast.ImportStatement:
+			declare(node, node.Alias)
+		}
+		return true
+	})
+}
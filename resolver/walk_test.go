@@ -0,0 +1,66 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/NilCent/eval/ast"
+)
+
+// events records what Walk reports, one word per call.
+type events []string
+
+func (e *events) Enter(node ast.Node, params []*ast.Identifier) {
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.Value
+	}
+	*e = append(*e, fmt.Sprintf("enter(%s)", strings.Join(names, ",")))
+}
+
+func (e *events) Declare(decl ast.Statement, name *ast.Identifier) {
+	if name == nil {
+		*e = append(*e, "declare(*)")
+		return
+	}
+	*e = append(*e, "declare("+name.Value+")")
+}
+
+func (e *events) Bind(decl ast.Statement, name *ast.Identifier) {
+	*e = append(*e, "bind("+name.Value+")")
+}
+
+func (e *events) Read(ident *ast.Identifier, call bool) {
+	if call {
+		*e = append(*e, ident.Value+"()")
+		return
+	}
+	*e = append(*e, ident.Value)
+}
+
+func (e *events) Leave(node ast.Node) {
+	*e = append(*e, "leave")
+}
+
+func TestWalk(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = a + 1; a.b", "enter() declare(a) a bind(a) a leave"},
+		{"if (c) { let x = f(1) }", "enter() declare(x) c f() bind(x) leave"},
+		{"let f = fn(x) { let y = x; g(y)(z) }", "enter() declare(f) enter(x) declare(y) x bind(y) g() y z leave bind(f) leave"},
+		{"try { let a = 1 } catch (e) { let b = e } finally { c }",
+			"enter() declare(a) bind(a) enter(e) declare(b) e bind(b) leave c leave"},
+		{`import "m" as m; import "n"; export let v = m.v`, "enter() declare(m) declare(*) declare(v) bind(m) m bind(v) leave"},
+	}
+
+	for _, tc := range testCases {
+		var got events
+		Walk(parse(t, tc.input), &got)
+		if s := strings.Join(got, " "); s != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.input, tc.expected, s)
+		}
+	}
+}
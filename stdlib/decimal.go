@@ -0,0 +1,109 @@
+package stdlib
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/NilCent/eval/object"
+)
+
+// Decimal installs decimal(x), converting integers, floats and strings
+// to exact decimals, and int(x), truncating numbers toward zero.
+var Decimal = Library{
+	Name: "decimal",
+	Load: func(rt *object.Runtime) map[string]object.Object {
+		return map[string]object.Object{
+			"decimal": &object.Builtin{Name: "decimal", Fn: decimalDecimal},
+			"int":     &object.Builtin{Name: "int", Fn: decimalInt},
+		}
+	},
+}
+
+func decimalDecimal(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("decimal", len(args), "1")
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Decimal:
+		return arg
+	case *object.Integer:
+		return &object.Decimal{Value: new(big.Rat).SetInt64(arg.Value)}
+	case *object.BigInteger:
+		return &object.Decimal{Value: new(big.Rat).SetInt(arg.Value)}
+	case *object.Float:
+		if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+			return newError("decimal: cannot convert %s", arg.Inspect())
+		}
+		return parseDecimal(strconv.FormatFloat(arg.Value, 'f', -1, 64))
+	case *object.String:
+		return parseDecimal(arg.Value)
+	}
+	return wrongType("decimal", 0, args[0])
+}
+
+// parseDecimal parses digits with an optional sign and fraction.
+func parseDecimal(s string) object.Object {
+	digits := strings.TrimLeft(s, "+-")
+	valid := digits != "" && len(s)-len(digits) <= 1 && strings.Count(digits, ".") <= 1
+	for _, ch := range digits {
+		valid = valid && (ch == '.' || ch >= '0' && ch <= '9')
+	}
+
+	value, ok := new(big.Rat).SetString(s)
+	if !valid || !ok {
+		return newError("decimal: cannot parse %q", s)
+	}
+
+	scale := 0
+	if dot := strings.IndexByte(digits, '.'); dot >= 0 {
+		scale = len(digits) - dot - 1
+	}
+	return &object.Decimal{Value: value, Scale: scale}
+}
+
+func decimalInt(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("int", len(args), "1")
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer, *object.BigInteger:
+		return arg
+	case *object.Float:
+		return roundToInteger("int", arg, math.Trunc)
+	case *object.Decimal:
+		return ratToInteger(arg.Value, "down")
+	case *object.Rational:
+		return ratToInteger(arg.Value, "down")
+	}
+	return wrongType("int", 0, args[0])
+}
+
+// ratToInteger rounds r to an integer: "floor", "ceil", "down" toward
+// zero, or to the nearest with ties broken by "half-up" or "half-even".
+func ratToInteger(r *big.Rat, mode string) object.Object {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+
+	switch mode {
+	case "floor":
+		if m.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		}
+	case "ceil":
+		if m.Sign() > 0 {
+			q.Add(q, big.NewInt(1))
+		}
+	case "half-up", "half-even":
+		rounding := object.RoundHalfUp
+		if mode == "half-even" {
+			rounding = object.RoundHalfEven
+		}
+		ctx := object.DecimalContext{Rounding: rounding}
+		q = ctx.Round(r, 0).Value.Num()
+	}
+
+	return object.NewInteger(q)
+}
@@ -0,0 +1,41 @@
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/NilCent/eval/object"
+)
+
+// Library is a set of builtins installed in an interpreter's runtime.
+// Load is called once per interpreter, so builtins may keep state.
+type Library struct {
+	Name string
+	Load func(rt *object.Runtime) map[string]object.Object
+}
+
+// Default returns the libraries every interpreter starts with.
+func Default() []Library {
+	return []Library{Math, Strings, Regexp, Time, Decimal}
+}
+
+// Install loads lib and registers its builtins in rt.
+func Install(rt *object.Runtime, lib Library) {
+	if rt.Builtins == nil {
+		rt.Builtins = make(map[string]object.Object)
+	}
+	for name, obj := range lib.Load(rt) {
+		rt.Builtins[name] = obj
+	}
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func wrongArguments(name string, got int, want string) *object.Error {
+	return newError("%s: wrong number of arguments. got=%d, want=%s", name, got, want)
+}
+
+func wrongType(name string, i int, obj object.Object) *object.Error {
+	return newError("%s: argument %d not supported, got %s", name, i+1, obj.Type())
+}
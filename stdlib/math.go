@@ -0,0 +1,341 @@
+package stdlib
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/NilCent/eval/object"
+)
+
+// Math is exposed to scripts as the math namespace: math.max(a, b).
+var Math = Library{
+	Name: "math",
+	Load: func(rt *object.Runtime) map[string]object.Object {
+		members := map[string]object.Object{
+			"pi": &object.Float{Value: math.Pi},
+			"e":  &object.Float{Value: math.E},
+		}
+		for name, fn := range mathFunctions {
+			members[name] = &object.Builtin{Name: "math." + name, Fn: fn}
+		}
+		return map[string]object.Object{
+			"math": object.NewNamespace("math", members),
+		}
+	},
+}
+
+var mathFunctions = map[string]object.BuiltinFunction{
+	"abs":   mathAbs,
+	"min":   mathMin,
+	"max":   mathMax,
+	"clamp": mathClamp,
+	"pow":   mathPow,
+	"sqrt":  floatFunction("sqrt", math.Sqrt, func(x float64) bool { return x >= 0 }),
+	"floor": roundingFunction("floor", math.Floor),
+	"ceil":  roundingFunction("ceil", math.Ceil),
+	"round": mathRound,
+	"log":   floatFunction("log", math.Log, func(x float64) bool { return x > 0 }),
+	"log2":  floatFunction("log2", math.Log2, func(x float64) bool { return x > 0 }),
+	"log10": floatFunction("log10", math.Log10, func(x float64) bool { return x > 0 }),
+	"exp":   floatFunction("exp", math.Exp, nil),
+	"sin":   floatFunction("sin", math.Sin, nil),
+	"cos":   floatFunction("cos", math.Cos, nil),
+	"tan":   floatFunction("tan", math.Tan, nil),
+	"asin":  floatFunction("asin", math.Asin, func(x float64) bool { return x >= -1 && x <= 1 }),
+	"acos":  floatFunction("acos", math.Acos, func(x float64) bool { return x >= -1 && x <= 1 }),
+	"atan":  floatFunction("atan", math.Atan, nil),
+	"atan2": mathAtan2,
+}
+
+// maxIntegerBits bounds the size of the integers built by math.pow.
+const maxIntegerBits = 1 << 20
+
+func toFloat(obj object.Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value), true
+	case *object.BigInteger:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f, true
+	case *object.Rational:
+		f, _ := obj.Value.Float64()
+		return f, true
+	case *object.Float:
+		return obj.Value, true
+	}
+	return 0, false
+}
+
+func newFloat(name string, f float64) object.Object {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return newError("%s: result out of range", name)
+	}
+	return &object.Float{Value: f}
+}
+
+// floatFunction adapts f to a builtin taking one number, rejecting
+// arguments outside the domain when one is given.
+func floatFunction(
+	name string,
+	f func(float64) float64,
+	domain func(float64) bool,
+) object.BuiltinFunction {
+	name = "math." + name
+	return func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return wrongArguments(name, len(args), "1")
+		}
+		x, ok := toFloat(args[0])
+		if !ok {
+			return wrongType(name, 0, args[0])
+		}
+		if domain != nil && !domain(x) {
+			return newError("%s: argument out of domain: %s", name, args[0].Inspect())
+		}
+		return newFloat(name, f(x))
+	}
+}
+
+// roundingFunction adapts f to a builtin returning an integer. The
+// name of the function is also its rounding mode for decimals.
+func roundingFunction(mode string, f func(float64) float64) object.BuiltinFunction {
+	name := "math." + mode
+	return func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return wrongArguments(name, len(args), "1")
+		}
+		if r, ok := exactValue(args[0]); ok {
+			return ratToInteger(r, mode)
+		}
+		return roundToInteger(name, args[0], f)
+	}
+}
+
+func roundToInteger(name string, obj object.Object, f func(float64) float64) object.Object {
+	switch obj := obj.(type) {
+	case *object.Integer, *object.BigInteger:
+		return obj
+	case *object.Float:
+		r := f(obj.Value)
+		if math.IsNaN(r) || math.IsInf(r, 0) {
+			return newError("%s: result out of range", name)
+		}
+		if r < math.MinInt64 || r >= math.MaxInt64 {
+			n, _ := big.NewFloat(r).Int(nil)
+			return object.NewInteger(n)
+		}
+		return object.NativeInt(int64(r))
+	}
+	return wrongType(name, 0, obj)
+}
+
+func mathAbs(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("math.abs", len(args), "1")
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		if arg.Value == math.MinInt64 {
+			return object.NewInteger(new(big.Int).Neg(big.NewInt(arg.Value)))
+		}
+		if arg.Value < 0 {
+			return object.NativeInt(-arg.Value)
+		}
+		return arg
+	case *object.BigInteger:
+		return object.NewInteger(new(big.Int).Abs(arg.Value))
+	case *object.Rational:
+		return &object.Rational{Value: new(big.Rat).Abs(arg.Value)}
+	case *object.Float:
+		return &object.Float{Value: math.Abs(arg.Value)}
+	case *object.Decimal:
+		return &object.Decimal{Value: new(big.Rat).Abs(arg.Value), Scale: arg.Scale}
+	}
+	return wrongType("math.abs", 0, args[0])
+}
+
+func isNumeric(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Integer, *object.BigInteger, *object.Rational, *object.Float, *object.Decimal:
+		return true
+	}
+	return false
+}
+
+func toRat(obj object.Object) *big.Rat {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return new(big.Rat).SetInt64(obj.Value)
+	case *object.BigInteger:
+		return new(big.Rat).SetInt(obj.Value)
+	case *object.Float:
+		return new(big.Rat).SetFloat64(obj.Value)
+	}
+	r, _ := exactValue(obj)
+	return r
+}
+
+// exactValue returns the value of a decimal or a rational.
+func exactValue(obj object.Object) (*big.Rat, bool) {
+	switch obj := obj.(type) {
+	case *object.Decimal:
+		return obj.Value, true
+	case *object.Rational:
+		return obj.Value, true
+	}
+	return nil, false
+}
+
+// compareNumbers returns -1, 0 or 1 as a is less, equal or greater
+// than b. Integers and decimals are compared exactly.
+func compareNumbers(a, b object.Object) int {
+	if a.Type() == object.DECIMAL_OBJ || b.Type() == object.DECIMAL_OBJ ||
+		a.Type() == object.RATIONAL_OBJ && b.Type() != object.FLOAT_OBJ ||
+		b.Type() == object.RATIONAL_OBJ && a.Type() != object.FLOAT_OBJ ||
+		a.Type() == object.BIG_INTEGER_OBJ && b.Type() != object.FLOAT_OBJ ||
+		b.Type() == object.BIG_INTEGER_OBJ && a.Type() != object.FLOAT_OBJ {
+		return toRat(a).Cmp(toRat(b))
+	}
+
+	if ai, ok := a.(*object.Integer); ok {
+		if bi, ok := b.(*object.Integer); ok {
+			switch {
+			case ai.Value < bi.Value:
+				return -1
+			case ai.Value > bi.Value:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	af, _ := toFloat(a)
+	bf, _ := toFloat(b)
+	switch {
+	case af < bf:
+		return -1
+	case af > bf:
+		return 1
+	}
+	return 0
+}
+
+func extremum(name string, sign int, args []object.Object) object.Object {
+	if len(args) == 0 {
+		return wrongArguments(name, 0, "at least 1")
+	}
+
+	var result object.Object
+	for i, arg := range args {
+		if !isNumeric(arg) {
+			return wrongType(name, i, arg)
+		}
+		if result == nil || compareNumbers(arg, result) == sign {
+			result = arg
+		}
+	}
+	return result
+}
+
+func mathMin(args ...object.Object) object.Object {
+	return extremum("math.min", -1, args)
+}
+
+func mathMax(args ...object.Object) object.Object {
+	return extremum("math.max", 1, args)
+}
+
+func mathClamp(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return wrongArguments("math.clamp", len(args), "3")
+	}
+	for i, arg := range args {
+		if !isNumeric(arg) {
+			return wrongType("math.clamp", i, arg)
+		}
+	}
+
+	x, lo, hi := args[0], args[1], args[2]
+	if compareNumbers(lo, hi) > 0 {
+		return newError("math.clamp: lower bound %s above upper bound %s", lo.Inspect(), hi.Inspect())
+	}
+	if compareNumbers(x, lo) < 0 {
+		return lo
+	}
+	if compareNumbers(x, hi) > 0 {
+		return hi
+	}
+	return x
+}
+
+func mathPow(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return wrongArguments("math.pow", len(args), "2")
+	}
+
+	base, baseOk := object.ToBigInt(args[0])
+	exp, expOk := object.ToBigInt(args[1])
+	if baseOk && expOk && exp.Sign() >= 0 {
+		if base.CmpAbs(big.NewInt(1)) > 0 &&
+			(!exp.IsInt64() || int64(base.BitLen()-1)*exp.Int64() > maxIntegerBits) {
+			return newError("math.pow: result too large")
+		}
+		return object.NewInteger(new(big.Int).Exp(base, exp, nil))
+	}
+
+	x, ok := toFloat(args[0])
+	if !ok {
+		return wrongType("math.pow", 0, args[0])
+	}
+	y, ok := toFloat(args[1])
+	if !ok {
+		return wrongType("math.pow", 1, args[1])
+	}
+	if x < 0 && y != math.Trunc(y) {
+		return newError("math.pow: argument out of domain: %s", args[0].Inspect())
+	}
+	return newFloat("math.pow", math.Pow(x, y))
+}
+
+// mathRound rounds half away from zero, or half to even when the mode
+// "half-even" is given as second argument.
+func mathRound(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return wrongArguments("math.round", len(args), "1 or 2")
+	}
+
+	f, rounding := math.Round, "half-up"
+	if len(args) == 2 {
+		mode, ok := args[1].(*object.String)
+		if !ok {
+			return wrongType("math.round", 1, args[1])
+		}
+		switch mode.Value {
+		case "half-up":
+		case "half-even":
+			f, rounding = math.RoundToEven, "half-even"
+		default:
+			return newError("math.round: unknown rounding mode %q", mode.Value)
+		}
+	}
+
+	if r, ok := exactValue(args[0]); ok {
+		return ratToInteger(r, rounding)
+	}
+	return roundToInteger("math.round", args[0], f)
+}
+
+func mathAtan2(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return wrongArguments("math.atan2", len(args), "2")
+	}
+	y, ok := toFloat(args[0])
+	if !ok {
+		return wrongType("math.atan2", 0, args[0])
+	}
+	x, ok := toFloat(args[1])
+	if !ok {
+		return wrongType("math.atan2", 1, args[1])
+	}
+	return newFloat("math.atan2", math.Atan2(y, x))
+}
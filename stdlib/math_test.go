@@ -0,0 +1,101 @@
+package stdlib
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMath(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"math.abs(-5)", 5},
+		{"math.abs(-2.5)", 2.5},
+		{"math.min(3, 1, 2)", 1},
+		{"math.max(3, 1.5, 2)", 3},
+		{"math.max(1, 1.5)", 1.5},
+		{"math.clamp(15, 0, 10)", 10},
+		{"math.clamp(-1, 0, 10)", 0},
+		{"math.clamp(5, 0, 10)", 5},
+		{"math.pow(2, 10)", 1024},
+		{"math.pow(-1, 1000000000000)", 1},
+		{"math.pow(4, 0.5)", 2.0},
+		{"math.pow(2, -1)", 0.5},
+		{"math.sqrt(16)", 4.0},
+		{"math.floor(2.7)", 2},
+		{"math.floor(-2.5)", -3},
+		{"math.ceil(2.1)", 3},
+		{"math.ceil(7)", 7},
+		{"math.round(2.5)", 3},
+		{"math.round(-2.5)", -3},
+		{`math.round(2.5, "half-even")`, 2},
+		{`math.round(3.5, "half-even")`, 4},
+		{"math.log(1)", 0.0},
+		{"math.exp(0)", 1.0},
+		{"math.log10(1000)", 3.0},
+		{"math.sin(0)", 0.0},
+		{"math.cos(0)", 1.0},
+		{"math.atan2(0, 1)", 0.0},
+		{"math.pi", math.Pi},
+		{"math.e > 2.71", true},
+		{"let math = 1; math", 1},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, tc.input, testEval(t, tc.input, Math), tc.expected)
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"math.sqrt(-1)", "math.sqrt: argument out of domain: -1"},
+		{"math.log(0)", "math.log: argument out of domain: 0"},
+		{"math.asin(2)", "math.asin: argument out of domain: 2"},
+		{"math.pow(-8, 0.5)", "math.pow: argument out of domain: -8"},
+		{"math.pow(2, 10000000)", "math.pow: result too large"},
+		{"math.exp(1000)", "math.exp: result out of range"},
+		{"math.abs(true)", "math.abs: argument 1 not supported, got BOOLEAN"},
+		{"math.abs()", "math.abs: wrong number of arguments. got=0, want=1"},
+		{"math.max()", "math.max: wrong number of arguments. got=0, want=at least 1"},
+		{"math.clamp(1, 10, 0)", "math.clamp: lower bound 10 above upper bound 0"},
+		{`math.round(1.5, "up")`, `math.round: unknown rounding mode "up"`},
+		{"math.nope", "unknown member of MODULE: nope"},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, tc.input, testEval(t, tc.input, Math), errors.New(tc.expected))
+	}
+}
+
+func TestMathBigIntegers(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"math.pow(10, 19)", "10000000000000000000"},
+		{"math.pow(-2, 65)", "-36893488147419103232"},
+		{"math.pow(math.pow(2, 64), 0)", "1"},
+		{"math.abs(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"math.abs(-99999999999999999999)", "99999999999999999999"},
+		{"math.max(1, 99999999999999999999, 2.5)", "99999999999999999999"},
+		{"math.min(99999999999999999999, 1.5)", "1.5"},
+		{"math.floor(100000000000000000000.0)", "100000000000000000000"},
+		{"math.sqrt(10000000000000000000000000000000000000000)", "100000000000000000000"},
+	}
+
+	for _, tc := range testCases {
+		obj := testEval(t, tc.input, Math)
+		if obj.Inspect() != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.input, tc.expected, obj.Inspect())
+		}
+	}
+}
+
+func TestMathNotInstalled(t *testing.T) {
+	testObject(t, "math.pi", testEval(t, "math.pi"), errors.New("identifier not found: math"))
+}
@@ -0,0 +1,114 @@
+package stdlib
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/NilCent/eval/object"
+)
+
+// maxCachedPatterns bounds the compiled patterns kept per interpreter.
+const maxCachedPatterns = 256
+
+// Regexp installs match, find, findAll and replaceRegex. Patterns use
+// the RE2 syntax of the regexp package and run in linear time.
+var Regexp = Library{
+	Name: "regexp",
+	Load: func(rt *object.Runtime) map[string]object.Object {
+		c := &patternCache{patterns: make(map[string]*regexp.Regexp)}
+		return map[string]object.Object{
+			"match":        &object.Builtin{Name: "match", Fn: c.match},
+			"find":         &object.Builtin{Name: "find", Fn: c.find},
+			"findAll":      &object.Builtin{Name: "findAll", Fn: c.findAll},
+			"replaceRegex": &object.Builtin{Name: "replaceRegex", Fn: c.replace},
+		}
+	},
+}
+
+type patternCache struct {
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+func (c *patternCache) compile(name, pattern string) (*regexp.Regexp, *object.Error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if re, ok := c.patterns[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, newError("%s: invalid pattern %q: %s", name, pattern, err)
+	}
+
+	if len(c.patterns) >= maxCachedPatterns {
+		c.patterns = make(map[string]*regexp.Regexp)
+	}
+	c.patterns[pattern] = re
+
+	return re, nil
+}
+
+// args checks that args holds count strings, the pattern being the
+// second one, and compiles it.
+func (c *patternCache) args(name string, args []object.Object, count int) ([]string, *regexp.Regexp, *object.Error) {
+	values, errObj := stringArgs(name, args, count)
+	if errObj != nil {
+		return nil, nil, errObj
+	}
+
+	re, errObj := c.compile(name, values[1])
+	if errObj != nil {
+		return nil, nil, errObj
+	}
+
+	return values, re, nil
+}
+
+func (c *patternCache) match(args ...object.Object) object.Object {
+	values, re, err := c.args("match", args, 2)
+	if err != nil {
+		return err
+	}
+	return object.NativeBool(re.MatchString(values[0]))
+}
+
+// find returns the leftmost match of the pattern, or null.
+func (c *patternCache) find(args ...object.Object) object.Object {
+	values, re, err := c.args("find", args, 2)
+	if err != nil {
+		return err
+	}
+
+	loc := re.FindStringIndex(values[0])
+	if loc == nil {
+		return object.NULL
+	}
+	return &object.String{Value: values[0][loc[0]:loc[1]]}
+}
+
+func (c *patternCache) findAll(args ...object.Object) object.Object {
+	values, re, err := c.args("findAll", args, 2)
+	if err != nil {
+		return err
+	}
+
+	matches := re.FindAllString(values[0], -1)
+	elements := make([]object.Object, len(matches))
+	for i, m := range matches {
+		elements[i] = &object.String{Value: m}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// replace replaces every match, expanding $1 or ${name} in the
+// replacement to the text of the submatch.
+func (c *patternCache) replace(args ...object.Object) object.Object {
+	values, re, err := c.args("replaceRegex", args, 3)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: re.ReplaceAllString(values[0], values[2])}
+}
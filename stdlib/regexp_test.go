@@ -0,0 +1,67 @@
+package stdlib
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/NilCent/eval/object"
+)
+
+func TestRegexp(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match("12345", "^[0-9]{5}$")`, true},
+		{`match("1234", "^[0-9]{5}$")`, false},
+		{`match("SKU-42-X", "SKU-[0-9]+")`, true},
+		{`find("order 17 of 20", "[0-9]+")`, "17"},
+		{`len(findAll("order 17 of 20", "[0-9]+"))`, 2},
+		{`findAll("order 17 of 20", "[0-9]+")[1]`, "20"},
+		{`len(findAll("abc", "[0-9]+"))`, 0},
+		{`replaceRegex("2026-01-31", "(\\d+)-(\\d+)-(\\d+)", "$3/$2/$1")`, "31/01/2026"},
+		{`match(repeat("a", 5000) + "!", "^(a+)+$")`, false},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, tc.input, testEval(t, tc.input, Regexp, Strings), tc.expected)
+	}
+
+	if evaluated := testEval(t, `find("abc", "[0-9]")`, Regexp); evaluated != object.NULL {
+		t.Errorf("expected null, got %#v", evaluated)
+	}
+}
+
+func TestRegexpErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`match("a", "(")`, "match: invalid pattern \"(\": error parsing regexp: missing closing ): `(`"},
+		{`replaceRegex("a", "[", "b")`, "replaceRegex: invalid pattern \"[\": error parsing regexp: missing closing ]: `[`"},
+		{`find("a", 1)`, "find: argument 2 not supported, got INTEGER"},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, tc.input, testEval(t, tc.input, Regexp), errors.New(tc.expected))
+	}
+}
+
+func TestPatternCache(t *testing.T) {
+	c := &patternCache{patterns: map[string]*regexp.Regexp{}}
+
+	first, _ := c.compile("match", "a+")
+	second, _ := c.compile("match", "a+")
+	if first != second {
+		t.Errorf("compiled patterns must be cached")
+	}
+
+	for i := 0; i < maxCachedPatterns+1; i++ {
+		c.compile("match", strings.Repeat("a", i+1))
+	}
+	if len(c.patterns) > maxCachedPatterns {
+		t.Errorf("cache exceeds its bound. got=%d", len(c.patterns))
+	}
+}
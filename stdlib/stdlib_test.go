@@ -0,0 +1,57 @@
+package stdlib
+
+import (
+	"testing"
+
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+)
+
+func testEval(t *testing.T, input string, libs ...Library) object.Object {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse %q: %s", input, err)
+	}
+
+	rt := &object.Runtime{}
+	for _, lib := range libs {
+		Install(rt, lib)
+	}
+	env := object.NewEnvironment()
+	env.SetRuntime(rt)
+
+	return evaluator.Eval(program, env)
+}
+
+func testObject(t *testing.T, input string, obj object.Object, expected interface{}) {
+	switch expected := expected.(type) {
+	case int:
+		integer, ok := obj.(*object.Integer)
+		if !ok || integer.Value != int64(expected) {
+			t.Errorf("%s: expected %d, got %#v", input, expected, obj)
+		}
+	case float64:
+		f, ok := obj.(*object.Float)
+		if !ok || f.Value != expected {
+			t.Errorf("%s: expected %v, got %#v", input, expected, obj)
+		}
+	case bool:
+		if obj != object.NativeBool(expected) {
+			t.Errorf("%s: expected %t, got %#v", input, expected, obj)
+		}
+	case string:
+		str, ok := obj.(*object.String)
+		if !ok || str.Value != expected {
+			t.Errorf("%s: expected %q, got %#v", input, expected, obj)
+		}
+	case error:
+		errObj, ok := obj.(*object.Error)
+		if !ok || errObj.Message != expected.Error() {
+			t.Errorf("%s: expected error %q, got %#v", input, expected, obj)
+		}
+	default:
+		t.Errorf("type of expected not handled. got=%T", expected)
+	}
+}
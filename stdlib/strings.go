@@ -0,0 +1,361 @@
+package stdlib
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NilCent/eval/object"
+)
+
+// maxStringLength bounds the strings built by repeat and padLeft.
+const maxStringLength = 1 << 24
+
+// maxFormatWidth bounds the width and precision of format verbs.
+const maxFormatWidth = 1024
+
+// Strings installs the string functions as global builtins: upper(s).
+var Strings = Library{
+	Name: "strings",
+	Load: func(rt *object.Runtime) map[string]object.Object {
+		builtins := map[string]object.Object{}
+		for name, fn := range stringFunctions {
+			builtins[name] = &object.Builtin{Name: name, Fn: fn}
+		}
+		return builtins
+	},
+}
+
+var stringFunctions = map[string]object.BuiltinFunction{
+	"len":        stringsLen,
+	"upper":      stringFunction("upper", strings.ToUpper),
+	"lower":      stringFunction("lower", strings.ToLower),
+	"trim":       stringFunction("trim", strings.TrimSpace),
+	"split":      stringsSplit,
+	"join":       stringsJoin,
+	"contains":   stringPredicate("contains", strings.Contains),
+	"startsWith": stringPredicate("startsWith", strings.HasPrefix),
+	"endsWith":   stringPredicate("endsWith", strings.HasSuffix),
+	"replace":    stringsReplace,
+	"repeat":     stringsRepeat,
+	"padLeft":    stringsPadLeft,
+	"format":     stringsFormat,
+}
+
+// stringArgs checks that args holds count strings.
+func stringArgs(name string, args []object.Object, count int) ([]string, *object.Error) {
+	if len(args) != count {
+		return nil, wrongArguments(name, len(args), fmt.Sprint(count))
+	}
+
+	values := make([]string, count)
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return nil, wrongType(name, i, arg)
+		}
+		values[i] = str.Value
+	}
+	return values, nil
+}
+
+func stringFunction(name string, f func(string) string) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		values, err := stringArgs(name, args, 1)
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: f(values[0])}
+	}
+}
+
+func stringPredicate(name string, f func(string, string) bool) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		values, err := stringArgs(name, args, 2)
+		if err != nil {
+			return err
+		}
+		return object.NativeBool(f(values[0], values[1]))
+	}
+}
+
+// stringsLen counts the characters of a string or the elements of an
+// array or hash.
+func stringsLen(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongArguments("len", len(args), "1")
+	}
+
+	switch arg := args[0].(type) {
+	case *object.String:
+		return object.NativeInt(int64(utf8.RuneCountInString(arg.Value)))
+	case *object.Array:
+		return object.NativeInt(int64(len(arg.Elements)))
+	case *object.Hash:
+		return object.NativeInt(int64(len(arg.Pairs)))
+	}
+	return wrongType("len", 0, args[0])
+}
+
+func stringsSplit(args ...object.Object) object.Object {
+	values, err := stringArgs("split", args, 2)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(values[0], values[1])
+	elements := make([]object.Object, len(parts))
+	for i, part := range parts {
+		elements[i] = &object.String{Value: part}
+	}
+	return &object.Array{Elements: elements}
+}
+
+func stringsJoin(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return wrongArguments("join", len(args), "2")
+	}
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return wrongType("join", 0, args[0])
+	}
+	sep, ok := args[1].(*object.String)
+	if !ok {
+		return wrongType("join", 1, args[1])
+	}
+
+	parts := make([]string, len(array.Elements))
+	for i, el := range array.Elements {
+		str, ok := el.(*object.String)
+		if !ok {
+			return newError("join: element %d is not a string, got %s", i, el.Type())
+		}
+		parts[i] = str.Value
+	}
+	return &object.String{Value: strings.Join(parts, sep.Value)}
+}
+
+func stringsReplace(args ...object.Object) object.Object {
+	values, err := stringArgs("replace", args, 3)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: strings.ReplaceAll(values[0], values[1], values[2])}
+}
+
+func stringsRepeat(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return wrongArguments("repeat", len(args), "2")
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return wrongType("repeat", 0, args[0])
+	}
+	count, ok := args[1].(*object.Integer)
+	if !ok {
+		return wrongType("repeat", 1, args[1])
+	}
+
+	if count.Value < 0 {
+		return newError("repeat: negative count %d", count.Value)
+	}
+	if len(str.Value) > 0 && count.Value > int64(maxStringLength/len(str.Value)) {
+		return newError("repeat: result too long")
+	}
+	return &object.String{Value: strings.Repeat(str.Value, int(count.Value))}
+}
+
+// stringsPadLeft pads a string to a width in characters with spaces,
+// or with the characters of the optional third argument.
+func stringsPadLeft(args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return wrongArguments("padLeft", len(args), "2 or 3")
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return wrongType("padLeft", 0, args[0])
+	}
+	width, ok := args[1].(*object.Integer)
+	if !ok {
+		return wrongType("padLeft", 1, args[1])
+	}
+	pad := " "
+	if len(args) == 3 {
+		padArg, ok := args[2].(*object.String)
+		if !ok {
+			return wrongType("padLeft", 2, args[2])
+		}
+		if padArg.Value == "" {
+			return newError("padLeft: empty padding")
+		}
+		pad = padArg.Value
+	}
+
+	if width.Value > maxStringLength {
+		return newError("padLeft: result too long")
+	}
+	missing := int(width.Value) - utf8.RuneCountInString(str.Value)
+	if missing <= 0 {
+		return str
+	}
+
+	padding := []rune(strings.Repeat(pad, missing/utf8.RuneCountInString(pad)+1))
+	return &object.String{Value: string(padding[:missing]) + str.Value}
+}
+
+// stringsFormat implements format(layout, args...) with the verbs of
+// fmt.Sprintf: %d, %f, %e, %g, %s, %q, %v, %t, %x, %X, %o, %b and %c,
+// along with their flags, width and precision. Decimals are printed
+// exactly by %f.
+func stringsFormat(args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return wrongArguments("format", 0, "at least 1")
+	}
+	layout, ok := args[0].(*object.String)
+	if !ok {
+		return wrongType("format", 0, args[0])
+	}
+
+	var out strings.Builder
+	values := args[1:]
+	next := 0
+	s := layout.Value
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			out.WriteByte(s[i])
+			continue
+		}
+
+		start := i
+		i++
+		for i < len(s) && strings.IndexByte("+-# 0", s[i]) >= 0 {
+			i++
+		}
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		for _, n := range strings.Split(s[start+1:i], ".") {
+			if width, err := strconv.Atoi(strings.TrimLeft(n, "+-# 0")); err == nil && width > maxFormatWidth {
+				return newError("format: width %d too large", width)
+			}
+		}
+		if i >= len(s) {
+			return newError("format: incomplete verb at end of %q", s)
+		}
+
+		verb := s[i]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if next >= len(values) {
+			return newError("format: missing argument for %s", s[start:i+1])
+		}
+
+		arg, err := formatArgument(verb, values[next])
+		if err != nil {
+			return newError("format: %s for argument %d", err, next+1)
+		}
+		out.WriteString(fmt.Sprintf(s[start:i+1], arg))
+		next++
+	}
+
+	if next < len(values) {
+		return newError("format: %d unused arguments", len(values)-next)
+	}
+	return &object.String{Value: out.String()}
+}
+
+// formatArgument converts obj to the Go value matching verb.
+func formatArgument(verb byte, obj object.Object) (interface{}, error) {
+	switch verb {
+	case 'v', 's':
+		if str, ok := obj.(*object.String); ok {
+			return str.Value, nil
+		}
+		return obj.Inspect(), nil
+	case 'q':
+		if str, ok := obj.(*object.String); ok {
+			return str.Value, nil
+		}
+	case 'd', 'o', 'b':
+		switch obj := obj.(type) {
+		case *object.Integer:
+			return obj.Value, nil
+		case *object.BigInteger:
+			return obj.Value, nil
+		}
+	case 'c':
+		if integer, ok := obj.(*object.Integer); ok {
+			return integer.Value, nil
+		}
+	case 'x', 'X':
+		switch obj := obj.(type) {
+		case *object.Integer:
+			return obj.Value, nil
+		case *object.BigInteger:
+			return obj.Value, nil
+		case *object.String:
+			return obj.Value, nil
+		}
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if d, ok := obj.(*object.Decimal); ok {
+			if verb == 'f' || verb == 'F' {
+				return exactDecimal{d.Value}, nil
+			}
+			return new(big.Float).SetPrec(256).SetRat(d.Value), nil
+		}
+		if f, ok := toFloat(obj); ok {
+			return f, nil
+		}
+	case 't':
+		if b, ok := obj.(*object.Boolean); ok {
+			return b.Value, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown verb %%%c", verb)
+	}
+	return nil, fmt.Errorf("%%%c does not accept %s", verb, obj.Type())
+}
+
+// exactDecimal formats a decimal for %f without going through a binary
+// float, rounding halves away from zero: format("%.2f", 1.005dec) is
+// 1.01.
+type exactDecimal struct {
+	value *big.Rat
+}
+
+func (d exactDecimal) Format(f fmt.State, verb rune) {
+	prec, ok := f.Precision()
+	if !ok {
+		prec = 6
+	}
+	digits := d.value.FloatString(prec)
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	} else if f.Flag('+') {
+		sign = "+"
+	} else if f.Flag(' ') {
+		sign = " "
+	}
+
+	pad := 0
+	if width, ok := f.Width(); ok {
+		pad = width - len(sign) - len(digits)
+	}
+	switch {
+	case pad <= 0:
+		fmt.Fprint(f, sign+digits)
+	case f.Flag('-'):
+		fmt.Fprint(f, sign+digits+strings.Repeat(" ", pad))
+	case f.Flag('0'):
+		fmt.Fprint(f, sign+strings.Repeat("0", pad)+digits)
+	default:
+		fmt.Fprint(f, strings.Repeat(" ", pad)+sign+digits)
+	}
+}
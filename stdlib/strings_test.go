@@ -0,0 +1,68 @@
+package stdlib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStrings(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("héllo")`, 5},
+		{`len(["a", "b"])`, 2},
+		{`len({"a": 1})`, 1},
+		{`upper("abc")`, "ABC"},
+		{`lower("AbC")`, "abc"},
+		{`trim("  a b ")`, "a b"},
+		{`split("a,b,c", ",")[1]`, "b"},
+		{`len(split("a,b,c", ","))`, 3},
+		{`join(split("a,b,c", ","), "-")`, "a-b-c"},
+		{`join([], "-")`, ""},
+		{`contains("seafood", "foo")`, true},
+		{`startsWith("SKU-123", "SKU-")`, true},
+		{`endsWith("file.eval", ".txt")`, false},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`padLeft("7", 3, "0")`, "007"},
+		{`padLeft("7", 4)`, "   7"},
+		{`padLeft("1234", 2, "0")`, "1234"},
+		{`padLeft("x", 4, "ab")`, "abax"},
+		{`format("%d items", 3)`, "3 items"},
+		{`format("%05.2f|%-4s|%t|%q|%x|%%", 3.14159, "ab", true, "q", 255)`, "03.14|ab  |true|\"q\"|ff|%"},
+		{`format("%v and %s", [1, 2], 5)`, "[1, 2] and 5"},
+		{`format("%.1f", 2)`, "2.0"},
+		{`format("%.2f", 19.99dec)`, "19.99"},
+		{`format("%.2f|%+.1f|%08.3f|%-7.2f|%f", 1.005dec, 2.25dec, -1.5dec, 0.1dec, 3dec)`, "1.01|+2.3|-001.500|0.10   |3.000000"},
+		{`format("%.3e|%g", 12345.678dec, 0.1dec)`, "1.235e+04|0.1"},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, tc.input, testEval(t, tc.input, Strings), tc.expected)
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`upper(1)`, "upper: argument 1 not supported, got INTEGER"},
+		{`len()`, "len: wrong number of arguments. got=0, want=1"},
+		{`join(["a", 1], ",")`, "join: element 1 is not a string, got INTEGER"},
+		{`repeat("a", -1)`, "repeat: negative count -1"},
+		{`repeat("ab", 100000000)`, "repeat: result too long"},
+		{`padLeft("a", 3, "")`, "padLeft: empty padding"},
+		{`format("%d", "a")`, "format: %d does not accept STRING for argument 1"},
+		{`format("%d %d", 1)`, "format: missing argument for %d"},
+		{`format("%d", 1, 2)`, "format: 1 unused arguments"},
+		{`format("%y", 1)`, "format: unknown verb %y for argument 1"},
+		{`format("100%")`, `format: incomplete verb at end of "100%"`},
+		{`format("%99999d", 1)`, "format: width 99999 too large"},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, tc.input, testEval(t, tc.input, Strings), errors.New(tc.expected))
+	}
+}
@@ -0,0 +1,50 @@
+package stdlib
+
+import (
+	"time"
+
+	"github.com/NilCent/eval/object"
+)
+
+// dateLayouts are the layouts accepted by date, all read as UTC unless
+// they carry an offset.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+// Time installs date and now. now reads the runtime clock so that hosts
+// can evaluate rules at a fixed instant.
+var Time = Library{
+	Name: "time",
+	Load: func(rt *object.Runtime) map[string]object.Object {
+		return map[string]object.Object{
+			"date": &object.Builtin{Name: "date", Fn: timeDate},
+			"now": &object.Builtin{Name: "now", Fn: func(args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return wrongArguments("now", len(args), "0")
+				}
+				if rt.Now != nil {
+					return &object.Time{Value: rt.Now()}
+				}
+				return &object.Time{Value: time.Now()}
+			}},
+		}
+	},
+}
+
+func timeDate(args ...object.Object) object.Object {
+	values, err := stringArgs("date", args, 1)
+	if err != nil {
+		return err
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, values[0]); err == nil {
+			return &object.Time{Value: t}
+		}
+	}
+	return newError("date: cannot parse %q, expected a layout like 2006-01-02 or %s", values[0], time.RFC3339)
+}
@@ -0,0 +1,95 @@
+package stdlib
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+)
+
+func TestTime(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`date("2026-01-31").year`, 2026},
+		{`date("2026-01-31").month`, 1},
+		{`date("2026-01-31").day`, 31},
+		{`date("2026-02-01").weekday`, 7},
+		{`date("2026-01-31 16:30").hour`, 16},
+		{`date("2026-01-31T16:30:05+02:00").second`, 5},
+		{`(date("2026-01-31") + 1d).month`, 2},
+		{`(date("2026-03-01") - date("2026-01-31")).days`, 29},
+		{`date("2026-01-31") - 30d < date("2026-01-02")`, true},
+		{`date("2026-01-31") + 2h == date("2026-01-31T04:00:00+02:00")`, true},
+		{`date("2026-01-31") > date("2026-01-30")`, true},
+		{`(1h30m).minutes`, 90},
+		{`2 * 15m == 30m`, true},
+		{`(1d / 2).hours`, 12},
+		{`-1h < 0s`, true},
+		{`1w == 7d`, true},
+		{`1h == 60`, false},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, tc.input, testEval(t, tc.input, Time), tc.expected)
+	}
+}
+
+func TestTimeErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`date("31/01/2026")`, `date: cannot parse "31/01/2026", expected a layout like 2006-01-02 or 2006-01-02T15:04:05Z07:00`},
+		{`date("2026-01-31") + date("2026-01-31")`, "unknown operator: TIME + TIME"},
+		{`date("2026-01-31") + 1`, "type mismatch: TIME + INTEGER"},
+		{`1d / 0`, "division by zero"},
+		{`now(1)`, "now: wrong number of arguments. got=1, want=0"},
+	}
+
+	for _, tc := range testCases {
+		testObject(t, tc.input, testEval(t, tc.input, Time), errors.New(tc.expected))
+	}
+}
+
+func TestNowUsesRuntimeClock(t *testing.T) {
+	fixed := time.Date(2026, 10, 19, 16, 59, 0, 0, time.UTC)
+
+	rt := &object.Runtime{Now: func() time.Time { return fixed }}
+	Install(rt, Time)
+	env := object.NewEnvironment()
+	env.SetRuntime(rt)
+
+	input := `let t = now(); if (t.weekday < 6) { t.hour < 17 } else { false }`
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testObject(t, input, evaluator.Eval(program, env), true)
+}
+
+func TestInspectDuration(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{36 * time.Hour, "1d12h"},
+		{90 * time.Minute, "1h30m"},
+		{-time.Second, "-1s"},
+		{0, "0s"},
+		{1500 * time.Microsecond, "1ms500µs"},
+	}
+
+	for _, tc := range testCases {
+		d := &object.Duration{Value: tc.duration}
+		if d.Inspect() != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, d.Inspect())
+		}
+	}
+}
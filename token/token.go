@@ -7,8 +7,12 @@ const (
 	EOF     = "EOF"
 
 	// Identifiers + literals
-	IDENT = "IDENT" // add, foobar, x, y, ...
-	INT   = "INT"   // 1343456
+	IDENT    = "IDENT"    // add, foobar, x, y, ...
+	INT      = "INT"      // 1343456
+	FLOAT    = "FLOAT"    // 3.14
+	DURATION = "DURATION" // 30d, 1h30m
+	DECIMAL  = "DECIMAL"  // 19.99dec, 19dec, 19.99d
+	STRING   = "STRING"   // "foobar"
 
 	// Operators
 	ASSIGN   = "="
@@ -24,15 +28,23 @@ const (
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	AND = "&&"
+	OR  = "||"
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	DOT       = "."
 
 	LPAREN = "("
 	RPAREN = ")"
 	LBRACE = "{"
 	RBRACE = "}"
 
+	LBRACKET = "["
+	RBRACKET = "]"
+
 	// Keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
@@ -41,12 +53,27 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	THROW    = "THROW"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
+	AS       = "AS"
 )
 
+// DecimalSuffix ends decimal literals. It is no unit of durations, so
+// that 19dec is a decimal and 19d a duration of 19 days. A fractional
+// number may end with d instead, as in 19.99d, durations being whole.
+const DecimalSuffix = "dec"
+
 type Token struct {
-	Type    TokenType
-	Literal string
-	Line 	int
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+	Line    int       `json:"line"`
+	// Column is the byte offset of the token in its line, from 1, or 0
+	// for tokens not read from a source.
+	Column int `json:"column,omitempty"`
 }
 
 func New(t TokenType, l string, line int) Token{
@@ -66,13 +93,20 @@ func (t *Token) Is(ty TokenType) bool {
 }
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":      FUNCTION,
+	"let":     LET,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"throw":   THROW,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"import":  IMPORT,
+	"export":  EXPORT,
+	"as":      AS,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -0,0 +1,343 @@
+// Package types infers the types of the values of a program ahead of
+// its evaluation, reporting the operations bound to fail whatever the
+// input: operators applied to operands of the wrong types, calls of
+// values other than functions, calls with a wrong number of arguments
+// and undefined variables.
+//
+// The types of parameters are not inferred, so that the operations on
+// them are only reported once they are known to fail.
+package types
+
+import (
+	"fmt"
+
+	"github.com/NilCent/eval/ast"
+)
+
+// Schema gives the types of the variables provided by the host, such
+// as the inputs of a rule and the builtins.
+type Schema map[string]Type
+
+// Diagnostic reports an operation bound to fail at a line.
+type Diagnostic struct {
+	Line    int
+	Message string
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("Line %d %s", d.Line, d.Message)
+}
+
+// Check infers the types of program, whose global variables not bound
+// by it are described by schema, and returns the diagnostics of the
+// operations bound to fail, in source order.
+func Check(program *ast.Program, schema Schema) []Diagnostic {
+	return CheckInfo(program, schema, nil)
+}
+
+// Info holds the types inferred for the expressions of a program.
+type Info struct {
+	Types map[ast.Expression]Type
+}
+
+// CheckInfo is like Check, recording in info the type of every
+// expression checked when info is not nil. An expression within a
+// function has the type inferred for any call, parameters being of
+// unknown type.
+func CheckInfo(program *ast.Program, schema Schema, info *Info) []Diagnostic {
+	c := &checker{schema: schema, info: info}
+	if info != nil && info.Types == nil {
+		info.Types = make(map[ast.Expression]Type)
+	}
+
+	global := newScope(nil)
+	global.declare(program)
+	for _, stmt := range program.Statements {
+		c.statement(stmt, global)
+	}
+
+	return c.diagnostics
+}
+
+type checker struct {
+	schema      Schema
+	info        *Info
+	diagnostics []Diagnostic
+	// results collects the types of the values returned by the function
+	// being checked, nil at the top level.
+	results *[]Type
+}
+
+func (c *checker) report(line int, format string, a ...interface{}) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{Line: line, Message: fmt.Sprintf(format, a...)})
+}
+
+// block returns the type of the value of block, the value of its last
+// statement.
+func (c *checker) block(block *ast.BlockStatement, s *scope) Type {
+	var t Type = Unknown
+	for _, stmt := range block.Statements {
+		t = c.statement(stmt, s)
+	}
+	return t
+}
+
+// branch checks a block evaluated or not depending on a condition.
+// The variables it binds are of unknown type afterwards, since they may
+// keep their former value.
+func (c *checker) branch(block *ast.BlockStatement, s *scope) Type {
+	before := make(map[string]Type, len(s.vars))
+	for name, t := range s.vars {
+		before[name] = t
+	}
+
+	t := c.block(block, s)
+
+	for name, after := range s.vars {
+		if prev, ok := before[name]; !ok || prev != after {
+			s.vars[name] = Unknown
+		}
+	}
+	return t
+}
+
+func (c *checker) statement(stmt ast.Statement, s *scope) Type {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		s.vars[stmt.Name.Value] = c.expression(stmt.Value, s)
+	case *ast.ExportStatement:
+		c.statement(stmt.Statement, s)
+	case *ast.ReturnStatement:
+		t := c.expression(stmt.ReturnValue, s)
+		if c.results != nil {
+			*c.results = append(*c.results, t)
+		}
+	case *ast.ThrowStatement:
+		c.expression(stmt.Value, s)
+	case *ast.ImportStatement:
+		if stmt.Alias != nil {
+			s.vars[stmt.Alias.Value] = Module
+		}
+	case *ast.ExpressionStatement:
+		return c.expression(stmt.Expression, s)
+	}
+	return Unknown
+}
+
+func (c *checker) expression(exp ast.Expression, s *scope) Type {
+	t := c.infer(exp, s)
+	if c.info != nil && exp != nil {
+		c.info.Types[exp] = t
+	}
+	return t
+}
+
+func (c *checker) infer(exp ast.Expression, s *scope) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral, *ast.BigIntegerLiteral:
+		return Integer
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.DecimalLiteral:
+		return Decimal
+	case *ast.DurationLiteral:
+		return Duration
+	case *ast.StringLiteral:
+		return String
+	case *ast.Boolean:
+		return Boolean
+
+	case *ast.Identifier:
+		t, ok := s.lookup(exp.Value)
+		if !ok {
+			if t, ok = c.schema[exp.Value]; !ok {
+				c.report(exp.Token.Line, "identifier not found: %s", exp.Value)
+				return Unknown
+			}
+		}
+		return t
+
+	case *ast.PrefixExpression:
+		t, err := prefix(exp.Operator, c.expression(exp.Right, s))
+		if err != "" {
+			c.report(exp.Token.Line, "%s", err)
+			return Unknown
+		}
+		return t
+
+	case *ast.InfixExpression:
+		left := c.expression(exp.Left, s)
+		right := c.expression(exp.Right, s)
+		t, err := infix(exp.Operator, left, right)
+		if err != "" {
+			c.report(exp.Token.Line, "%s", err)
+			return Unknown
+		}
+		return t
+
+	case *ast.IfExpression:
+		c.expression(exp.Condition, s)
+		t := c.branch(exp.Consequence, s)
+		if exp.Alternative == nil {
+			return join(t, Null)
+		}
+		return join(t, c.branch(exp.Alternative, s))
+
+	case *ast.TryExpression:
+		t := c.branch(exp.Block, s)
+		if exp.Catch != nil {
+			cs := newScope(s)
+			cs.define(exp.Param.Value)
+			cs.declare(exp.Catch)
+			cs.vars[exp.Param.Value] = Unknown
+			t = join(t, c.block(exp.Catch, cs))
+		}
+		if exp.Finally != nil {
+			c.branch(exp.Finally, s)
+		}
+		return t
+
+	case *ast.ArrayLiteral:
+		for _, el := range exp.Elements {
+			c.expression(el, s)
+		}
+		return Array
+
+	case *ast.HashLiteral:
+		for i, key := range exp.Keys {
+			if t := c.expression(key, s); !hashable(t) {
+				c.report(exp.Token.Line, "unusable as hash key: %s", kind(t))
+			}
+			c.expression(exp.Values[i], s)
+		}
+		return Hash
+
+	case *ast.IndexExpression:
+		return c.index(exp, s)
+
+	case *ast.MemberExpression:
+		switch t := c.expression(exp.Object, s); t {
+		case Integer, Number:
+			// the numerator and denominator of rationals and integers
+			switch exp.Property.Value {
+			case "num", "den":
+				return Integer
+			}
+			if t == Integer {
+				c.report(exp.Token.Line, "unknown member of %s: %s", kind(t), exp.Property.Value)
+			}
+		case Unknown, Hash, Module, Duration, Time:
+		default:
+			c.report(exp.Token.Line, "member access on %s: %s", kind(t), exp.Property.Value)
+		}
+		return Unknown
+
+	case *ast.FunctionLiteral:
+		return c.function(exp, s)
+
+	case *ast.CallExpression:
+		return c.call(exp, s)
+	}
+
+	return Unknown
+}
+
+func (c *checker) index(exp *ast.IndexExpression, s *scope) Type {
+	left := c.expression(exp.Left, s)
+	index := c.expression(exp.Index, s)
+
+	switch left {
+	case Unknown:
+	case Array:
+		if index != Unknown && index != Integer {
+			c.report(exp.Token.Line, "index operator not supported: %s[%s]", kind(left), kind(index))
+		}
+	case Hash:
+		if !hashable(index) {
+			c.report(exp.Token.Line, "unusable as hash key: %s", kind(index))
+		}
+	default:
+		c.report(exp.Token.Line, "index operator not supported: %s", kind(left))
+	}
+
+	return Unknown
+}
+
+// function returns the signature of a function literal, whose result
+// is the type of every value it returns, if the same.
+func (c *checker) function(fl *ast.FunctionLiteral, s *scope) Type {
+	fs := newScope(s)
+	params := make([]Type, len(fl.Parameters))
+	for i, param := range fl.Parameters {
+		fs.define(param.Value)
+		fs.vars[param.Value] = Unknown
+		params[i] = Unknown
+	}
+	fs.declare(fl.Body)
+
+	outer := c.results
+	results := []Type{}
+	c.results = &results
+	last := c.block(fl.Body, fs)
+	c.results = outer
+
+	n := len(fl.Body.Statements)
+	if n > 0 {
+		switch fl.Body.Statements[n-1].(type) {
+		case *ast.ExpressionStatement:
+			results = append(results, last)
+		case *ast.ReturnStatement:
+		default:
+			results = append(results, Unknown)
+		}
+	}
+
+	var result Type = Unknown
+	for i, t := range results {
+		if i == 0 {
+			result = t
+		} else {
+			result = join(result, t)
+		}
+	}
+
+	return &Func{Params: params, Result: result}
+}
+
+func (c *checker) call(exp *ast.CallExpression, s *scope) Type {
+	callee := c.expression(exp.Function, s)
+	args := make([]Type, len(exp.Arguments))
+	for i, arg := range exp.Arguments {
+		args[i] = c.expression(arg, s)
+	}
+
+	name := "function"
+	if ident, ok := exp.Function.(*ast.Identifier); ok {
+		name = ident.Value
+	}
+
+	switch callee := callee.(type) {
+	case *Func:
+		if callee.Params == nil {
+			return callee.Result
+		}
+		if len(args) != len(callee.Params) {
+			c.report(exp.Token.Line, "wrong number of arguments to %s: want=%d, got=%d",
+				name, len(callee.Params), len(args))
+			return callee.Result
+		}
+		for i, arg := range args {
+			if !assignable(arg, callee.Params[i]) {
+				c.report(exp.Token.Line, "cannot use %s as %s in argument %d of %s",
+					kind(arg), kind(callee.Params[i]), i+1, name)
+			}
+		}
+		return callee.Result
+	case Basic:
+		if callee != Unknown {
+			c.report(exp.Token.Line, "not a function: %s", kind(callee))
+		}
+	}
+
+	return Unknown
+}
@@ -0,0 +1,199 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	return program
+}
+
+var schema = Schema{
+	"price":    Integer,
+	"rate":     Decimal,
+	"name":     String,
+	"vip":      Boolean,
+	"tags":     Array,
+	"discount": &Func{Params: []Type{Integer, Integer}, Result: Integer},
+	"len":      &Func{Params: []Type{Unknown}, Result: Integer},
+	"max":      &Func{Result: Number},
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{"price + 1", nil},
+		{"price + true", []string{"Line 1 type mismatch: INTEGER + BOOLEAN"}},
+		{"discount(1, 2, 3)", []string{"Line 1 wrong number of arguments to discount: want=2, got=3"}},
+		{"discount(price, name)", []string{"Line 1 cannot use STRING as INTEGER in argument 2 of discount"}},
+		{"discount(price, 1.5) * 2", nil},
+		{"len(tags) + max(1, 2, 3)", nil},
+		{"rate * 1.5", []string{"Line 1 type mismatch: DECIMAL * FLOAT"}},
+		{"name - name", []string{"Line 1 unknown operator: STRING - STRING"}},
+		{"-vip", []string{"Line 1 unknown operator: -BOOLEAN"}},
+		{"!price", nil},
+		{"vip && price > 1 || name", nil},
+		{"(vip || price) + 1", []string{"Line 1 type mismatch: BOOLEAN + INTEGER"}},
+		{"amount * 2", []string{"Line 1 identifier not found: amount"}},
+		{"price(1)", []string{"Line 1 not a function: INTEGER"}},
+		{"price.cents", []string{"Line 1 unknown member of INTEGER: cents"}},
+		{"price.num + price.den", nil},
+		{"tags[name]", []string{"Line 1 index operator not supported: ARRAY[STRING]"}},
+		{"{tags: 1}", []string{"Line 1 unusable as hash key: ARRAY"}},
+		{"{1.5: 1}", []string{"Line 1 unusable as hash key: FLOAT"}},
+		{"{\"a\": 1}[tags]", []string{"Line 1 unusable as hash key: ARRAY"}},
+		// a diagnostic per failing operation, with its line
+		{"let total = price + true;\nlet label = name + 1;\ntotal", []string{
+			"Line 1 type mismatch: INTEGER + BOOLEAN",
+			"Line 2 type mismatch: STRING + INTEGER",
+		}},
+		// a failing operand is not reported twice
+		{"(price + true) * 2", []string{"Line 1 type mismatch: INTEGER + BOOLEAN"}},
+		// inferred variables and results
+		{"let total = price * 2; total + vip", []string{"Line 1 type mismatch: INTEGER + BOOLEAN"}},
+		{"let f = fn(x) { x * 2 }; f(1, 2)", []string{"Line 1 wrong number of arguments to f: want=1, got=2"}},
+		{"let f = fn() { \"a\" }; f() - 1", []string{"Line 1 type mismatch: STRING - INTEGER"}},
+		{"let f = fn(x) { if (x) { return 1; } return 2; }; f(vip) + \"a\"", []string{"Line 1 type mismatch: INTEGER + STRING"}},
+		{"let f = fn(x) { if (x) { return 1; } \"a\" }; f(vip) + 1", nil},
+		{"let n = 1 / 2; n + 1.5", nil},
+		// parameters are of unknown type
+		{"let f = fn(x, y) { x + y }; f(1, true)", nil},
+		{"fn(x) { x.y[0](1) }", nil},
+		// variables bound in branches may keep their former value
+		{"let x = 1; if (vip) { let x = \"a\"; }; x + 1", nil},
+		{"let x = 1; if (vip) { let x = 2; }; x + true", []string{"Line 1 type mismatch: INTEGER + BOOLEAN"}},
+		{"let x = if (vip) { 1 } else { 2 }; x - 1", nil},
+		{"let x = if (vip) { 1 }; x - 1", nil},
+		// closures see variables bound once, whenever they are called
+		{"let k = 1; let f = fn() { k + true }; f()", []string{"Line 1 type mismatch: INTEGER + BOOLEAN"}},
+		{"let k = 1; let f = fn() { k + true }; let k = true; f()", nil},
+		{"let f = fn() { g() }; let g = fn() { 1 }; f()", nil},
+		// lets hoist into the enclosing call
+		{"let f = fn() { if (vip) { let y = 1; } y }; f()", nil},
+		// catch clauses and imports
+		{"try { price / 0 } catch (e) { e.message }", nil},
+		{"try { 1 } catch (e) { e + 1 }", nil},
+		{"import \"lib\"; helper(1)", nil},
+		{"import \"lib\" as lib; lib.helper(1)", nil},
+		{"import \"lib\" as lib; lib + 1", []string{"Line 1 type mismatch: MODULE + INTEGER"}},
+	}
+
+	for _, tc := range testCases {
+		diagnostics := Check(parse(t, tc.input), schema)
+
+		got := []string{}
+		for _, d := range diagnostics {
+			got = append(got, d.Error())
+		}
+		if len(got) != len(tc.expected) {
+			t.Errorf("%s: expected %q, got %q", tc.input, tc.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("%s: expected %q, got %q", tc.input, tc.expected, got)
+				break
+			}
+		}
+	}
+}
+
+// TestErrors checks that the checker reports the error the evaluator
+// raises, when it does.
+func TestErrors(t *testing.T) {
+	inputs := []string{
+		"1 + true",
+		"\"a\" - \"b\"",
+		"-true",
+		"let a = [1];\na[\"x\"]",
+		"{[1]: 2}",
+		"let x = 1;\nx()",
+		"let f = fn() {\n  1\n};\nf() - \"a\"",
+		"1.5 + 0.5dec",
+		"5.field",
+		"missing + 1",
+	}
+
+	for _, input := range inputs {
+		obj := evaluator.Eval(parse(t, input), object.NewEnvironment())
+		err, ok := obj.(*object.Error)
+		if !ok {
+			t.Fatalf("%s: expected an error, got %s", input, obj.Inspect())
+		}
+
+		diagnostics := Check(parse(t, input), nil)
+		if len(diagnostics) == 0 {
+			t.Errorf("%s: expected %q, got nothing", input, err.Message)
+			continue
+		}
+		d := diagnostics[len(diagnostics)-1]
+		if d.Message != err.Message || d.Line != err.Line {
+			t.Errorf("%s: expected line %d %q, got %q", input, err.Line, err.Message, d.Error())
+		}
+	}
+}
+
+func TestFuncString(t *testing.T) {
+	testCases := []struct {
+		typ      Type
+		expected string
+	}{
+		{Integer, "INTEGER"},
+		{&Func{Params: []Type{Integer, Unknown}, Result: Boolean}, "fn(INTEGER, UNKNOWN) BOOLEAN"},
+		{&Func{Result: Number}, "fn(...) NUMBER"},
+		{&Func{Params: []Type{}, Result: &Func{Result: Null}}, "fn() fn(...) NULL"},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.typ.String(); got != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, got)
+		}
+	}
+}
+
+func TestCheckInfo(t *testing.T) {
+	program := parse(t, `let n = len(tags); let f = fn(x) { if (n) { x } }; f(name)`)
+
+	info := &Info{}
+	if diagnostics := CheckInfo(program, schema, info); len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics %v", diagnostics)
+	}
+
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"len(tags)", "INTEGER"},
+		{"n", "INTEGER"},
+		{"x", "UNKNOWN"},
+		{"f(name)", "UNKNOWN"},
+	}
+
+	for _, tc := range testCases {
+		found := false
+		for exp, typ := range info.Types {
+			if exp.String() != tc.source {
+				continue
+			}
+			found = true
+			if typ.String() != tc.expected {
+				t.Errorf("%s: expected %s, got %s", tc.source, tc.expected, typ)
+			}
+		}
+		if !found {
+			t.Errorf("%s: no type recorded", tc.source)
+		}
+	}
+}
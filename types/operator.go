@@ -0,0 +1,72 @@
+package types
+
+import "fmt"
+
+// infix returns the type of the value of left operator right, or the
+// error the evaluator raises for operands of these types.
+func infix(operator string, left, right Type) (Type, string) {
+	comparison := operator == "<" || operator == ">" || operator == "==" || operator == "!="
+
+	switch {
+	case operator == "==" || operator == "!=" || operator == "&&" || operator == "||":
+		return Boolean, ""
+	case left == Unknown || right == Unknown || isTemporal(left) || isTemporal(right):
+		if comparison {
+			return Boolean, ""
+		}
+		return Unknown, ""
+	case isNumeric(left) && isNumeric(right):
+		t := arithmetic(operator, left, right)
+		if t == nil {
+			return nil, mismatch(operator, left, right)
+		}
+		if comparison {
+			return Boolean, ""
+		}
+		return t, ""
+	case left == String && right == String && operator == "+":
+		return String, ""
+	}
+
+	return nil, mismatch(operator, left, right)
+}
+
+// arithmetic returns the type of the result of an arithmetic operator
+// applied to numbers, nil for a decimal and a float, which do not mix.
+func arithmetic(operator string, left, right Type) Type {
+	switch {
+	case left == Decimal && right == Float || left == Float && right == Decimal:
+		return nil
+	case left == Decimal || right == Decimal:
+		if left == Number || right == Number {
+			// a rational does not mix with a decimal either
+			return Unknown
+		}
+		return Decimal
+	case left == Float || right == Float:
+		return Float
+	case left == Integer && right == Integer && operator != "/":
+		return Integer
+	}
+	// the quotient of integers is a rational in rational mode
+	return Number
+}
+
+func mismatch(operator string, left, right Type) string {
+	if kind(left) != kind(right) {
+		return fmt.Sprintf("type mismatch: %s %s %s", kind(left), operator, kind(right))
+	}
+	return fmt.Sprintf("unknown operator: %s %s %s", kind(left), operator, kind(right))
+}
+
+// prefix returns the type of the value of operator right, or the error
+// the evaluator raises for an operand of this type.
+func prefix(operator string, right Type) (Type, string) {
+	switch {
+	case operator == "!":
+		return Boolean, ""
+	case right == Unknown || isNumeric(right) || right == Duration:
+		return right, ""
+	}
+	return nil, fmt.Sprintf("unknown operator: %s%s", operator, kind(right))
+}
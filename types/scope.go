@@ -0,0 +1,61 @@
+package types
+
+import (
+	"github.com/NilCent/eval/ast"
+	"github.com/NilCent/eval/resolver"
+)
+
+// scope holds the types of the variables of the program, of a function
+// call or of a catch clause, mirroring the environments of the
+// evaluator.
+type scope struct {
+	outer *scope
+	// vars holds the type of the variables bound so far.
+	vars map[string]Type
+	// declared counts the bindings of each variable of the scope,
+	// wherever they appear.
+	declared map[string]int
+	// imports is set when an import without alias binds unknown names
+	// in the scope.
+	imports bool
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, vars: make(map[string]Type), declared: make(map[string]int)}
+}
+
+// lookup returns the type of the variable name. A variable not bound
+// yet, or bound in an enclosing scope more than once and so of a type
+// depending on when it is read, is of unknown type. ok is false when no
+// scope declares name.
+func (s *scope) lookup(name string) (t Type, ok bool) {
+	for scope := s; scope != nil; scope = scope.outer {
+		if n := scope.declared[name]; n > 0 {
+			t, ok := scope.vars[name]
+			if !ok || scope != s && n > 1 {
+				return Unknown, true
+			}
+			return t, true
+		}
+		if scope.imports {
+			return Unknown, true
+		}
+	}
+	return nil, false
+}
+
+func (s *scope) define(name string) {
+	s.declared[name]++
+}
+
+// declare counts the variables declared by node in s, see
+// resolver.Declarations.
+func (s *scope) declare(node ast.Node) {
+	resolver.Declarations(node, func(decl ast.Statement, name *ast.Identifier) {
+		if name == nil {
+			s.imports = true
+			return
+		}
+		s.define(name.Value)
+	})
+}
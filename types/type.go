@@ -0,0 +1,137 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Type is the type of a value: a Basic type or a *Func.
+type Type interface {
+	String() string
+}
+
+type Basic int
+
+const (
+	// Unknown is the type of values the checker cannot tell, such as
+	// parameters. Operations on them are never reported.
+	Unknown Basic = iota
+	Null
+	Integer
+	Float
+	Decimal
+	// Number is the type of numbers of an unknown kind, such as the
+	// quotient of integers, an integer or a rational.
+	Number
+	Boolean
+	String
+	Duration
+	Time
+	Array
+	Hash
+	Module
+)
+
+var basicNames = []string{
+	Unknown:  "UNKNOWN",
+	Null:     "NULL",
+	Integer:  "INTEGER",
+	Float:    "FLOAT",
+	Decimal:  "DECIMAL",
+	Number:   "NUMBER",
+	Boolean:  "BOOLEAN",
+	String:   "STRING",
+	Duration: "DURATION",
+	Time:     "TIME",
+	Array:    "ARRAY",
+	Hash:     "HASH",
+	Module:   "MODULE",
+}
+
+func (b Basic) String() string { return basicNames[b] }
+
+// Func is the type of functions and builtins.
+type Func struct {
+	// Params are the types of the parameters, nil for a function
+	// accepting any number of arguments.
+	Params []Type
+	Result Type
+}
+
+func (f *Func) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("fn(")
+	if f.Params == nil {
+		out.WriteString("...")
+	} else {
+		params := []string{}
+		for _, p := range f.Params {
+			params = append(params, p.String())
+		}
+		out.WriteString(strings.Join(params, ", "))
+	}
+	out.WriteString(") ")
+	out.WriteString(f.Result.String())
+
+	return out.String()
+}
+
+// kind names the type of values as the runtime does in its errors.
+func kind(t Type) string {
+	if _, ok := t.(*Func); ok {
+		return "FUNCTION"
+	}
+	return t.String()
+}
+
+func isNumeric(t Type) bool {
+	switch t {
+	case Integer, Float, Decimal, Number:
+		return true
+	}
+	return false
+}
+
+func isTemporal(t Type) bool {
+	return t == Duration || t == Time
+}
+
+// hashable reports whether values of type t may be hash keys, as
+// integers, booleans and strings are.
+func hashable(t Type) bool {
+	switch t {
+	case Unknown, Integer, Number, Boolean, String:
+		return true
+	}
+	return false
+}
+
+// join returns the type of a value of type a or b.
+func join(a, b Type) Type {
+	switch {
+	case a == b:
+		return a
+	case isNumeric(a) && isNumeric(b) && a != Decimal && b != Decimal:
+		return Number
+	}
+	return Unknown
+}
+
+// assignable reports whether a value of type t may be passed for a
+// parameter of type param. Numbers of every kind are accepted for one
+// another, since builtins convert them.
+func assignable(t, param Type) bool {
+	if t == Unknown || param == Unknown {
+		return true
+	}
+	if isNumeric(t) && isNumeric(param) {
+		return true
+	}
+	_, fn := t.(*Func)
+	_, paramFn := param.(*Func)
+	if fn && paramFn {
+		return true
+	}
+	return t == param
+}
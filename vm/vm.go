@@ -0,0 +1,546 @@
+// Package vm runs the bytecode produced by the compiler package. It
+// agrees with the evaluator on the results and errors of every program.
+package vm
+
+import (
+	"fmt"
+
+	"github.com/NilCent/eval/code"
+	"github.com/NilCent/eval/compiler"
+	"github.com/NilCent/eval/evaluator"
+	"github.com/NilCent/eval/object"
+)
+
+// MaxFrames bounds the depth of nested calls.
+const MaxFrames = 1 << 20
+
+type Frame struct {
+	cl *object.Closure
+	// ip is the offset of the next instruction to execute.
+	ip int
+	// bp is the stack pointer when the frame was entered.
+	bp    int
+	scope *object.Scope
+}
+
+type VM struct {
+	main *object.CompiledFunction
+
+	env *object.Environment
+	rt  *object.Runtime
+	// scope is the scope of the top-level statements, whose variables
+	// live in env.
+	scope *object.Scope
+
+	stack []object.Object
+	sp    int
+
+	frames []Frame
+}
+
+// New prepares bytecode to run in env, where global variables are read
+// and written as they are by the evaluator.
+func New(bytecode *compiler.Bytecode, env *object.Environment) *VM {
+	return &VM{
+		main:   bytecode.Main,
+		env:    env,
+		rt:     env.Runtime(),
+		scope:  &object.Scope{},
+		stack:  make([]object.Object, 0, 64),
+		frames: make([]Frame, 0, 16),
+	}
+}
+
+// Run executes the program, returning the value of its last statement
+// or the error stopping it, like evaluator.Eval.
+func (vm *VM) Run() object.Object {
+	main := &object.Closure{Fn: vm.main, Scope: vm.scope}
+
+	result, _, err := vm.runBlock(main, vm.scope)
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// runBlock runs cl in a new frame until it returns, reporting whether
+// it did so through a return statement.
+func (vm *VM) runBlock(cl *object.Closure, scope *object.Scope) (object.Object, bool, *object.Error) {
+	if len(vm.frames) >= MaxFrames {
+		return nil, false, newError("stack overflow")
+	}
+	vm.frames = append(vm.frames, Frame{cl: cl, bp: vm.sp, scope: scope})
+	return vm.run(len(vm.frames) - 1)
+}
+
+func (vm *VM) run(base int) (object.Object, bool, *object.Error) {
+	frame := &vm.frames[len(vm.frames)-1]
+	ins := frame.cl.Fn.Instructions
+	constants := frame.cl.Fn.Constants
+
+	for {
+		op := code.Opcode(ins[frame.ip])
+		frame.ip++
+
+		switch op {
+		case code.OpConstant:
+			index := code.ReadUint16(ins[frame.ip:])
+			frame.ip += 2
+			vm.push(constants[index])
+
+		case code.OpNull:
+			vm.push(object.NULL)
+		case code.OpTrue:
+			vm.push(object.TRUE)
+		case code.OpFalse:
+			vm.push(object.FALSE)
+
+		case code.OpPop:
+			vm.sp--
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv,
+			code.OpEqual, code.OpNotEqual, code.OpLessThan, code.OpGreaterThan:
+			right := vm.stack[vm.sp-1]
+			left := vm.stack[vm.sp-2]
+			vm.sp -= 2
+
+			result := vm.infix(op, left, right)
+			if err, ok := result.(*object.Error); ok {
+				return vm.fail(err, base, true)
+			}
+			vm.push(result)
+
+		case code.OpMinus, code.OpBang:
+			operator := "-"
+			if op == code.OpBang {
+				operator = "!"
+			}
+			result := evaluator.Prefix(operator, vm.stack[vm.sp-1])
+			if err, ok := result.(*object.Error); ok {
+				return vm.fail(err, base, true)
+			}
+			vm.stack[vm.sp-1] = result
+
+		case code.OpJump:
+			frame.ip = int(code.ReadUint16(ins[frame.ip:]))
+
+		case code.OpJumpNotTruthy:
+			target := int(code.ReadUint16(ins[frame.ip:]))
+			frame.ip += 2
+			vm.sp--
+			if !evaluator.IsTruthy(vm.stack[vm.sp]) {
+				frame.ip = target
+			}
+
+		case code.OpGetGlobal:
+			name := constants[code.ReadUint16(ins[frame.ip:])].(*object.String).Value
+			frame.ip += 2
+
+			val, ok := vm.lookup(frame.scope, name, false)
+			if !ok {
+				return vm.fail(newError("identifier not found: "+name), base, true)
+			}
+			vm.push(val)
+
+		case code.OpSetGlobal:
+			name := constants[code.ReadUint16(ins[frame.ip:])].(*object.String).Value
+			frame.ip += 2
+			vm.sp--
+			vm.env.Set(name, vm.stack[vm.sp])
+
+		case code.OpGetLocal:
+			slot := int(code.ReadUint16(ins[frame.ip:]))
+			frame.ip += 2
+
+			val, err := vm.slot(frame.scope, slot)
+			if err != nil {
+				return vm.fail(err, base, true)
+			}
+			vm.push(val)
+
+		case code.OpSetLocal:
+			slot := code.ReadUint16(ins[frame.ip:])
+			frame.ip += 2
+			vm.sp--
+			frame.scope.Slots[slot] = vm.stack[vm.sp]
+
+		case code.OpGetOuter:
+			depth := int(code.ReadUint8(ins[frame.ip:]))
+			slot := int(code.ReadUint16(ins[frame.ip+1:]))
+			frame.ip += 3
+
+			scope := frame.scope
+			for ; depth > 0; depth-- {
+				scope = scope.Outer
+			}
+			val, err := vm.slot(scope, slot)
+			if err != nil {
+				return vm.fail(err, base, true)
+			}
+			vm.push(val)
+
+		case code.OpArray:
+			n := int(code.ReadUint16(ins[frame.ip:]))
+			frame.ip += 2
+
+			elements := make([]object.Object, n)
+			copy(elements, vm.stack[vm.sp-n:vm.sp])
+			vm.sp -= n
+			vm.push(&object.Array{Elements: elements})
+
+		case code.OpHashKey:
+			if key := vm.stack[vm.sp-1]; !isHashable(key) {
+				return vm.fail(newError("unusable as hash key: %s", key.Type()), base, true)
+			}
+
+		case code.OpHash:
+			n := int(code.ReadUint16(ins[frame.ip:]))
+			frame.ip += 2
+
+			pairs := make(map[object.HashKey]object.HashPair, n)
+			for i := vm.sp - 2*n; i < vm.sp; i += 2 {
+				key, value := vm.stack[i], vm.stack[i+1]
+				pairs[key.(object.Hashable).HashKey()] = object.HashPair{Key: key, Value: value}
+			}
+			vm.sp -= 2 * n
+			vm.push(&object.Hash{Pairs: pairs})
+
+		case code.OpIndex:
+			result := evaluator.Index(vm.stack[vm.sp-2], vm.stack[vm.sp-1])
+			if err, ok := result.(*object.Error); ok {
+				return vm.fail(err, base, true)
+			}
+			vm.sp--
+			vm.stack[vm.sp-1] = result
+
+		case code.OpMember:
+			name := constants[code.ReadUint16(ins[frame.ip:])].(*object.String).Value
+			frame.ip += 2
+
+			result := evaluator.Member(vm.stack[vm.sp-1], name)
+			if err, ok := result.(*object.Error); ok {
+				return vm.fail(err, base, true)
+			}
+			vm.stack[vm.sp-1] = result
+
+		case code.OpClosure:
+			fn := constants[code.ReadUint16(ins[frame.ip:])].(*object.CompiledFunction)
+			frame.ip += 2
+			vm.push(&object.Closure{Fn: fn, Scope: frame.scope})
+
+		case code.OpCall, code.OpTailCall:
+			argc := int(code.ReadUint8(ins[frame.ip:]))
+			frame.ip++
+
+			callee := vm.stack[vm.sp-1-argc]
+			cl, ok := callee.(*object.Closure)
+			if !ok {
+				result := vm.callObject(callee, argc)
+				if err, ok := result.(*object.Error); ok {
+					return vm.fail(err, base, true)
+				}
+				vm.sp -= argc + 1
+				vm.push(result)
+				continue
+			}
+
+			if argc != cl.Fn.NumParameters {
+				return vm.fail(newError("wrong number of arguments: want=%d, got=%d",
+					cl.Fn.NumParameters, argc), base, true)
+			}
+			if op == code.OpCall && len(vm.frames) >= MaxFrames {
+				return vm.fail(newError("stack overflow"), base, true)
+			}
+
+			scope := &object.Scope{
+				Slots: make([]object.Object, len(cl.Fn.Slots)),
+				Names: cl.Fn.Slots,
+				Outer: cl.Scope,
+			}
+			copy(scope.Slots, vm.stack[vm.sp-argc:vm.sp-argc+cl.Fn.NumParameters])
+			vm.sp -= argc + 1
+
+			if op == code.OpTailCall {
+				// the frame of the caller is left for the callee
+				vm.sp = frame.bp
+				*frame = Frame{cl: cl, bp: vm.sp, scope: scope}
+			} else {
+				vm.frames = append(vm.frames, Frame{cl: cl, bp: vm.sp, scope: scope})
+				frame = &vm.frames[len(vm.frames)-1]
+			}
+			ins = cl.Fn.Instructions
+			constants = cl.Fn.Constants
+
+		case code.OpReturnValue, code.OpReturn:
+			var result object.Object = object.NULL
+			if op == code.OpReturnValue {
+				vm.sp--
+				result = vm.stack[vm.sp]
+			} else if len(vm.frames)-1 == base {
+				// the top-level statements produced no value
+				result = nil
+			}
+
+			if len(vm.frames)-1 == base {
+				vm.sp = frame.bp
+				vm.frames = vm.frames[:base]
+				return result, true, nil
+			}
+
+			vm.sp = frame.bp
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			frame = &vm.frames[len(vm.frames)-1]
+			ins = frame.cl.Fn.Instructions
+			constants = frame.cl.Fn.Constants
+			vm.push(result)
+
+		case code.OpBlockEnd:
+			result := vm.stack[vm.sp-1]
+			vm.sp = frame.bp
+			vm.frames = vm.frames[:base]
+			return result, false, nil
+
+		case code.OpTry:
+			blockIndex := code.ReadUint16(ins[frame.ip:])
+			catchIndex := code.ReadUint16(ins[frame.ip+2:])
+			finallyIndex := code.ReadUint16(ins[frame.ip+4:])
+			frame.ip += 6
+
+			result, returned, err := vm.try(constants, frame.scope, blockIndex, catchIndex, finallyIndex)
+			// the frames slice may have been reallocated by the clauses
+			frame = &vm.frames[len(vm.frames)-1]
+			if err != nil {
+				return vm.fail(err, base, false)
+			}
+			if !returned {
+				vm.push(result)
+				continue
+			}
+
+			if len(vm.frames)-1 == base {
+				vm.sp = frame.bp
+				vm.frames = vm.frames[:base]
+				return result, true, nil
+			}
+			vm.sp = frame.bp
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			frame = &vm.frames[len(vm.frames)-1]
+			ins = frame.cl.Fn.Instructions
+			constants = frame.cl.Fn.Constants
+			vm.push(result)
+
+		case code.OpThrow:
+			vm.sp--
+			line := code.LineAt(frame.cl.Fn.Lines, frame.ip)
+			return vm.fail(evaluator.Throw(vm.stack[vm.sp], line), base, false)
+
+		case code.OpImport, code.OpImportAll:
+			path := constants[code.ReadUint16(ins[frame.ip:])].(*object.String).Value
+			frame.ip += 2
+
+			module, err := vm.importModule(path)
+			if err != nil {
+				return vm.fail(err, base, true)
+			}
+			if op == code.OpImport {
+				vm.push(module)
+				continue
+			}
+			vm.bind(frame.scope, module)
+
+		default:
+			return vm.fail(newError("unknown opcode %d", op), base, true)
+		}
+	}
+}
+
+func (vm *VM) push(obj object.Object) {
+	if vm.sp < len(vm.stack) {
+		vm.stack[vm.sp] = obj
+	} else {
+		vm.stack = append(vm.stack, obj)
+	}
+	vm.sp++
+}
+
+// fail abandons the frames of the current run and reports err. Errors
+// without a position get the line of the innermost instruction which
+// records one, as the evaluator stamps errors with the line of the
+// innermost node raising or propagating them. stamp is false when the
+// current instruction must not be considered.
+func (vm *VM) fail(err *object.Error, base int, stamp bool) (object.Object, bool, *object.Error) {
+	for i := len(vm.frames) - 1; i >= base && err.Line == 0; i-- {
+		if stamp || i < len(vm.frames)-1 {
+			err.Line = code.LineAt(vm.frames[i].cl.Fn.Lines, vm.frames[i].ip)
+		}
+	}
+
+	vm.sp = vm.frames[base].bp
+	vm.frames = vm.frames[:base]
+	return nil, false, err
+}
+
+// try runs the clauses of a try expression as the evaluator does: the
+// catch clause handles errors raised by the block, and a finally clause
+// which returns or fails replaces the outcome of the others.
+func (vm *VM) try(constants []object.Object, scope *object.Scope, blockIndex, catchIndex, finallyIndex uint16) (object.Object, bool, *object.Error) {
+	block := constants[blockIndex].(*object.CompiledFunction)
+	result, returned, err := vm.runBlock(&object.Closure{Fn: block, Scope: scope}, scope)
+
+	if err != nil && catchIndex != code.NoBlock {
+		catch := constants[catchIndex].(*object.CompiledFunction)
+		catchScope := &object.Scope{
+			Slots: make([]object.Object, len(catch.Slots)),
+			Names: catch.Slots,
+			Outer: scope,
+		}
+		catchScope.Slots[0] = &object.Exception{Message: err.Message, Line: err.Line, Value: err.Value}
+
+		result, returned, err = vm.runBlock(&object.Closure{Fn: catch, Scope: catchScope}, catchScope)
+	}
+
+	if finallyIndex != code.NoBlock {
+		finally := constants[finallyIndex].(*object.CompiledFunction)
+		final, finalReturned, finalErr := vm.runBlock(&object.Closure{Fn: finally, Scope: scope}, scope)
+		if finalErr != nil || finalReturned {
+			return final, finalReturned, finalErr
+		}
+	}
+
+	return result, returned, err
+}
+
+func (vm *VM) infix(op code.Opcode, left, right object.Object) object.Object {
+	if l, ok := left.(*object.Integer); ok {
+		if r, ok := right.(*object.Integer); ok {
+			// the common cases computed without overflow
+			switch op {
+			case code.OpAdd:
+				if sum := l.Value + r.Value; (sum > l.Value) == (r.Value > 0) {
+					return object.NativeInt(sum)
+				}
+			case code.OpSub:
+				if diff := l.Value - r.Value; (diff < l.Value) == (r.Value > 0) {
+					return object.NativeInt(diff)
+				}
+			case code.OpLessThan:
+				return object.NativeBool(l.Value < r.Value)
+			case code.OpGreaterThan:
+				return object.NativeBool(l.Value > r.Value)
+			case code.OpEqual:
+				return object.NativeBool(l.Value == r.Value)
+			case code.OpNotEqual:
+				return object.NativeBool(l.Value != r.Value)
+			}
+		}
+	}
+
+	return evaluator.Infix(operators[op], left, right, vm.rt)
+}
+
+var operators = map[code.Opcode]string{
+	code.OpAdd:         "+",
+	code.OpSub:         "-",
+	code.OpMul:         "*",
+	code.OpDiv:         "/",
+	code.OpEqual:       "==",
+	code.OpNotEqual:    "!=",
+	code.OpLessThan:    "<",
+	code.OpGreaterThan: ">",
+}
+
+// callObject calls a builtin or a function created by the evaluator,
+// such as those exported by modules.
+func (vm *VM) callObject(callee object.Object, argc int) object.Object {
+	switch callee.(type) {
+	case *object.Builtin, *object.Function:
+		args := make([]object.Object, argc)
+		copy(args, vm.stack[vm.sp-argc:vm.sp])
+		if result := evaluator.Apply(callee, args); result != nil {
+			return result
+		}
+		return object.NULL
+	}
+	return newError("not a function: %s", callee.Type())
+}
+
+// slot reads a variable of scope. Variables are declared ahead of
+// their let statement, so an unset slot falls back to the variables of
+// the same name in enclosing scopes.
+func (vm *VM) slot(scope *object.Scope, slot int) (object.Object, *object.Error) {
+	if val := scope.Slots[slot]; val != nil {
+		return val, nil
+	}
+
+	name := scope.Names[slot]
+	if val, ok := vm.lookup(scope.Outer, name, true); ok {
+		return val, nil
+	}
+	return nil, newError("identifier not found: " + name)
+}
+
+// lookup finds a variable by name in the names imported into scope and
+// its enclosing scopes, then in the environment and the builtins.
+// Slots are searched by name only when slots is true, since variables
+// resolved to a slot at compile time are not looked up by name.
+func (vm *VM) lookup(scope *object.Scope, name string, slots bool) (object.Object, bool) {
+	for s := scope; s != nil; s = s.Outer {
+		if val, ok := s.Bound[name]; ok {
+			return val, true
+		}
+		if !slots {
+			continue
+		}
+		for i, n := range s.Names {
+			if n == name && s.Slots[i] != nil {
+				return s.Slots[i], true
+			}
+		}
+	}
+
+	if val, ok := vm.env.Get(name); ok {
+		return val, true
+	}
+	if vm.rt != nil {
+		val, ok := vm.rt.Builtins[name]
+		return val, ok
+	}
+	return nil, false
+}
+
+func (vm *VM) importModule(path string) (*object.Module, *object.Error) {
+	if vm.rt == nil || vm.rt.Importer == nil {
+		return nil, newError("import not supported: %s", path)
+	}
+
+	module, err := vm.rt.Importer.Import(path)
+	if err != nil {
+		return nil, newError("%s", err)
+	}
+	return module, nil
+}
+
+// bind copies the names exported by module into scope, or into the
+// environment for the top-level statements.
+func (vm *VM) bind(scope *object.Scope, module *object.Module) {
+	for _, name := range module.Exports {
+		val, _ := module.Env.Get(name)
+		if scope == vm.scope {
+			vm.env.Set(name, val)
+			continue
+		}
+		if scope.Bound == nil {
+			scope.Bound = make(map[string]object.Object)
+		}
+		scope.Bound[name] = val
+	}
+}
+
+func isHashable(obj object.Object) bool {
+	_, ok := obj.(object.Hashable)
+	return ok
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,115 @@
+package vm
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/NilCent/eval/compiler"
+	"github.com/NilCent/eval/lexer"
+	"github.com/NilCent/eval/module"
+	"github.com/NilCent/eval/object"
+	"github.com/NilCent/eval/parser"
+	"github.com/NilCent/eval/resolver"
+)
+
+func compile(t *testing.T, input string) *compiler.Bytecode {
+	program, err := parser.New(lexer.New(input)).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse %q: %s", input, err)
+	}
+	// undefined names are left to the VM to report
+	resolver.Resolve(program, nil)
+
+	bytecode, err := compiler.Compile(program)
+	if err != nil {
+		t.Fatalf("compile %q: %s", input, err)
+	}
+	return bytecode
+}
+
+func TestRunReusesBytecode(t *testing.T) {
+	bytecode := compile(t, "let double = fn(x) { x * 2 }; double(price) + 1")
+
+	for price := int64(0); price < 5; price++ {
+		env := object.NewEnvironment()
+		env.Set("price", &object.Integer{Value: price})
+
+		result, ok := New(bytecode, env).Run().(*object.Integer)
+		if !ok || result.Value != price*2+1 {
+			t.Errorf("price %d: expected %d, got %#v", price, price*2+1, result)
+		}
+		if _, ok := env.Get("double"); !ok {
+			t.Errorf("expected top-level bindings in the environment")
+		}
+	}
+}
+
+func TestRunWithoutValue(t *testing.T) {
+	if result := New(compile(t, "let a = 1;"), object.NewEnvironment()).Run(); result != nil {
+		t.Errorf("expected no value, got %#v", result)
+	}
+}
+
+func TestImports(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib.eval": {Data: []byte(`export let inc = fn(x) { x + 1 }; export let two = 2;`)},
+	}
+
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{`import "lib"; inc(two)`, 3},
+		{`import "lib" as l; l.inc(l.two)`, 3},
+		{`let f = fn() { import "lib"; inc(two) }; f()`, 3},
+		{`let f = fn() { import "lib"; fn() { inc(1) } }; f()()`, 2},
+		{`let f = fn() { import "lib" as l; l.two }; f()`, 2},
+	}
+
+	for _, tc := range testCases {
+		rt := &object.Runtime{}
+		rt.Importer = module.NewRegistry(module.FS(fsys), rt)
+		env := object.NewEnvironment()
+		env.SetRuntime(rt)
+
+		result, ok := New(compile(t, tc.input), env).Run().(*object.Integer)
+		if !ok || result.Value != tc.expected {
+			t.Errorf("%s: expected %d, got %#v", tc.input, tc.expected, result)
+		}
+	}
+}
+
+func TestDeepRecursion(t *testing.T) {
+	input := "let count = fn(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } }; count(100000)"
+
+	result, ok := New(compile(t, input), object.NewEnvironment()).Run().(*object.Integer)
+	if !ok || result.Value != 100000 {
+		t.Errorf("expected 100000, got %#v", result)
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	input := "let f = fn() {\n  1 + f()\n}; f()"
+
+	errObj, ok := New(compile(t, input), object.NewEnvironment()).Run().(*object.Error)
+	if !ok || errObj.Message != "stack overflow" || errObj.Line != 2 {
+		t.Errorf("expected a stack overflow at line 2, got %#v", errObj)
+	}
+}
+
+func TestWrongNumberOfArguments(t *testing.T) {
+	errObj, ok := New(compile(t, "fn(a, b) { a }(1)"), object.NewEnvironment()).Run().(*object.Error)
+	if !ok || errObj.Message != "wrong number of arguments: want=2, got=1" {
+		t.Errorf("expected an arity error, got %#v", errObj)
+	}
+}
+
+func TestClosuresOutliveTheirProgram(t *testing.T) {
+	env := object.NewEnvironment()
+	New(compile(t, `let greet = fn(name) { "hello " + name };`), env).Run()
+
+	result, ok := New(compile(t, `let other = "unused"; greet("ann")`), env).Run().(*object.String)
+	if !ok || result.Value != "hello ann" {
+		t.Errorf("expected a greeting, got %#v", result)
+	}
+}